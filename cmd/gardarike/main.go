@@ -19,7 +19,8 @@ const (
 )
 
 var (
-	flagVersion bool = false
+	flagVersion      bool = false
+	flagPregenRadius int  = 0
 )
 
 func setupLogging() {
@@ -27,10 +28,33 @@ func setupLogging() {
 	log.SetLevel(log.DebugLevel)
 }
 
-func setupDefaults() {
-	viper.SetDefault("logic.WaterLevel", consts.DefaultWaterLevel)
-	viper.SetDefault("logic.ChunkSize", consts.DefaultMapChunkSize)
-	viper.SetDefault("logic.AlwaysRegenerateMap", consts.DefaultAlwaysRegenerateMap)
+// setupLogicDefaults - defaults are set on the [logic] section itself, defaults of the root config
+// are lost when the section is extracted
+func setupLogicDefaults(config *viper.Viper) {
+	config.SetDefault("AFKTimeout", time.Minute*10)
+	config.SetDefault("ChatMessageMaxLength", 200)
+	config.SetDefault("WaterLevel", consts.DefaultWaterLevel)
+	config.SetDefault("ChunkSize", consts.DefaultMapChunkSize)
+	config.SetDefault("AlwaysRegenerateMap", consts.DefaultAlwaysRegenerateMap)
+	config.SetDefault("MinTownDistance", consts.DefaultMinTownDistance)
+	config.SetDefault("MaxTownSlope", consts.DefaultMaxTownSlope)
+	config.SetDefault("SpawnSearchAttempts", consts.DefaultSpawnSearchAttempts)
+	config.SetDefault("MaxViewportChunks", consts.DefaultMaxViewportChunks)
+	config.SetDefault("ChunkCacheSize", consts.DefaultChunkCacheSize)
+	config.SetDefault("PregenRadius", consts.DefaultPregenRadius)
+	config.SetDefault("PregenWorkers", consts.DefaultPregenWorkers)
+	config.SetDefault("ChatRegionSize", consts.DefaultChatRegionSize)
+	config.SetDefault("ChatRateLimit", consts.DefaultChatRateLimit)
+	config.SetDefault("ChatRateWindow", consts.DefaultChatRateWindow)
+	config.SetDefault("ChatHistoryMaxPageSize", consts.DefaultChatHistoryMaxPageSize)
+	config.SetDefault("DirectMessagesMaxPageSize", consts.DefaultDirectMessagesMaxPageSize)
+	config.SetDefault("CaravanTileTravelTime", consts.DefaultCaravanTileTravelTime)
+	config.SetDefault("EventReplayBufferSize", consts.DefaultEventReplayBufferSize)
+	config.SetDefault("TechTreeFile", consts.DefaultTechTreeFile)
+	config.SetDefault("MarchMaxDistance", consts.DefaultMarchMaxDistance)
+	config.SetDefault("NewbieProtection", consts.DefaultNewbieProtection)
+	config.SetDefault("PeaceShieldDuration", consts.DefaultPeaceShieldDuration)
+	config.SetDefault("PeaceShieldGoldCost", consts.DefaultPeaceShieldGoldCost)
 }
 
 func setupConfig() error {
@@ -39,13 +63,19 @@ func setupConfig() error {
 	viper.AddConfigPath(".")
 	viper.AddConfigPath("configs/")
 
-	setupDefaults()
-
 	return viper.ReadInConfig()
 }
 
 func setupFlags() {
 	flag.BoolVar(&flagVersion, "version", false, "print version and exit")
+	flag.IntVar(&flagPregenRadius, "radius", 0,
+		"radius (in chunks) for the pregen command, PregenRadius from the config is used if not set")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [pregen]\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "  pregen\tgenerate map chunks around the spawn and exit")
+		flag.PrintDefaults()
+	}
 
 	flag.Parse()
 }
@@ -67,6 +97,8 @@ func parseServerConfig(config *viper.Viper) (result server.Config, err error) {
 		return result, fmt.Errorf("missing [server] section in the configuration")
 	}
 
+	config.SetDefault("EventsQueueSize", consts.DefaultEventsQueueSize)
+
 	if err := config.Unmarshal(&result); err != nil {
 		return result, fmt.Errorf("failed to parse [server] config section: %w", err)
 	}
@@ -102,8 +134,7 @@ func parseLogicConfig(config *viper.Viper) (result logic.Config, err error) {
 		return result, fmt.Errorf("missing [logic] section in the configuration")
 	}
 
-	config.SetDefault("AFKTimeout", time.Minute*10)
-	config.SetDefault("ChatMessageMaxLength", 200)
+	setupLogicDefaults(config)
 
 	if err := config.Unmarshal(&result); err != nil {
 		return result, fmt.Errorf("failed to parse [logic] config section: %w", err)
@@ -112,6 +143,31 @@ func parseLogicConfig(config *viper.Viper) (result logic.Config, err error) {
 	return
 }
 
+// runPregen - generates map chunks around the spawn without starting the server
+func runPregen(dbConfig postgres.Config, generatorConfig generation.TerrainGeneratorConfig, logicConfig logic.Config) error {
+	radius := logicConfig.PregenRadius
+	if flagPregenRadius > 0 {
+		radius = flagPregenRadius
+	}
+
+	if radius <= 0 {
+		return fmt.Errorf("pregen radius should be set via -radius flag or PregenRadius config variable")
+	}
+
+	database, err := postgres.NewDatabase(dbConfig)
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+
+	pregenerator := logic.NewChunkPregenerator(
+		database,
+		generation.NewSimplexTerrainGenerator(generatorConfig, time.Now().UnixNano()),
+		logic.NewChunkCache(0),
+		logicConfig)
+
+	return pregenerator.Pregenerate(radius)
+}
+
 func main() {
 	setupFlags()
 
@@ -147,6 +203,14 @@ func main() {
 		log.WithError(err).Fatal("Failed to parse logic config")
 	}
 
+	if flag.Arg(0) == "pregen" {
+		if err := runPregen(dbConfig, generatorConfig, logicConfig); err != nil {
+			log.WithError(err).Fatal("Failed to pregenerate map chunks")
+		}
+
+		os.Exit(0)
+	}
+
 	s, err := server.NewServer(serverConfig, logicConfig, dbConfig, generatorConfig)
 	if err != nil {
 		log.WithError(err).Fatalf("Failed to start server")
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"abbysoft/gardarike-online/logic"
+	"abbysoft/gardarike-online/model/consts"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+const repoRoot = "../.."
+
+// readExampleConfig - the example config has all the optional settings commented out,
+// so the server starts with the defaults only
+func readExampleConfig(t *testing.T) *viper.Viper {
+	config := viper.New()
+	config.SetConfigFile(filepath.Join(repoRoot, "configs", "config.example.toml"))
+	require.NoError(t, config.ReadInConfig())
+
+	return config
+}
+
+func TestParseLogicConfig_Defaults(t *testing.T) {
+	logicConfig, err := parseLogicConfig(readExampleConfig(t).Sub("logic"))
+	require.NoError(t, err)
+
+	require.Equal(t, float32(consts.DefaultMaxTownSlope), logicConfig.MaxTownSlope)
+	require.Equal(t, consts.DefaultSpawnSearchAttempts, logicConfig.SpawnSearchAttempts)
+	require.Equal(t, consts.DefaultMaxViewportChunks, logicConfig.MaxViewportChunks)
+	require.Equal(t, consts.DefaultChatHistoryMaxPageSize, logicConfig.ChatHistoryMaxPageSize)
+	require.Equal(t, consts.DefaultDirectMessagesMaxPageSize, logicConfig.DirectMessagesMaxPageSize)
+	require.Equal(t, consts.DefaultCaravanTileTravelTime, logicConfig.CaravanTileTravelTime)
+	require.Equal(t, consts.DefaultTechTreeFile, logicConfig.TechTreeFile)
+	require.Equal(t, consts.DefaultMarchMaxDistance, logicConfig.MarchMaxDistance)
+	require.Equal(t, consts.DefaultNewbieProtection, logicConfig.NewbieProtection)
+	require.Equal(t, consts.DefaultPeaceShieldDuration, logicConfig.PeaceShieldDuration)
+	require.Equal(t, uint64(consts.DefaultPeaceShieldGoldCost), logicConfig.PeaceShieldGoldCost)
+
+	_, err = logic.LoadTechTree(filepath.Join(repoRoot, logicConfig.TechTreeFile))
+	require.NoError(t, err)
+}
+
+func TestParseServerConfig_Defaults(t *testing.T) {
+	serverConfig, err := parseServerConfig(readExampleConfig(t).Sub("server"))
+	require.NoError(t, err)
+
+	require.Equal(t, consts.DefaultEventsQueueSize, serverConfig.EventsQueueSize)
+}
@@ -1,11 +1,14 @@
 package db
 
 import (
+	"abbysoft/gardarike-online/geometry"
 	"abbysoft/gardarike-online/model"
+	"time"
 )
 
 type CharacterDatabaseTransaction interface {
 	GetCharacter(id int64) (model.Character, error)
+	GetCharacterByName(name string) (model.Character, error)
 	AddCharacter(name string) (id int, err error)
 	AddAccountCharacter(characterID, accountID int) error
 	DeleteCharacter(id int64) error
@@ -13,6 +16,7 @@ type CharacterDatabaseTransaction interface {
 	UpdateCharacter(character model.Character) error
 	GetResources(characterID int64) (model.Resources, error)
 	GetProductionRates(characterID int64) (model.Resources, error)
+	GetCharacterBuildings(characterName string) (model.CharacterBuildings, error)
 }
 
 type AccountDatabaseTransaction interface {
@@ -22,27 +26,126 @@ type AccountDatabaseTransaction interface {
 
 type WorldDatabaseTransaction interface {
 	AddChatMessage(message model.ChatMessage) (int64, error)
-	GetChatMessages(offset int, count int) ([]model.ChatMessage, error)
+	GetChatMessages(channel string, beforeID int64, afterID int64, count int) ([]model.ChatMessage, error)
+	AddDirectMessage(message model.DirectMessage) (int64, error)
+	GetDirectMessages(characterName string, interlocutor string, offset int, count int) ([]model.DirectMessage, error)
+	GetConversations(characterName string) ([]model.Conversation, error)
+	MarkDirectMessagesRead(recipientName string, senderName string) error
+	GetChatMute(characterName string, now time.Time) (model.ChatMute, error)
+	AddChatMute(mute model.ChatMute) error
+	RemoveChatMute(characterName string) error
+	AddModerationLogEntry(entry model.ModerationLogEntry) error
+	GetModerationLog(offset int, count int) ([]model.ModerationLogEntry, error)
 	GetMapChunk(x, y int64) (model.WorldMapChunk, error)
+	GetMapChunkResources(x, y int64) (model.ChunkResources, error)
+	GetMapChunksResources() ([]model.WorldMapChunk, error)
+	SubtractMapChunkResources(x, y int64, resources model.ChunkResources) error
 	GetChunkRange() (model.ChunkRange, error)
 	IncrementMapResources(resources model.ChunkResources) error
 	SaveMapChunkOrUpdate(chunk model.WorldMapChunk) error
+	GetTown(id int64) (model.Town, error)
 	GetTowns(ownerName string) ([]model.Town, error)
 	GetAllTowns() ([]model.Town, error)
-	GetTownsForRect(xStart, xEnd, yStart, yEnd int) ([]model.Town, error)
-	AddOrUpdateResources(resources model.Resources) error
-	AddOrUpdateProductionRates(rates model.Resources) error
+	GetTownsForRect(rect geometry.Rect) ([]model.Town, error)
+	GetNewestCapitals(count int) ([]model.Town, error)
+	AddOrUpdateResources(characterID int64, resources model.Resources) error
+	AddCharacterResources(characterName string, resources model.Resources, capacity model.Resources) error
+	AddOrUpdateProductionRates(characterID int64, rates model.Resources) error
 	AddTown(town model.Town) error
 	AddTownBuilding(townID int64, building model.Building) error
 	GetAllBuildings() (map[int64]model.CharacterBuildings, error)
+	GetTownBuildings(townID int64) (model.CharacterBuildings, error)
+}
+
+type AllianceDatabaseTransaction interface {
+	AddAlliance(alliance model.Alliance) (int64, error)
+	GetAlliance(id int64) (model.Alliance, error)
+	GetAllianceByTag(tag string) (model.Alliance, error)
+	DeleteAlliance(id int64) error
+	AddAllianceMember(member model.AllianceMember) error
+	GetAllianceMember(characterName string) (model.AllianceMember, error)
+	GetAllianceMembers(allianceID int64) ([]model.AllianceMember, error)
+	UpdateAllianceMemberRank(characterName string, rank model.AllianceRank) error
+	RemoveAllianceMember(characterName string) error
+	AddAllianceInvite(invite model.AllianceInvite) error
+	GetAllianceInvite(allianceID int64, characterName string) (model.AllianceInvite, error)
+	RemoveAllianceInvites(characterName string) error
+}
+
+type DiplomacyDatabaseTransaction interface {
+	GetDiplomacyRelation(first model.DiplomacyParty, second model.DiplomacyParty) (model.DiplomacyRelation, error)
+	GetDiplomacyRelations(party model.DiplomacyParty) ([]model.DiplomacyRelation, error)
+	SetDiplomacyRelation(relation model.DiplomacyRelation) error
+	AddDiplomacyProposal(proposal model.DiplomacyProposal) (int64, error)
+	GetDiplomacyProposal(id int64) (model.DiplomacyProposal, error)
+	GetDiplomacyProposals(party model.DiplomacyParty) ([]model.DiplomacyProposal, error)
+	RemoveDiplomacyProposal(id int64) error
+	RemoveDiplomacyParty(party model.DiplomacyParty) error
+}
+
+type MarketDatabaseTransaction interface {
+	AddMarketOrder(order model.MarketOrder) (int64, error)
+	GetMarketOrder(id int64) (model.MarketOrder, error)
+	UpdateMarketOrder(order model.MarketOrder) error
+	GetMatchingMarketOrders(order model.MarketOrder, count int) ([]model.MarketOrder, error)
+	GetOrderBook(resource, priceResource model.ResourceType, side model.MarketOrderSide, depth int) ([]model.MarketOrder, error)
+	GetCharacterMarketOrders(characterID int64, offset int, count int) ([]model.MarketOrder, error)
+}
+
+type CaravanDatabaseTransaction interface {
+	AddCaravan(caravan model.Caravan) (int64, error)
+	UpdateCaravan(caravan model.Caravan) error
+	GetCharacterCaravans(characterID int64) ([]model.Caravan, error)
+	GetArrivedCaravans(now time.Time, count int) ([]model.Caravan, error)
+}
+
+type ResearchDatabaseTransaction interface {
+	AddResearch(research model.Research) error
+	DeleteResearch(characterID int64, tech string) error
+	CompleteResearch(research model.Research) error
+	GetActiveResearch(characterID int64) (model.Research, error)
+	GetCharacterTechs(characterID int64) (model.CharacterTechs, error)
+	GetFinishedResearch(now time.Time, count int) ([]model.Research, error)
+}
+
+type ArmyDatabaseTransaction interface {
+	AddUnitTraining(training model.UnitTraining) (int64, error)
+	DeleteUnitTraining(id int64) error
+	GetCharacterUnitTraining(characterID int64) ([]model.UnitTraining, error)
+	GetFinishedUnitTraining(now time.Time, count int) ([]model.UnitTraining, error)
+	AddTownUnits(townID int64, army model.Army) error
+	GetCharacterArmy(characterName string) (map[int64]model.Army, error)
+	RemoveTownUnits(townID int64, army model.Army) error
+	AddMarch(march model.March) (int64, error)
+	UpdateMarch(march model.March) error
+	DeleteMarch(id int64) error
+	GetMarch(id int64) (model.March, error)
+	GetCharacterMarches(characterID int64) ([]model.March, error)
+	GetMovingMarches() ([]model.March, error)
+	GetArrivedMarches(now time.Time, count int) ([]model.March, error)
+	UpdateMarchUnits(marchID int64, units model.Army) error
+}
+
+type BattleDatabaseTransaction interface {
+	AddBattleReport(report model.BattleReport) (int64, error)
+	GetBattleReports(characterID int64, offset int, count int) ([]model.BattleReport, error)
+	ChangeTownOwner(townID int64, ownerName string) error
 }
 
 type DatabaseTransaction interface {
 	CharacterDatabaseTransaction
 	AccountDatabaseTransaction
 	WorldDatabaseTransaction
+	AllianceDatabaseTransaction
+	DiplomacyDatabaseTransaction
+	MarketDatabaseTransaction
+	CaravanDatabaseTransaction
+	ResearchDatabaseTransaction
+	ArmyDatabaseTransaction
+	BattleDatabaseTransaction
 
 	EndTransaction() error
+	RollBack() error
 	IsCompleted() bool
 	IsFailed() bool
 	IsSucceed() bool
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS direct_messages
+(
+    id             serial       PRIMARY KEY,
+    sender_name    varchar(25)  NOT NULL,
+    recipient_name varchar(25)  NOT NULL,
+    text           varchar(200) NOT NULL,
+    is_read        bool         NOT NULL DEFAULT false,
+    created_at     timestamp    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS direct_messages_sender_idx ON direct_messages (sender_name, recipient_name);
+CREATE INDEX IF NOT EXISTS direct_messages_recipient_idx ON direct_messages (recipient_name, sender_name);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS chat_messages_channel_idx;
+
+ALTER TABLE chat_messages
+DROP COLUMN IF EXISTS channel;
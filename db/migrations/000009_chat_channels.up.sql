@@ -0,0 +1,4 @@
+ALTER TABLE chat_messages
+ADD COLUMN channel varchar(40) NOT NULL DEFAULT 'GLOBAL';
+
+CREATE INDEX IF NOT EXISTS chat_messages_channel_idx ON chat_messages (channel, message_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS moderation_log;
+DROP TABLE IF EXISTS chat_mutes;
+
+ALTER TABLE accounts
+DROP COLUMN IF EXISTS is_moderator;
@@ -0,0 +1,20 @@
+ALTER TABLE accounts
+ADD COLUMN is_moderator bool NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS chat_mutes
+(
+    character_name varchar(25)  PRIMARY KEY,
+    moderator_name varchar(25)  NOT NULL,
+    reason         varchar(200) NOT NULL DEFAULT '',
+    expires_at     timestamp    NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS moderation_log
+(
+    id             serial       PRIMARY KEY,
+    moderator_name varchar(25)  NOT NULL,
+    action         varchar(20)  NOT NULL,
+    target_name    varchar(25)  NOT NULL,
+    reason         varchar(200) NOT NULL DEFAULT '',
+    created_at     timestamp    NOT NULL DEFAULT now()
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS alliance_invites;
+DROP TABLE IF EXISTS alliance_members;
+DROP TABLE IF EXISTS alliances;
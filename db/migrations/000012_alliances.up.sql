@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS alliances
+(
+    id         serial      PRIMARY KEY,
+    name       varchar(40) UNIQUE NOT NULL,
+    tag        varchar(5)  UNIQUE NOT NULL,
+    created_at timestamp   NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS alliance_members
+(
+    character_name varchar(25) PRIMARY KEY,
+    alliance_id    int         NOT NULL REFERENCES alliances (id) ON DELETE CASCADE,
+    rank           smallint    NOT NULL DEFAULT 0,
+    joined_at      timestamp   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS alliance_members_alliance_idx ON alliance_members (alliance_id);
+
+CREATE TABLE IF NOT EXISTS alliance_invites
+(
+    alliance_id    int         NOT NULL REFERENCES alliances (id) ON DELETE CASCADE,
+    character_name varchar(25) NOT NULL,
+    inviter_name   varchar(25) NOT NULL,
+    created_at     timestamp   NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (alliance_id, character_name)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS diplomacy_proposals;
+DROP TABLE IF EXISTS diplomacy_relations;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS diplomacy_relations
+(
+    first_type  smallint    NOT NULL,
+    first_name  varchar(25) NOT NULL,
+    second_type smallint    NOT NULL,
+    second_name varchar(25) NOT NULL,
+    state       smallint    NOT NULL,
+    updated_at  timestamp   NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (first_type, first_name, second_type, second_name)
+);
+
+CREATE INDEX IF NOT EXISTS diplomacy_relations_second_idx ON diplomacy_relations (second_type, second_name);
+
+CREATE TABLE IF NOT EXISTS diplomacy_proposals
+(
+    id            serial      PRIMARY KEY,
+    from_type     smallint    NOT NULL,
+    from_name     varchar(25) NOT NULL,
+    to_type       smallint    NOT NULL,
+    to_name       varchar(25) NOT NULL,
+    state         smallint    NOT NULL,
+    proposer_name varchar(25) NOT NULL,
+    created_at    timestamp   NOT NULL DEFAULT now(),
+
+    UNIQUE (from_type, from_name, to_type, to_name)
+);
+
+CREATE INDEX IF NOT EXISTS diplomacy_proposals_to_idx ON diplomacy_proposals (to_type, to_name);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS market_orders
+(
+    id             serial      PRIMARY KEY,
+    character_id   int         NOT NULL,
+    character_name varchar(25) NOT NULL,
+    side           smallint    NOT NULL,
+    resource       smallint    NOT NULL,
+    price_resource smallint    NOT NULL,
+    price          bigint      NOT NULL,
+    amount         bigint      NOT NULL,
+    remaining      bigint      NOT NULL,
+    status         smallint    NOT NULL DEFAULT 0,
+    created_at     timestamp   NOT NULL DEFAULT now(),
+    updated_at     timestamp   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS market_orders_book_idx ON market_orders (resource, price_resource, side, price)
+    WHERE status = 0;
+CREATE INDEX IF NOT EXISTS market_orders_character_idx ON market_orders (character_id, id);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS caravans
+(
+    id            serial      PRIMARY KEY,
+    owner_id      int         NOT NULL,
+    owner_name    varchar(25) NOT NULL,
+    receiver_id   int         NOT NULL,
+    receiver_name varchar(25) NOT NULL,
+    from_town_id  int         NOT NULL,
+    to_town_id    int         NOT NULL,
+    wood          bigint      NOT NULL DEFAULT 0,
+    stone         bigint      NOT NULL DEFAULT 0,
+    food          bigint      NOT NULL DEFAULT 0,
+    leather       bigint      NOT NULL DEFAULT 0,
+    status        smallint    NOT NULL DEFAULT 0,
+    departed_at   timestamp   NOT NULL DEFAULT now(),
+    arrives_at    timestamp   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS caravans_arrival_idx ON caravans (arrives_at) WHERE status = 0;
+CREATE INDEX IF NOT EXISTS caravans_owner_idx ON caravans (owner_id) WHERE status = 0;
+CREATE INDEX IF NOT EXISTS caravans_receiver_idx ON caravans (receiver_id) WHERE status = 0;
@@ -0,0 +1,59 @@
+-- Resources of the types which didn't exist before are lost
+ALTER TABLE market_orders
+    ALTER COLUMN resource TYPE smallint USING (CASE resource
+        WHEN 'wood' THEN 0 WHEN 'stone' THEN 1 WHEN 'food' THEN 2 ELSE 3 END),
+    ALTER COLUMN price_resource TYPE smallint USING (CASE price_resource
+        WHEN 'wood' THEN 0 WHEN 'stone' THEN 1 WHEN 'food' THEN 2 ELSE 3 END);
+
+ALTER TABLE caravans
+    ADD COLUMN wood    bigint NOT NULL DEFAULT 0,
+    ADD COLUMN stone   bigint NOT NULL DEFAULT 0,
+    ADD COLUMN food    bigint NOT NULL DEFAULT 0,
+    ADD COLUMN leather bigint NOT NULL DEFAULT 0;
+
+UPDATE caravans c SET
+    wood = COALESCE((SELECT amount FROM caravan_resources r WHERE r.caravan_id = c.id AND r.resource = 'wood'), 0),
+    stone = COALESCE((SELECT amount FROM caravan_resources r WHERE r.caravan_id = c.id AND r.resource = 'stone'), 0),
+    food = COALESCE((SELECT amount FROM caravan_resources r WHERE r.caravan_id = c.id AND r.resource = 'food'), 0),
+    leather = COALESCE((SELECT amount FROM caravan_resources r WHERE r.caravan_id = c.id AND r.resource = 'leather'), 0);
+
+DROP TABLE IF EXISTS caravan_resources;
+
+ALTER TABLE resources RENAME TO resources_rows;
+ALTER TABLE production_rates RENAME TO production_rates_rows;
+
+CREATE TABLE IF NOT EXISTS resources
+(
+    character_id int PRIMARY KEY,
+    wood         int NOT NULL DEFAULT 0,
+    stone        int NOT NULL DEFAULT 0,
+    food         int NOT NULL DEFAULT 0,
+    leather      int NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS production_rates
+(
+    character_id int PRIMARY KEY,
+    wood         int NOT NULL DEFAULT 0,
+    stone        int NOT NULL DEFAULT 0,
+    food         int NOT NULL DEFAULT 0,
+    leather      int NOT NULL DEFAULT 0
+);
+
+INSERT INTO resources (character_id) SELECT id FROM characters;
+INSERT INTO production_rates (character_id) SELECT id FROM characters;
+
+UPDATE resources r SET
+    wood = COALESCE((SELECT amount FROM resources_rows s WHERE s.character_id = r.character_id AND s.resource = 'wood'), 0),
+    stone = COALESCE((SELECT amount FROM resources_rows s WHERE s.character_id = r.character_id AND s.resource = 'stone'), 0),
+    food = COALESCE((SELECT amount FROM resources_rows s WHERE s.character_id = r.character_id AND s.resource = 'food'), 0),
+    leather = COALESCE((SELECT amount FROM resources_rows s WHERE s.character_id = r.character_id AND s.resource = 'leather'), 0);
+
+UPDATE production_rates r SET
+    wood = COALESCE((SELECT amount FROM production_rates_rows s WHERE s.character_id = r.character_id AND s.resource = 'wood'), 0),
+    stone = COALESCE((SELECT amount FROM production_rates_rows s WHERE s.character_id = r.character_id AND s.resource = 'stone'), 0),
+    food = COALESCE((SELECT amount FROM production_rates_rows s WHERE s.character_id = r.character_id AND s.resource = 'food'), 0),
+    leather = COALESCE((SELECT amount FROM production_rates_rows s WHERE s.character_id = r.character_id AND s.resource = 'leather'), 0);
+
+DROP TABLE resources_rows;
+DROP TABLE production_rates_rows;
@@ -0,0 +1,59 @@
+-- Resources are stored as (owner, resource type, amount) rows, so new resource types don't need migrations
+ALTER TABLE resources RENAME TO resources_legacy;
+ALTER TABLE production_rates RENAME TO production_rates_legacy;
+
+CREATE TABLE IF NOT EXISTS resources
+(
+    character_id int         NOT NULL,
+    resource     varchar(32) NOT NULL,
+    amount       bigint      NOT NULL,
+
+    PRIMARY KEY (character_id, resource)
+);
+
+CREATE TABLE IF NOT EXISTS production_rates
+(
+    character_id int         NOT NULL,
+    resource     varchar(32) NOT NULL,
+    amount       bigint      NOT NULL,
+
+    PRIMARY KEY (character_id, resource)
+);
+
+CREATE TABLE IF NOT EXISTS caravan_resources
+(
+    caravan_id int         NOT NULL REFERENCES caravans (id) ON DELETE CASCADE,
+    resource   varchar(32) NOT NULL,
+    amount     bigint      NOT NULL,
+
+    PRIMARY KEY (caravan_id, resource)
+);
+
+INSERT INTO resources
+SELECT character_id, 'wood', wood FROM resources_legacy WHERE wood > 0
+UNION ALL SELECT character_id, 'stone', stone FROM resources_legacy WHERE stone > 0
+UNION ALL SELECT character_id, 'food', food FROM resources_legacy WHERE food > 0
+UNION ALL SELECT character_id, 'leather', leather FROM resources_legacy WHERE leather > 0;
+
+INSERT INTO production_rates
+SELECT character_id, 'wood', wood FROM production_rates_legacy WHERE wood > 0
+UNION ALL SELECT character_id, 'stone', stone FROM production_rates_legacy WHERE stone > 0
+UNION ALL SELECT character_id, 'food', food FROM production_rates_legacy WHERE food > 0
+UNION ALL SELECT character_id, 'leather', leather FROM production_rates_legacy WHERE leather > 0;
+
+INSERT INTO caravan_resources
+SELECT id, 'wood', wood FROM caravans WHERE wood > 0
+UNION ALL SELECT id, 'stone', stone FROM caravans WHERE stone > 0
+UNION ALL SELECT id, 'food', food FROM caravans WHERE food > 0
+UNION ALL SELECT id, 'leather', leather FROM caravans WHERE leather > 0;
+
+DROP TABLE resources_legacy;
+DROP TABLE production_rates_legacy;
+
+ALTER TABLE caravans DROP COLUMN wood, DROP COLUMN stone, DROP COLUMN food, DROP COLUMN leather;
+
+ALTER TABLE market_orders
+    ALTER COLUMN resource TYPE varchar(32) USING (CASE resource
+        WHEN 0 THEN 'wood' WHEN 1 THEN 'stone' WHEN 2 THEN 'food' ELSE 'leather' END),
+    ALTER COLUMN price_resource TYPE varchar(32) USING (CASE price_resource
+        WHEN 0 THEN 'wood' WHEN 1 THEN 'stone' WHEN 2 THEN 'food' ELSE 'leather' END);
@@ -0,0 +1,2 @@
+ALTER TABLE characters
+    DROP COLUMN tax_rate;
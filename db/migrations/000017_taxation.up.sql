@@ -0,0 +1,2 @@
+ALTER TABLE characters
+    ADD COLUMN tax_rate smallint NOT NULL DEFAULT 10;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS research
+(
+    character_id int         NOT NULL REFERENCES characters (id) ON DELETE CASCADE,
+    tech         varchar(64) NOT NULL,
+    started_at   timestamp   NOT NULL,
+    finishes_at  timestamp   NOT NULL,
+    completed    boolean     NOT NULL DEFAULT FALSE,
+
+    PRIMARY KEY (character_id, tech)
+);
+
+-- Only one research of the character can be in progress
+CREATE UNIQUE INDEX IF NOT EXISTS research_active_idx ON research (character_id) WHERE NOT completed;
+CREATE INDEX IF NOT EXISTS research_finishes_at_idx ON research (finishes_at) WHERE NOT completed;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS unit_training;
+DROP TABLE IF EXISTS town_units;
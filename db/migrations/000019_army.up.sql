@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS town_units
+(
+    town_id   int      NOT NULL REFERENCES towns (id) ON DELETE CASCADE,
+    unit_type smallint NOT NULL,
+    count     bigint   NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (town_id, unit_type)
+);
+
+CREATE TABLE IF NOT EXISTS unit_training
+(
+    id           serial    PRIMARY KEY,
+    character_id int       NOT NULL REFERENCES characters (id) ON DELETE CASCADE,
+    town_id      int       NOT NULL REFERENCES towns (id) ON DELETE CASCADE,
+    unit_type    smallint  NOT NULL,
+    count        bigint    NOT NULL,
+    started_at   timestamp NOT NULL,
+    finishes_at  timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS unit_training_finishes_at_idx ON unit_training (finishes_at);
+CREATE INDEX IF NOT EXISTS unit_training_character_idx ON unit_training (character_id);
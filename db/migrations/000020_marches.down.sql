@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS march_units;
+DROP TABLE IF EXISTS marches;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS marches
+(
+    id             serial      PRIMARY KEY,
+    character_id   int         NOT NULL REFERENCES characters (id) ON DELETE CASCADE,
+    character_name varchar(25) NOT NULL,
+    home_town_id   int         NOT NULL REFERENCES towns (id) ON DELETE CASCADE,
+    target_town_id int         NOT NULL DEFAULT 0,
+    from_x         bigint      NOT NULL,
+    from_y         bigint      NOT NULL,
+    to_x           bigint      NOT NULL,
+    to_y           bigint      NOT NULL,
+    status         smallint    NOT NULL DEFAULT 0,
+    departed_at    timestamp   NOT NULL,
+    arrives_at     timestamp   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS marches_arrival_idx ON marches (arrives_at) WHERE status = 0;
+CREATE INDEX IF NOT EXISTS marches_character_idx ON marches (character_id);
+
+CREATE TABLE IF NOT EXISTS march_units
+(
+    march_id  int      NOT NULL REFERENCES marches (id) ON DELETE CASCADE,
+    unit_type smallint NOT NULL,
+    count     bigint   NOT NULL,
+
+    PRIMARY KEY (march_id, unit_type)
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS battle_report_loot;
+DROP TABLE IF EXISTS battle_report_units;
+DROP TABLE IF EXISTS battle_reports;
+
+ALTER TABLE marches DROP COLUMN IF EXISTS capture;
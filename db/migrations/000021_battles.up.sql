@@ -0,0 +1,41 @@
+ALTER TABLE marches ADD COLUMN IF NOT EXISTS capture boolean NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS battle_reports
+(
+    id                serial           PRIMARY KEY,
+    attacker_id       int              NOT NULL REFERENCES characters (id) ON DELETE CASCADE,
+    attacker_name     varchar(25)      NOT NULL,
+    defender_id       int              NOT NULL REFERENCES characters (id) ON DELETE CASCADE,
+    defender_name     varchar(25)      NOT NULL,
+    town_id           int              NOT NULL,
+    town_name         varchar(40)      NOT NULL,
+    attacker_strength double precision NOT NULL,
+    defender_strength double precision NOT NULL,
+    attacker_won      boolean          NOT NULL,
+    captured          boolean          NOT NULL DEFAULT FALSE,
+    fought_at         timestamp        NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS battle_reports_attacker_idx ON battle_reports (attacker_id);
+CREATE INDEX IF NOT EXISTS battle_reports_defender_idx ON battle_reports (defender_id);
+
+-- Armies of both sides before the battle and their losses, side 0 is the attacker and 1 is the defender
+CREATE TABLE IF NOT EXISTS battle_report_units
+(
+    report_id int      NOT NULL REFERENCES battle_reports (id) ON DELETE CASCADE,
+    side      smallint NOT NULL,
+    unit_type smallint NOT NULL,
+    count     bigint   NOT NULL,
+    lost      bigint   NOT NULL,
+
+    PRIMARY KEY (report_id, side, unit_type)
+);
+
+CREATE TABLE IF NOT EXISTS battle_report_loot
+(
+    report_id int         NOT NULL REFERENCES battle_reports (id) ON DELETE CASCADE,
+    resource  varchar(32) NOT NULL,
+    amount    bigint      NOT NULL,
+
+    PRIMARY KEY (report_id, resource)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE characters
+    DROP COLUMN shield_until,
+    DROP COLUMN protected_until;
@@ -0,0 +1,3 @@
+ALTER TABLE characters
+    ADD COLUMN protected_until timestamp NOT NULL DEFAULT '0001-01-01 00:00:00',
+    ADD COLUMN shield_until    timestamp NOT NULL DEFAULT '0001-01-01 00:00:00';
@@ -2,13 +2,33 @@ package postgres
 
 import (
 	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/geometry"
 	"abbysoft/gardarike-online/model"
 	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	pq "github.com/lib/pq"
+	"math"
+	"time"
 )
 
+// selectTownsQuery - selects towns with the number of their buildings and the owner's alliance tag
+const selectTownsQuery = `SELECT t.*, 
+       (SELECT COUNT(*) FROM town_buildings tb WHERE tb.town_id = t.id) AS buildings_count,
+       COALESCE(a.tag, '') AS alliance_tag
+FROM towns t
+LEFT JOIN alliance_members am ON am.character_name = t.owner_name
+LEFT JOIN alliances a ON a.id = am.alliance_id`
+
+// selectCharactersQuery - selects characters with their account and alliance
+const selectCharactersQuery = `SELECT c.*, ac.account_id,
+       COALESCE(am.alliance_id, 0) AS alliance_id, COALESCE(a.tag, '') AS alliance_tag 
+FROM characters c
+JOIN account_characters ac ON c.id = ac.character_id
+LEFT JOIN alliance_members am ON am.character_name = c.name
+LEFT JOIN alliances a ON a.id = am.alliance_id`
+
 type DatabaseTransaction struct {
 	tx           *sqlx.Tx
 	autoCommit   bool
@@ -23,18 +43,139 @@ type allBuildingsRow struct {
 	Count       uint64 `db:"count"`
 }
 
-func (d *DatabaseTransaction) AddOrUpdateProductionRates(rates model.Resources) error {
-	_, err := d.tx.NamedExec(
-		`INSERT INTO production_rates VALUES (:character_id, :wood, :leather, :stone, :food) ON CONFLICT DO 
-    UPDATE SET wood=:wood, leather=:leather, food=:food, stone=:stone`, rates)
+type townUnitsRow struct {
+	TownID   int64 `db:"town_id"`
+	UnitType int32 `db:"unit_type"`
+	Count    uint64
+}
+
+type marchUnitsRow struct {
+	MarchID  int64 `db:"march_id"`
+	UnitType int32 `db:"unit_type"`
+	Count    uint64
+}
+
+type battleReportUnitsRow struct {
+	ReportID int64 `db:"report_id"`
+	Side     battleSide
+	UnitType int32 `db:"unit_type"`
+	Count    uint64
+	Lost     uint64
+}
+
+type battleReportLootRow struct {
+	ReportID int64 `db:"report_id"`
+	resourceRow
+}
+
+type resourceRow struct {
+	Resource model.ResourceType
+	Amount   uint64
+}
+
+type caravanResourceRow struct {
+	CaravanID int64 `db:"caravan_id"`
+	resourceRow
+}
+
+// selectResources - loads resource/amount rows selected by the query
+func (d *DatabaseTransaction) selectResources(query string, args ...interface{}) (model.Resources, error) {
+	var rows []resourceRow
+	if err := d.tx.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	result := model.Resources{}
+	for _, row := range rows {
+		result[row.Resource] = row.Amount
+	}
+
+	return result, nil
+}
+
+// resourceArrays - splits the resources to the arrays of types and amounts for the bulk insert
+func resourceArrays(resources model.Resources) (types pq.StringArray, amounts pq.Int64Array) {
+	for resourceType, amount := range resources {
+		if amount > 0 {
+			types = append(types, string(resourceType))
+			amounts = append(amounts, int64(amount))
+		}
+	}
+
+	return
+}
+
+// replaceResources - replaces the resource rows of the owner in the table,
+// ownerColumn is the table column referencing the owner
+func (d *DatabaseTransaction) replaceResources(table string, ownerColumn string, ownerID int64, resources model.Resources) error {
+	if _, err := d.tx.Exec("DELETE FROM "+table+" WHERE "+ownerColumn+" = $1", ownerID); err != nil {
+		return err
+	}
+
+	types, amounts := resourceArrays(resources)
+	if len(types) == 0 {
+		return nil
+	}
+
+	_, err := d.tx.Exec(
+		"INSERT INTO "+table+" ("+ownerColumn+", resource, amount) SELECT $1, unnest($2::varchar[]), unnest($3::bigint[])",
+		ownerID, types, amounts)
+	return err
+}
+
+func (d *DatabaseTransaction) AddOrUpdateProductionRates(characterID int64, rates model.Resources) error {
+	err := d.replaceResources("production_rates", "character_id", characterID, rates)
 	return d.handleError(err)
 }
 
 func (d *DatabaseTransaction) GetProductionRates(characterID int64) (result model.Resources, err error) {
-	err = d.tx.Get(&result, "SELECT * FROM production_rates WHERE character_id=$1", characterID)
+	result, err = d.selectResources("SELECT resource, amount FROM production_rates WHERE character_id=$1", characterID)
 	return result, d.handleError(err)
 }
 
+// GetCharacterBuildings - returns the number of buildings of every type in all towns of the character
+func (d *DatabaseTransaction) GetCharacterBuildings(characterName string) (result model.CharacterBuildings, err error) {
+	var rows []allBuildingsRow
+	err = d.tx.Select(&rows, `SELECT tb.building_id, COUNT(tb.building_id) FROM town_buildings tb 
+JOIN towns t ON tb.town_id = t.id 
+WHERE t.owner_name = $1
+GROUP BY tb.building_id`, characterName)
+
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+
+	result = make(model.CharacterBuildings)
+	for _, row := range rows {
+		if model.IsValidBuildingType(int32(row.BuildingID)) {
+			result[rpc.BuildingType(row.BuildingID)] = row.Count
+		}
+	}
+
+	return
+}
+
+// GetTownBuildings - returns the number of buildings of every type in the town
+func (d *DatabaseTransaction) GetTownBuildings(townID int64) (result model.CharacterBuildings, err error) {
+	var rows []allBuildingsRow
+	err = d.tx.Select(&rows, `SELECT building_id, COUNT(building_id) FROM town_buildings 
+WHERE town_id = $1
+GROUP BY building_id`, townID)
+
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+
+	result = make(model.CharacterBuildings)
+	for _, row := range rows {
+		if model.IsValidBuildingType(int32(row.BuildingID)) {
+			result[rpc.BuildingType(row.BuildingID)] = row.Count
+		}
+	}
+
+	return
+}
+
 func (d *DatabaseTransaction) GetAllBuildings() (result map[int64]model.CharacterBuildings, err error) {
 	var rows []allBuildingsRow
 	err = d.tx.Select(&rows, `select c.id character_id, tb.building_id, COUNT(tb.building_id) from town_buildings tb 
@@ -116,6 +257,25 @@ func (d *DatabaseTransaction) EndTransaction() error {
 	}
 }
 
+// RollBack - discards all changes of the transaction, does nothing if the transaction is completed already
+func (d *DatabaseTransaction) RollBack() error {
+	if d.IsCompleted() {
+		return nil
+	}
+
+	if d.tx == nil {
+		return fmt.Errorf("transaction is not started")
+	}
+
+	if err := d.tx.Rollback(); err != nil {
+		return fmt.Errorf("failed to roll back transaction: %w", err)
+	}
+
+	d.tx = nil
+	d.isRolledBack = true
+	return nil
+}
+
 type transactionFunc func(t *sqlx.Tx) error
 
 func (d *DatabaseTransaction) handleError(err error) error {
@@ -164,10 +324,18 @@ func (d *DatabaseTransaction) GetChunkRange() (result model.ChunkRange, err erro
 	return result, d.handleError(err)
 }
 
-func (d *DatabaseTransaction) GetTownsForRect(xStart, xEnd, yStart, yEnd int) (results []model.Town, err error) {
+func (d *DatabaseTransaction) GetTownsForRect(rect geometry.Rect) (results []model.Town, err error) {
+	err = d.tx.Select(&results,
+		selectTownsQuery+" WHERE (t.x BETWEEN $1 AND $2) AND (t.y BETWEEN $3 AND $4)",
+		rect.MinX, rect.MaxX, rect.MinY, rect.MaxY)
+	return results, d.handleError(err)
+}
+
+// GetNewestCapitals - returns first towns of the most recently joined characters
+func (d *DatabaseTransaction) GetNewestCapitals(count int) (results []model.Town, err error) {
 	err = d.tx.Select(&results,
-		"SELECT * FROM towns WHERE (x BETWEEN $1 AND $2) AND (y BETWEEN $3 AND $4)",
-		xStart, xEnd, yStart, yEnd)
+		`SELECT * FROM (`+selectTownsQuery+` WHERE t.id IN (SELECT MIN(id) FROM towns GROUP BY owner_name)) capitals 
+ORDER BY id DESC LIMIT $1`, count)
 	return results, d.handleError(err)
 }
 
@@ -177,16 +345,40 @@ func (d *DatabaseTransaction) AddTown(town model.Town) error {
 	return d.handleError(err)
 }
 
-func (d *DatabaseTransaction) AddOrUpdateResources(resources model.Resources) error {
-	_, err := d.tx.NamedExec(
-		`INSERT INTO resources VALUES (:character_id, :wood, :stone, :food, :leather) ON CONFLICT DO 
-    UPDATE SET stone = :stone, food = :food, leather = :leather, wood = :wood`, resources)
+func (d *DatabaseTransaction) AddOrUpdateResources(characterID int64, resources model.Resources) error {
+	err := d.replaceResources("resources", "character_id", characterID, resources)
 	return d.handleError(err)
 }
 
-func (d *DatabaseTransaction) GetResources(characterID int64) (result model.Resources, err error) {
-	err = d.tx.Get(&result, "SELECT * FROM resources WHERE character_id=$1", characterID)
+// AddCharacterResources - increments resources of the character, every resource is limited by
+// the capacity, resources which are already above the capacity are kept as is. Nil capacity doesn't limit them
+func (d *DatabaseTransaction) AddCharacterResources(characterName string, resources model.Resources, capacity model.Resources) error {
+	for resourceType, amount := range resources {
+		if amount == 0 {
+			continue
+		}
+
+		limit := int64(math.MaxInt64)
+		if capacity != nil {
+			limit = int64(capacity[resourceType])
+		}
+
+		_, err := d.tx.Exec(
+			`INSERT INTO resources (character_id, resource, amount) 
+SELECT c.id, $2, LEAST($3::bigint, $4::bigint) FROM characters c WHERE c.name = $1 AND $4 > 0
+ON CONFLICT (character_id, resource) DO UPDATE 
+SET amount = GREATEST(resources.amount, LEAST(resources.amount + $3, $4))`,
+			characterName, resourceType, int64(amount), limit)
+		if err != nil {
+			return d.handleError(err)
+		}
+	}
 
+	return nil
+}
+
+func (d *DatabaseTransaction) GetResources(characterID int64) (result model.Resources, err error) {
+	result, err = d.selectResources("SELECT resource, amount FROM resources WHERE character_id=$1", characterID)
 	return result, d.handleError(err)
 }
 
@@ -203,12 +395,17 @@ func (d *DatabaseTransaction) AddAccount(login string, password string, salt str
 }
 
 func (d *DatabaseTransaction) GetAllTowns() (result []model.Town, err error) {
-	err = d.tx.Select(&result, "SELECT * FROM towns")
+	err = d.tx.Select(&result, selectTownsQuery)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetTown(id int64) (result model.Town, err error) {
+	err = d.tx.Get(&result, selectTownsQuery+" WHERE t.id=$1", id)
 	return result, d.handleError(err)
 }
 
 func (d *DatabaseTransaction) GetTowns(ownerName string) (result []model.Town, err error) {
-	err = d.tx.Select(&result, "SELECT * FROM towns WHERE owner_name=$1", ownerName)
+	err = d.tx.Select(&result, selectTownsQuery+" WHERE t.owner_name=$1", ownerName)
 	return result, d.handleError(err)
 }
 
@@ -230,44 +427,734 @@ func (d *DatabaseTransaction) GetMapChunk(x, y int64) (result model.WorldMapChun
 	return result, d.handleError(err)
 }
 
-func (d *DatabaseTransaction) GetChatMessages(offset int, count int) (result []model.ChatMessage, err error) {
-	err = d.tx.Select(&result,
-		"SELECT * FROM chat_messages ORDER BY message_id DESC OFFSET $1 LIMIT $2",
-		offset, count)
+// GetMapChunkResources - returns resources of the chunk without the terrain data
+func (d *DatabaseTransaction) GetMapChunkResources(x, y int64) (result model.ChunkResources, err error) {
+	err = d.tx.Get(&result, "SELECT trees, stones, animals, plants FROM chunks WHERE x=$1 AND y=$2", x, y)
+	return result, d.handleError(err)
+}
+
+// GetMapChunksResources - returns all chunks without the terrain data
+func (d *DatabaseTransaction) GetMapChunksResources() (result []model.WorldMapChunk, err error) {
+	err = d.tx.Select(&result, "SELECT x, y, trees, stones, animals, plants FROM chunks")
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) SubtractMapChunkResources(x, y int64, resources model.ChunkResources) error {
+	_, err := d.tx.Exec(
+		`UPDATE chunks SET 
+			trees = GREATEST(trees - $3, 0), 
+			stones = GREATEST(stones - $4, 0), 
+			animals = GREATEST(animals - $5, 0), 
+			plants = GREATEST(plants - $6, 0)
+		WHERE x = $1 AND y = $2`,
+		x, y, resources.Trees, resources.Stones, resources.Animals, resources.Plants)
+	return d.handleError(err)
+}
+
+// GetChatMessages - returns up to 'count' channel messages between the cursors (zero cursor isn't applied).
+// If afterID is set the messages closest to it are returned, otherwise the messages closest to beforeID.
+// Messages are sorted from newest to oldest
+func (d *DatabaseTransaction) GetChatMessages(
+	channel string, beforeID int64, afterID int64, count int) (result []model.ChatMessage, err error) {
+	const pageQuery = `SELECT * FROM chat_messages 
+WHERE channel = $1 AND ($2 = 0 OR message_id < $2) AND ($3 = 0 OR message_id > $3)`
+
+	if afterID != 0 {
+		err = d.tx.Select(&result,
+			"SELECT * FROM ("+pageQuery+" ORDER BY message_id ASC LIMIT $4) page ORDER BY message_id DESC",
+			channel, beforeID, afterID, count)
+	} else {
+		err = d.tx.Select(&result, pageQuery+" ORDER BY message_id DESC LIMIT $4",
+			channel, beforeID, afterID, count)
+	}
+
 	return result, d.handleError(err)
 }
 
 func (d *DatabaseTransaction) AddChatMessage(message model.ChatMessage) (id int64, err error) {
 	err = d.tx.Get(&id,
-		"INSERT INTO chat_messages (sender_name, text) VALUES ($1, $2) RETURNING message_id",
-		message.Sender, message.Text)
+		"INSERT INTO chat_messages (sender_name, text, channel, is_system) VALUES ($1, $2, $3, $4) RETURNING message_id",
+		message.Sender, message.Text, message.Channel, message.IsSystem)
+	return id, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddDirectMessage(message model.DirectMessage) (id int64, err error) {
+	err = d.tx.Get(&id,
+		`INSERT INTO direct_messages (sender_name, recipient_name, text, created_at) 
+VALUES ($1, $2, $3, $4) RETURNING id`,
+		message.Sender, message.Recipient, message.Text, message.CreatedAt)
+	return id, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetDirectMessages(
+	characterName string, interlocutor string, offset int, count int) (result []model.DirectMessage, err error) {
+	err = d.tx.Select(&result,
+		`SELECT * FROM direct_messages 
+WHERE (sender_name = $1 AND recipient_name = $2) OR (sender_name = $2 AND recipient_name = $1)
+ORDER BY id DESC OFFSET $3 LIMIT $4`,
+		characterName, interlocutor, offset, count)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetConversations(characterName string) (result []model.Conversation, err error) {
+	err = d.tx.Select(&result,
+		`SELECT latest.*, COALESCE(unread.count, 0) AS unread_count FROM (
+    SELECT DISTINCT ON (m.interlocutor) m.* FROM (
+        SELECT dm.*, CASE WHEN dm.sender_name = $1 THEN dm.recipient_name ELSE dm.sender_name END AS interlocutor
+        FROM direct_messages dm WHERE dm.sender_name = $1 OR dm.recipient_name = $1) m
+    ORDER BY m.interlocutor, m.id DESC) latest
+LEFT JOIN (
+    SELECT sender_name, COUNT(*) AS count FROM direct_messages 
+    WHERE recipient_name = $1 AND NOT is_read GROUP BY sender_name) unread
+ON unread.sender_name = latest.interlocutor
+ORDER BY latest.id DESC`, characterName)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) MarkDirectMessagesRead(recipientName string, senderName string) error {
+	_, err := d.tx.Exec(
+		"UPDATE direct_messages SET is_read = true WHERE recipient_name = $1 AND sender_name = $2 AND NOT is_read",
+		recipientName, senderName)
+	return d.handleError(err)
+}
+
+// GetChatMute - returns the character mute which expires after 'now'
+func (d *DatabaseTransaction) GetChatMute(characterName string, now time.Time) (result model.ChatMute, err error) {
+	err = d.tx.Get(&result,
+		"SELECT * FROM chat_mutes WHERE character_name = $1 AND expires_at > $2", characterName, now)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddChatMute(mute model.ChatMute) error {
+	_, err := d.tx.NamedExec(
+		`INSERT INTO chat_mutes VALUES (:character_name, :moderator_name, :reason, :expires_at) 
+ON CONFLICT (character_name) DO UPDATE 
+SET moderator_name=:moderator_name, reason=:reason, expires_at=:expires_at`, mute)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) RemoveChatMute(characterName string) error {
+	_, err := d.tx.Exec("DELETE FROM chat_mutes WHERE character_name = $1", characterName)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddModerationLogEntry(entry model.ModerationLogEntry) error {
+	_, err := d.tx.NamedExec(
+		`INSERT INTO moderation_log (moderator_name, action, target_name, reason, created_at) 
+VALUES (:moderator_name, :action, :target_name, :reason, :created_at)`, entry)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetModerationLog(offset int, count int) (result []model.ModerationLogEntry, err error) {
+	err = d.tx.Select(&result, "SELECT * FROM moderation_log ORDER BY id DESC OFFSET $1 LIMIT $2", offset, count)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddAlliance(alliance model.Alliance) (id int64, err error) {
+	err = d.tx.Get(&id,
+		"INSERT INTO alliances (name, tag, created_at) VALUES ($1, $2, $3) RETURNING id",
+		alliance.Name, alliance.Tag, alliance.CreatedAt)
+	return id, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetAlliance(id int64) (result model.Alliance, err error) {
+	err = d.tx.Get(&result, "SELECT * FROM alliances WHERE id = $1", id)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetAllianceByTag(tag string) (result model.Alliance, err error) {
+	err = d.tx.Get(&result, "SELECT * FROM alliances WHERE tag = $1", tag)
+	return result, d.handleError(err)
+}
+
+// DeleteAlliance - removes the alliance with all its members and invites
+func (d *DatabaseTransaction) DeleteAlliance(id int64) error {
+	_, err := d.tx.Exec("DELETE FROM alliances WHERE id = $1", id)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddAllianceMember(member model.AllianceMember) error {
+	_, err := d.tx.NamedExec(
+		`INSERT INTO alliance_members (character_name, alliance_id, rank, joined_at) 
+VALUES (:character_name, :alliance_id, :rank, :joined_at)`, member)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetAllianceMember(characterName string) (result model.AllianceMember, err error) {
+	err = d.tx.Get(&result, "SELECT * FROM alliance_members WHERE character_name = $1", characterName)
+	return result, d.handleError(err)
+}
+
+// GetAllianceMembers - returns members sorted by rank, the leader goes first
+func (d *DatabaseTransaction) GetAllianceMembers(allianceID int64) (result []model.AllianceMember, err error) {
+	err = d.tx.Select(&result,
+		"SELECT * FROM alliance_members WHERE alliance_id = $1 ORDER BY rank DESC, joined_at", allianceID)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) UpdateAllianceMemberRank(characterName string, rank model.AllianceRank) error {
+	_, err := d.tx.Exec("UPDATE alliance_members SET rank = $2 WHERE character_name = $1", characterName, rank)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) RemoveAllianceMember(characterName string) error {
+	_, err := d.tx.Exec("DELETE FROM alliance_members WHERE character_name = $1", characterName)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddAllianceInvite(invite model.AllianceInvite) error {
+	_, err := d.tx.NamedExec(
+		`INSERT INTO alliance_invites VALUES (:alliance_id, :character_name, :inviter_name, :created_at) 
+ON CONFLICT (alliance_id, character_name) DO UPDATE SET inviter_name=:inviter_name, created_at=:created_at`, invite)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetAllianceInvite(allianceID int64, characterName string) (result model.AllianceInvite, err error) {
+	err = d.tx.Get(&result,
+		"SELECT * FROM alliance_invites WHERE alliance_id = $1 AND character_name = $2", allianceID, characterName)
+	return result, d.handleError(err)
+}
+
+// RemoveAllianceInvites - removes invites of the character to all alliances
+func (d *DatabaseTransaction) RemoveAllianceInvites(characterName string) error {
+	_, err := d.tx.Exec("DELETE FROM alliance_invites WHERE character_name = $1", characterName)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetDiplomacyRelation(
+	first model.DiplomacyParty, second model.DiplomacyParty) (result model.DiplomacyRelation, err error) {
+	key := model.NewDiplomacyRelation(first, second, model.DiplomacyNeutral)
+	err = d.tx.Get(&result,
+		`SELECT * FROM diplomacy_relations 
+WHERE first_type = $1 AND first_name = $2 AND second_type = $3 AND second_name = $4`,
+		key.FirstType, key.FirstName, key.SecondType, key.SecondName)
+	return result, d.handleError(err)
+}
+
+// GetDiplomacyRelations - returns all relations of the party
+func (d *DatabaseTransaction) GetDiplomacyRelations(party model.DiplomacyParty) (result []model.DiplomacyRelation, err error) {
+	err = d.tx.Select(&result,
+		`SELECT * FROM diplomacy_relations 
+WHERE (first_type = $1 AND first_name = $2) OR (second_type = $1 AND second_name = $2)`,
+		party.Type, party.Name)
+	return result, d.handleError(err)
+}
+
+// SetDiplomacyRelation - saves the relation, relations in the neutral state aren't stored
+func (d *DatabaseTransaction) SetDiplomacyRelation(relation model.DiplomacyRelation) (err error) {
+	if relation.State == model.DiplomacyNeutral {
+		_, err = d.tx.NamedExec(
+			`DELETE FROM diplomacy_relations WHERE first_type = :first_type AND first_name = :first_name 
+AND second_type = :second_type AND second_name = :second_name`, relation)
+	} else {
+		_, err = d.tx.NamedExec(
+			`INSERT INTO diplomacy_relations VALUES 
+(:first_type, :first_name, :second_type, :second_name, :state, :updated_at) 
+ON CONFLICT (first_type, first_name, second_type, second_name) DO UPDATE 
+SET state = :state, updated_at = :updated_at`, relation)
+	}
+
+	return d.handleError(err)
+}
+
+// AddDiplomacyProposal - saves the proposal replacing the previous proposal between the same parties
+func (d *DatabaseTransaction) AddDiplomacyProposal(proposal model.DiplomacyProposal) (id int64, err error) {
+	err = d.tx.Get(&id,
+		`INSERT INTO diplomacy_proposals (from_type, from_name, to_type, to_name, state, proposer_name, created_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+ON CONFLICT (from_type, from_name, to_type, to_name) DO UPDATE 
+SET state = $5, proposer_name = $6, created_at = $7 RETURNING id`,
+		proposal.FromType, proposal.FromName, proposal.ToType, proposal.ToName,
+		proposal.State, proposal.ProposerName, proposal.CreatedAt)
+	return id, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetDiplomacyProposal(id int64) (result model.DiplomacyProposal, err error) {
+	err = d.tx.Get(&result, "SELECT * FROM diplomacy_proposals WHERE id = $1", id)
+	return result, d.handleError(err)
+}
+
+// GetDiplomacyProposals - returns proposals made by the party or to the party, the newest go first
+func (d *DatabaseTransaction) GetDiplomacyProposals(party model.DiplomacyParty) (result []model.DiplomacyProposal, err error) {
+	err = d.tx.Select(&result,
+		`SELECT * FROM diplomacy_proposals 
+WHERE (from_type = $1 AND from_name = $2) OR (to_type = $1 AND to_name = $2) ORDER BY id DESC`,
+		party.Type, party.Name)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) RemoveDiplomacyProposal(id int64) error {
+	_, err := d.tx.Exec("DELETE FROM diplomacy_proposals WHERE id = $1", id)
+	return d.handleError(err)
+}
+
+// RemoveDiplomacyParty - removes all relations and proposals of the party
+func (d *DatabaseTransaction) RemoveDiplomacyParty(party model.DiplomacyParty) error {
+	_, err := d.tx.Exec(
+		`DELETE FROM diplomacy_relations 
+WHERE (first_type = $1 AND first_name = $2) OR (second_type = $1 AND second_name = $2)`,
+		party.Type, party.Name)
+	if err != nil {
+		return d.handleError(err)
+	}
+
+	_, err = d.tx.Exec(
+		`DELETE FROM diplomacy_proposals 
+WHERE (from_type = $1 AND from_name = $2) OR (to_type = $1 AND to_name = $2)`,
+		party.Type, party.Name)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddMarketOrder(order model.MarketOrder) (id int64, err error) {
+	err = d.tx.Get(&id,
+		`INSERT INTO market_orders (character_id, character_name, side, resource, price_resource, 
+                           price, amount, remaining, status, created_at, updated_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		order.CharacterID, order.CharacterName, order.Side, order.Resource, order.PriceResource,
+		order.Price, order.Amount, order.Remaining, order.Status, order.CreatedAt, order.UpdatedAt)
+	return id, d.handleError(err)
+}
+
+// GetMarketOrder - returns the order locked until the end of the transaction
+func (d *DatabaseTransaction) GetMarketOrder(id int64) (result model.MarketOrder, err error) {
+	err = d.tx.Get(&result, "SELECT * FROM market_orders WHERE id = $1 FOR UPDATE", id)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) UpdateMarketOrder(order model.MarketOrder) error {
+	_, err := d.tx.NamedExec(
+		"UPDATE market_orders SET remaining = :remaining, status = :status, updated_at = :updated_at WHERE id = :id",
+		order)
+	return d.handleError(err)
+}
+
+// GetMatchingMarketOrders - returns up to 'count' open opposite orders of other characters
+// which price is acceptable for the order, the best prices and then the oldest orders go first.
+// Returned orders are locked until the end of the transaction
+func (d *DatabaseTransaction) GetMatchingMarketOrders(order model.MarketOrder, count int) (result []model.MarketOrder, err error) {
+	const matchingQuery = `SELECT * FROM market_orders 
+WHERE resource = $1 AND price_resource = $2 AND side = $3 AND status = $4 AND character_id <> $5 AND `
+
+	if order.Side == model.MarketOrderBuy {
+		err = d.tx.Select(&result, matchingQuery+"price <= $6 ORDER BY price ASC, id ASC LIMIT $7 FOR UPDATE",
+			order.Resource, order.PriceResource, model.MarketOrderSell, model.MarketOrderOpen,
+			order.CharacterID, order.Price, count)
+	} else {
+		err = d.tx.Select(&result, matchingQuery+"price >= $6 ORDER BY price DESC, id ASC LIMIT $7 FOR UPDATE",
+			order.Resource, order.PriceResource, model.MarketOrderBuy, model.MarketOrderOpen,
+			order.CharacterID, order.Price, count)
+	}
+
+	return result, d.handleError(err)
+}
+
+// GetOrderBook - returns up to 'depth' open orders of the side, the best prices go first
+func (d *DatabaseTransaction) GetOrderBook(
+	resource, priceResource model.ResourceType, side model.MarketOrderSide, depth int) (result []model.MarketOrder, err error) {
+	order := "price ASC"
+	if side == model.MarketOrderBuy {
+		order = "price DESC"
+	}
+
+	err = d.tx.Select(&result,
+		`SELECT * FROM market_orders WHERE resource = $1 AND price_resource = $2 AND side = $3 AND status = $4 
+ORDER BY `+order+`, id ASC LIMIT $5`,
+		resource, priceResource, side, model.MarketOrderOpen, depth)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetCharacterMarketOrders(characterID int64, offset int, count int) (result []model.MarketOrder, err error) {
+	err = d.tx.Select(&result,
+		"SELECT * FROM market_orders WHERE character_id = $1 ORDER BY id DESC OFFSET $2 LIMIT $3",
+		characterID, offset, count)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddCaravan(caravan model.Caravan) (id int64, err error) {
+	err = d.tx.Get(&id,
+		`INSERT INTO caravans (owner_id, owner_name, receiver_id, receiver_name, from_town_id, to_town_id, 
+                      status, departed_at, arrives_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		caravan.OwnerID, caravan.OwnerName, caravan.ReceiverID, caravan.ReceiverName,
+		caravan.FromTownID, caravan.ToTownID,
+		caravan.Status, caravan.DepartedAt, caravan.ArrivesAt)
+	if err != nil {
+		return id, d.handleError(err)
+	}
+
+	err = d.replaceResources("caravan_resources", "caravan_id", id, caravan.Resources)
+	return id, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) UpdateCaravan(caravan model.Caravan) error {
+	_, err := d.tx.NamedExec("UPDATE caravans SET status = :status WHERE id = :id", caravan)
+	if err != nil {
+		return d.handleError(err)
+	}
+
+	err = d.replaceResources("caravan_resources", "caravan_id", caravan.ID, caravan.Resources)
+	return d.handleError(err)
+}
+
+// loadCaravanResources - fills the cargo of the caravans
+func (d *DatabaseTransaction) loadCaravanResources(caravans []model.Caravan) error {
+	if len(caravans) == 0 {
+		return nil
+	}
+
+	ids := make(pq.Int64Array, len(caravans))
+	for i, caravan := range caravans {
+		ids[i] = caravan.ID
+	}
+
+	var rows []caravanResourceRow
+	err := d.tx.Select(&rows,
+		"SELECT caravan_id, resource, amount FROM caravan_resources WHERE caravan_id = ANY($1)", ids)
+	if err != nil {
+		return err
+	}
+
+	cargo := make(map[int64]model.Resources)
+	for _, row := range rows {
+		if cargo[row.CaravanID] == nil {
+			cargo[row.CaravanID] = model.Resources{}
+		}
+
+		cargo[row.CaravanID][row.Resource] = row.Amount
+	}
+
+	for i := range caravans {
+		caravans[i].Resources = cargo[caravans[i].ID]
+		if caravans[i].Resources == nil {
+			caravans[i].Resources = model.Resources{}
+		}
+	}
+
+	return nil
+}
+
+// GetCharacterCaravans - returns caravans on the way sent by or to the character, the nearest arrivals go first
+func (d *DatabaseTransaction) GetCharacterCaravans(characterID int64) (result []model.Caravan, err error) {
+	err = d.tx.Select(&result,
+		`SELECT * FROM caravans WHERE status = $1 AND (owner_id = $2 OR receiver_id = $2) ORDER BY arrives_at`,
+		model.CaravanEnRoute, characterID)
+	if err == nil {
+		err = d.loadCaravanResources(result)
+	}
+
+	return result, d.handleError(err)
+}
+
+// GetArrivedCaravans - returns up to 'count' caravans on the way which arrival time has come,
+// returned caravans are locked until the end of the transaction
+func (d *DatabaseTransaction) GetArrivedCaravans(now time.Time, count int) (result []model.Caravan, err error) {
+	err = d.tx.Select(&result,
+		`SELECT * FROM caravans WHERE status = $1 AND arrives_at <= $2 ORDER BY arrives_at LIMIT $3 FOR UPDATE`,
+		model.CaravanEnRoute, now, count)
+	if err == nil {
+		err = d.loadCaravanResources(result)
+	}
+
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddResearch(research model.Research) error {
+	_, err := d.tx.NamedExec(`INSERT INTO research (character_id, tech, started_at, finishes_at, completed) 
+VALUES (:character_id, :tech, :started_at, :finishes_at, :completed)`, research)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) DeleteResearch(characterID int64, tech string) error {
+	_, err := d.tx.Exec("DELETE FROM research WHERE character_id = $1 AND tech = $2", characterID, tech)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) CompleteResearch(research model.Research) error {
+	_, err := d.tx.Exec("UPDATE research SET completed = TRUE WHERE character_id = $1 AND tech = $2",
+		research.CharacterID, research.Tech)
+	return d.handleError(err)
+}
+
+// GetActiveResearch - returns research of the character which isn't completed yet
+func (d *DatabaseTransaction) GetActiveResearch(characterID int64) (result model.Research, err error) {
+	err = d.tx.Get(&result, "SELECT * FROM research WHERE character_id = $1 AND NOT completed", characterID)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetCharacterTechs(characterID int64) (model.CharacterTechs, error) {
+	var techs []string
+	err := d.tx.Select(&techs, "SELECT tech FROM research WHERE character_id = $1 AND completed", characterID)
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+
+	result := make(model.CharacterTechs, len(techs))
+	for _, tech := range techs {
+		result[tech] = true
+	}
+
+	return result, nil
+}
+
+// GetFinishedResearch - returns up to 'count' not completed researches which finish time has come,
+// returned researches are locked until the end of the transaction
+func (d *DatabaseTransaction) GetFinishedResearch(now time.Time, count int) (result []model.Research, err error) {
+	err = d.tx.Select(&result,
+		`SELECT * FROM research WHERE NOT completed AND finishes_at <= $1 ORDER BY finishes_at LIMIT $2 FOR UPDATE`,
+		now, count)
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddUnitTraining(training model.UnitTraining) (id int64, err error) {
+	err = d.tx.Get(&id,
+		`INSERT INTO unit_training (character_id, town_id, unit_type, count, started_at, finishes_at) 
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		training.CharacterID, training.TownID, training.UnitType, training.Count,
+		training.StartedAt, training.FinishesAt)
 	return id, d.handleError(err)
 }
 
+func (d *DatabaseTransaction) DeleteUnitTraining(id int64) error {
+	_, err := d.tx.Exec("DELETE FROM unit_training WHERE id = $1", id)
+	return d.handleError(err)
+}
+
+// GetCharacterUnitTraining - returns the training queues of all towns of the character, the nearest completions go first
+func (d *DatabaseTransaction) GetCharacterUnitTraining(characterID int64) (result []model.UnitTraining, err error) {
+	err = d.tx.Select(&result,
+		"SELECT * FROM unit_training WHERE character_id = $1 ORDER BY finishes_at, id", characterID)
+	return result, d.handleError(err)
+}
+
+// GetFinishedUnitTraining - returns up to 'count' trainings which finish time has come,
+// returned trainings are locked until the end of the transaction
+func (d *DatabaseTransaction) GetFinishedUnitTraining(now time.Time, count int) (result []model.UnitTraining, err error) {
+	err = d.tx.Select(&result,
+		"SELECT * FROM unit_training WHERE finishes_at <= $1 ORDER BY finishes_at, id LIMIT $2 FOR UPDATE",
+		now, count)
+	return result, d.handleError(err)
+}
+
+// AddTownUnits - increments the number of units stationed in the town
+func (d *DatabaseTransaction) AddTownUnits(townID int64, army model.Army) error {
+	for unitType, count := range army {
+		if count == 0 {
+			continue
+		}
+
+		_, err := d.tx.Exec(`INSERT INTO town_units (town_id, unit_type, count) VALUES ($1, $2, $3) 
+ON CONFLICT (town_id, unit_type) DO UPDATE SET count = town_units.count + EXCLUDED.count`,
+			townID, unitType, count)
+		if err != nil {
+			return d.handleError(err)
+		}
+	}
+
+	return d.handleError(nil)
+}
+
+// GetCharacterArmy - returns units stationed in the towns of the character by town
+func (d *DatabaseTransaction) GetCharacterArmy(characterName string) (map[int64]model.Army, error) {
+	var rows []townUnitsRow
+	err := d.tx.Select(&rows, `SELECT tu.town_id, tu.unit_type, tu.count FROM town_units tu 
+JOIN towns t ON tu.town_id = t.id 
+WHERE t.owner_name = $1 AND tu.count > 0`, characterName)
+	if err != nil {
+		return nil, d.handleError(err)
+	}
+
+	result := make(map[int64]model.Army)
+	for _, row := range rows {
+		if model.IsValidUnitType(row.UnitType) {
+			army := result[row.TownID]
+			army.Add(model.Army{rpc.UnitType(row.UnitType): row.Count})
+			result[row.TownID] = army
+		}
+	}
+
+	return result, nil
+}
+
+// RemoveTownUnits - decrements the number of units stationed in the town,
+// returns sql.ErrNoRows if the town doesn't have enough units of some type
+func (d *DatabaseTransaction) RemoveTownUnits(townID int64, army model.Army) error {
+	for unitType, count := range army {
+		if count == 0 {
+			continue
+		}
+
+		result, err := d.tx.Exec(
+			"UPDATE town_units SET count = count - $3 WHERE town_id = $1 AND unit_type = $2 AND count >= $3",
+			townID, unitType, count)
+		if err != nil {
+			return d.handleError(err)
+		}
+
+		if updated, err := result.RowsAffected(); err != nil {
+			return d.handleError(err)
+		} else if updated == 0 {
+			return d.handleError(sql.ErrNoRows)
+		}
+	}
+
+	return d.handleError(nil)
+}
+
+func (d *DatabaseTransaction) AddMarch(march model.March) (id int64, err error) {
+	err = d.tx.Get(&id,
+		`INSERT INTO marches (character_id, character_name, home_town_id, target_town_id, 
+                     from_x, from_y, to_x, to_y, status, capture, departed_at, arrives_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`,
+		march.CharacterID, march.CharacterName, march.HomeTownID, march.TargetTownID,
+		march.FromX, march.FromY, march.ToX, march.ToY, march.Status, march.Capture,
+		march.DepartedAt, march.ArrivesAt)
+	if err != nil {
+		return id, d.handleError(err)
+	}
+
+	err = d.insertMarchUnits(id, march.Units)
+	return id, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) insertMarchUnits(marchID int64, units model.Army) error {
+	for unitType, count := range units {
+		_, err := d.tx.Exec("INSERT INTO march_units (march_id, unit_type, count) VALUES ($1, $2, $3)",
+			marchID, unitType, count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateMarchUnits - replaces the units of the march, e.g. with the survivors of the battle
+func (d *DatabaseTransaction) UpdateMarchUnits(marchID int64, units model.Army) error {
+	if _, err := d.tx.Exec("DELETE FROM march_units WHERE march_id = $1", marchID); err != nil {
+		return d.handleError(err)
+	}
+
+	err := d.insertMarchUnits(marchID, units)
+	return d.handleError(err)
+}
+
+// UpdateMarch - updates the route and the status of the march, units of the march aren't changed
+func (d *DatabaseTransaction) UpdateMarch(march model.March) error {
+	_, err := d.tx.NamedExec(`UPDATE marches SET target_town_id = :target_town_id, 
+    from_x = :from_x, from_y = :from_y, to_x = :to_x, to_y = :to_y, 
+    status = :status, capture = :capture, departed_at = :departed_at, arrives_at = :arrives_at 
+WHERE id = :id`, march)
+	return d.handleError(err)
+}
+
+func (d *DatabaseTransaction) DeleteMarch(id int64) error {
+	_, err := d.tx.Exec("DELETE FROM marches WHERE id = $1", id)
+	return d.handleError(err)
+}
+
+// loadMarchUnits - fills the units of the marches
+func (d *DatabaseTransaction) loadMarchUnits(marches []model.March) error {
+	if len(marches) == 0 {
+		return nil
+	}
+
+	ids := make(pq.Int64Array, len(marches))
+	for i, march := range marches {
+		ids[i] = march.ID
+	}
+
+	var rows []marchUnitsRow
+	err := d.tx.Select(&rows, "SELECT march_id, unit_type, count FROM march_units WHERE march_id = ANY($1)", ids)
+	if err != nil {
+		return err
+	}
+
+	units := make(map[int64]model.Army)
+	for _, row := range rows {
+		if model.IsValidUnitType(row.UnitType) {
+			army := units[row.MarchID]
+			army.Add(model.Army{rpc.UnitType(row.UnitType): row.Count})
+			units[row.MarchID] = army
+		}
+	}
+
+	for i := range marches {
+		marches[i].Units = units[marches[i].ID]
+		if marches[i].Units == nil {
+			marches[i].Units = model.Army{}
+		}
+	}
+
+	return nil
+}
+
+// GetMarch - returns the march locked until the end of the transaction
+func (d *DatabaseTransaction) GetMarch(id int64) (result model.March, err error) {
+	err = d.tx.Get(&result, "SELECT * FROM marches WHERE id = $1 FOR UPDATE", id)
+	if err == nil {
+		marches := []model.March{result}
+		err = d.loadMarchUnits(marches)
+		result = marches[0]
+	}
+
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetCharacterMarches(characterID int64) (result []model.March, err error) {
+	err = d.tx.Select(&result, "SELECT * FROM marches WHERE character_id = $1 ORDER BY id", characterID)
+	if err == nil {
+		err = d.loadMarchUnits(result)
+	}
+
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) GetMovingMarches() (result []model.March, err error) {
+	err = d.tx.Select(&result, "SELECT * FROM marches WHERE status = $1 ORDER BY id", model.MarchMoving)
+	if err == nil {
+		err = d.loadMarchUnits(result)
+	}
+
+	return result, d.handleError(err)
+}
+
+// GetArrivedMarches - returns up to 'count' moving marches which arrival time has come,
+// returned marches are locked until the end of the transaction
+func (d *DatabaseTransaction) GetArrivedMarches(now time.Time, count int) (result []model.March, err error) {
+	err = d.tx.Select(&result,
+		`SELECT * FROM marches WHERE status = $1 AND arrives_at <= $2 ORDER BY arrives_at LIMIT $3 FOR UPDATE`,
+		model.MarchMoving, now, count)
+	if err == nil {
+		err = d.loadMarchUnits(result)
+	}
+
+	return result, d.handleError(err)
+}
+
 func (d *DatabaseTransaction) UpdateCharacter(character model.Character) error {
 	_, err := d.tx.NamedExec(
 		`UPDATE characters SET 
 			  name=:name, 
 			  max_population=:max_population, 
-			  current_population=:current_population
+			  current_population=:current_population,
+			  tax_rate=:tax_rate,
+			  protected_until=:protected_until,
+			  shield_until=:shield_until
          WHERE id=:id`, &character)
 	if err != nil {
 		return d.handleError(err)
 	}
 
-	if err := d.AddOrUpdateResources(character.Resources); err != nil {
+	if err := d.AddOrUpdateResources(character.ID, character.Resources); err != nil {
 		return d.handleError(err)
 	}
 
-	err = d.AddOrUpdateProductionRates(character.ProductionRate)
+	err = d.AddOrUpdateProductionRates(character.ID, character.ProductionRate)
 	return d.handleError(err)
 }
 
 func (d *DatabaseTransaction) GetCharacters(accountID int64) (result []model.Character, err error) {
-	err = d.tx.Select(&result, `SELECT c.* FROM account_characters as a
-    INNER JOIN characters as c
-        ON c.id = a.character_id
-WHERE account_id = $1`, accountID)
+	err = d.tx.Select(&result, selectCharactersQuery+" WHERE ac.account_id = $1", accountID)
 
 	return result, d.handleError(err)
 }
@@ -277,10 +1164,14 @@ func (d *DatabaseTransaction) GetAccount(login string) (result model.Account, er
 	return result, d.handleError(err)
 }
 
+// GetCharacterByName - returns the character without resources and towns
+func (d *DatabaseTransaction) GetCharacterByName(name string) (result model.Character, err error) {
+	err = d.tx.Get(&result, selectCharactersQuery+" WHERE c.name = $1", name)
+	return result, d.handleError(err)
+}
+
 func (d *DatabaseTransaction) GetCharacter(id int64) (result model.Character, err error) {
-	err = d.tx.Get(&result,
-		`SELECT c.*, ac.account_id FROM characters c 
-    JOIN account_characters ac on c.id = ac.character_id WHERE c.id = $1`, id)
+	err = d.tx.Get(&result, selectCharactersQuery+" WHERE c.id = $1", id)
 
 	if err != nil {
 		return result, d.handleError(err)
@@ -299,26 +1190,52 @@ func (d *DatabaseTransaction) GetCharacter(id int64) (result model.Character, er
 	}
 
 	result.ProductionRate = productionRates
-	return result, d.handleError(err)
-}
 
-func (d *DatabaseTransaction) AddCharacter(name string) (id int, err error) {
-	err = d.tx.Get(&id, "INSERT INTO characters VALUES (DEFAULT, $1, DEFAULT, DEFAULT) RETURNING id", name)
+	buildings, err := d.GetCharacterBuildings(result.Name)
 	if err != nil {
-		return 0, d.handleError(err)
+		return result, fmt.Errorf("failed to get character buildings: %w", err)
 	}
 
-	_, err = d.tx.Exec("INSERT INTO resources VALUES ($1, 0, 0, 0, 0)", id)
+	result.Buildings = buildings
+	result.StorageCapacity = model.StorageCapacity(buildings)
+
+	techs, err := d.GetCharacterTechs(id)
 	if err != nil {
-		return id, fmt.Errorf("failed to insert character resources: %w", err)
+		return result, fmt.Errorf("failed to get character techs: %w", err)
 	}
 
-	_, err = d.tx.Exec("INSERT INTO production_rates VALUES ($1, 0, 0, 0, 0)", id)
+	result.Techs = techs
+
+	towns, err := d.GetCharacterArmy(result.Name)
 	if err != nil {
-		err = fmt.Errorf("failed to insert character production rates: %w", err)
+		return result, fmt.Errorf("failed to get character army: %w", err)
 	}
 
-	return id, err
+	for _, army := range towns {
+		result.Army.Add(army)
+	}
+
+	// Units on the march are the part of the army too
+	marches, err := d.GetCharacterMarches(id)
+	if err != nil {
+		return result, fmt.Errorf("failed to get character marches: %w", err)
+	}
+
+	for _, march := range marches {
+		result.Army.Add(march.Units)
+	}
+	return result, d.handleError(err)
+}
+
+func (d *DatabaseTransaction) AddCharacter(name string) (id int, err error) {
+	err = d.tx.Get(&id, "INSERT INTO characters VALUES (DEFAULT, $1, DEFAULT, DEFAULT) RETURNING id", name)
+	if err != nil {
+		return 0, d.handleError(err)
+	}
+
+	// Resources and production rates are stored as rows of non-zero amounts,
+	// so the new character doesn't have them
+	return id, nil
 }
 
 func (d *DatabaseTransaction) DeleteCharacter(id int64) error {
@@ -359,3 +1276,128 @@ func NewDatabase(config Config) (db.Database, error) {
 		db: database,
 	}, nil
 }
+
+// battleSide - side of the battle the units of the report belong to
+type battleSide int
+
+const (
+	attackerSide battleSide = iota
+	defenderSide
+)
+
+func (d *DatabaseTransaction) insertBattleReportUnits(reportID int64, side battleSide, units, losses model.Army) error {
+	for unitType, count := range units {
+		_, err := d.tx.Exec(
+			"INSERT INTO battle_report_units (report_id, side, unit_type, count, lost) VALUES ($1, $2, $3, $4, $5)",
+			reportID, side, unitType, count, losses[unitType])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *DatabaseTransaction) AddBattleReport(report model.BattleReport) (id int64, err error) {
+	err = d.tx.Get(&id,
+		`INSERT INTO battle_reports (attacker_id, attacker_name, defender_id, defender_name, town_id, town_name, 
+                            attacker_strength, defender_strength, attacker_won, captured, fought_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		report.AttackerID, report.AttackerName, report.DefenderID, report.DefenderName,
+		report.TownID, report.TownName, report.AttackerStrength, report.DefenderStrength,
+		report.AttackerWon, report.Captured, report.FoughtAt)
+	if err != nil {
+		return id, d.handleError(err)
+	}
+
+	if err = d.insertBattleReportUnits(id, attackerSide, report.AttackerUnits, report.AttackerLosses); err != nil {
+		return id, d.handleError(err)
+	}
+
+	if err = d.insertBattleReportUnits(id, defenderSide, report.DefenderUnits, report.DefenderLosses); err != nil {
+		return id, d.handleError(err)
+	}
+
+	err = d.replaceResources("battle_report_loot", "report_id", id, report.Loot)
+	return id, d.handleError(err)
+}
+
+// loadBattleReportDetails - fills the armies and the loot of the reports
+func (d *DatabaseTransaction) loadBattleReportDetails(reports []model.BattleReport) error {
+	if len(reports) == 0 {
+		return nil
+	}
+
+	ids := make(pq.Int64Array, len(reports))
+	index := make(map[int64]*model.BattleReport, len(reports))
+	for i := range reports {
+		ids[i] = reports[i].ID
+		index[reports[i].ID] = &reports[i]
+
+		reports[i].AttackerUnits = model.Army{}
+		reports[i].DefenderUnits = model.Army{}
+		reports[i].AttackerLosses = model.Army{}
+		reports[i].DefenderLosses = model.Army{}
+		reports[i].Loot = model.Resources{}
+	}
+
+	var units []battleReportUnitsRow
+	err := d.tx.Select(&units,
+		"SELECT report_id, side, unit_type, count, lost FROM battle_report_units WHERE report_id = ANY($1)", ids)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range units {
+		if !model.IsValidUnitType(row.UnitType) {
+			continue
+		}
+
+		report := index[row.ReportID]
+		unitType := rpc.UnitType(row.UnitType)
+		army, losses := report.AttackerUnits, report.AttackerLosses
+		if row.Side == defenderSide {
+			army, losses = report.DefenderUnits, report.DefenderLosses
+		}
+
+		army.Add(model.Army{unitType: row.Count})
+		losses.Add(model.Army{unitType: row.Lost})
+	}
+
+	var loot []battleReportLootRow
+	err = d.tx.Select(&loot,
+		"SELECT report_id, resource, amount FROM battle_report_loot WHERE report_id = ANY($1)", ids)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range loot {
+		index[row.ReportID].Loot[row.Resource] = row.Amount
+	}
+
+	return nil
+}
+
+// GetBattleReports - returns reports of the battles the character attacked or defended in, the newest go first
+func (d *DatabaseTransaction) GetBattleReports(characterID int64, offset int, count int) (result []model.BattleReport, err error) {
+	err = d.tx.Select(&result,
+		`SELECT * FROM battle_reports WHERE attacker_id = $1 OR defender_id = $1 
+ORDER BY id DESC OFFSET $2 LIMIT $3`,
+		characterID, offset, count)
+	if err == nil {
+		err = d.loadBattleReportDetails(result)
+	}
+
+	return result, d.handleError(err)
+}
+
+// ChangeTownOwner - the town with its buildings and garrison passes to the new owner,
+// units still training in the town are lost
+func (d *DatabaseTransaction) ChangeTownOwner(townID int64, ownerName string) error {
+	if _, err := d.tx.Exec("UPDATE towns SET owner_name = $1 WHERE id = $2", ownerName, townID); err != nil {
+		return d.handleError(err)
+	}
+
+	_, err := d.tx.Exec("DELETE FROM unit_training WHERE town_id = $1", townID)
+	return d.handleError(err)
+}
@@ -0,0 +1,33 @@
+package generation
+
+// Downsample - reduces resolution of the heightmap generated by the TerrainGenerator
+// by averaging every factor x factor block of points.
+// Returns the new heightmap and its dimensions, the data is returned as is if factor <= 1
+func Downsample(data []float32, width, height, factor int) (result []float32, newWidth, newHeight int) {
+	if factor <= 1 {
+		return data, width, height
+	}
+
+	newWidth = (width + factor - 1) / factor
+	newHeight = (height + factor - 1) / factor
+	result = make([]float32, 0, newWidth*newHeight)
+
+	// Heightmap is stored column by column: point (x, y) is located at y+x*height
+	for i := 0; i < newWidth; i++ {
+		for j := 0; j < newHeight; j++ {
+			sum := float32(0)
+			count := 0
+
+			for x := i * factor; x < (i+1)*factor && x < width; x++ {
+				for y := j * factor; y < (j+1)*factor && y < height; y++ {
+					sum += data[y+x*height]
+					count++
+				}
+			}
+
+			result = append(result, sum/float32(count))
+		}
+	}
+
+	return result, newWidth, newHeight
+}
@@ -0,0 +1,24 @@
+package generation
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDownsample(t *testing.T) {
+	data := []float32{
+		1, 2, 3,
+		3, 4, 5,
+		5, 6, 7,
+	}
+
+	result, width, height := Downsample(data, 3, 3, 2)
+	assert.Equal(t, 2, width)
+	assert.Equal(t, 2, height)
+	assert.Equal(t, []float32{2.5, 4, 5.5, 7}, result)
+
+	result, width, height = Downsample(data, 3, 3, 1)
+	assert.Equal(t, 3, width)
+	assert.Equal(t, 3, height)
+	assert.Equal(t, data, result)
+}
@@ -0,0 +1,153 @@
+// Package geometry describes the world coordinate system.
+//
+// The world is an infinite grid of tiles split into square chunks of the same size.
+// World coordinates address a tile in the whole world, chunk coordinates address a chunk
+// and local coordinates address a tile inside of the chunk (from 0 to chunk size - 1).
+// Chunk (0, 0) starts at the world tile (0, 0), negative coordinates are allowed.
+package geometry
+
+import "math"
+
+// Point - tile or chunk coordinates
+type Point struct {
+	X int64
+	Y int64
+}
+
+// Rect - rectangle of the world tiles, both min and max bounds are included
+type Rect struct {
+	MinX int64
+	MinY int64
+	MaxX int64
+	MaxY int64
+}
+
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.MinX && p.X <= r.MaxX && p.Y >= r.MinY && p.Y <= r.MaxY
+}
+
+// Expand - returns the rectangle grown by distance in every direction
+func (r Rect) Expand(distance int64) Rect {
+	return Rect{
+		MinX: r.MinX - distance,
+		MinY: r.MinY - distance,
+		MaxX: r.MaxX + distance,
+		MaxY: r.MaxY + distance,
+	}
+}
+
+// RectAround - returns the square with the center at the point
+func RectAround(center Point, distance int64) Rect {
+	return Rect{MinX: center.X, MinY: center.Y, MaxX: center.X, MaxY: center.Y}.Expand(distance)
+}
+
+// Distance - euclidean distance between two points
+func Distance(a, b Point) float64 {
+	dx, dy := float64(a.X-b.X), float64(a.Y-b.Y)
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// CircleIntersectsRect - checks if the circle has common tiles with the rectangle
+func CircleIntersectsRect(center Point, radius float64, rect Rect) bool {
+	closest := Point{
+		X: int64(math.Max(float64(rect.MinX), math.Min(float64(center.X), float64(rect.MaxX)))),
+		Y: int64(math.Max(float64(rect.MinY), math.Min(float64(center.Y), float64(rect.MaxY)))),
+	}
+
+	return Distance(center, closest) <= radius
+}
+
+// PointFromFloat - returns the tile which contains the point with the fractional coordinates
+func PointFromFloat(x, y float32) Point {
+	return Point{
+		X: int64(math.Floor(float64(x))),
+		Y: int64(math.Floor(float64(y))),
+	}
+}
+
+// Grid - converts coordinates between world, chunk and local coordinate systems
+type Grid struct {
+	ChunkSize int64
+}
+
+func NewGrid(chunkSize int) Grid {
+	return Grid{ChunkSize: int64(chunkSize)}
+}
+
+// floorDiv - division rounded towards the negative infinity
+func floorDiv(a, b int64) int64 {
+	result := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		result--
+	}
+
+	return result
+}
+
+// WorldToChunk - returns coordinates of the chunk containing the world tile
+func (g Grid) WorldToChunk(world Point) Point {
+	return Point{
+		X: floorDiv(world.X, g.ChunkSize),
+		Y: floorDiv(world.Y, g.ChunkSize),
+	}
+}
+
+// WorldToLocal - returns coordinates of the world tile inside of its chunk
+func (g Grid) WorldToLocal(world Point) Point {
+	origin := g.ChunkOrigin(g.WorldToChunk(world))
+
+	return Point{
+		X: world.X - origin.X,
+		Y: world.Y - origin.Y,
+	}
+}
+
+// LocalToWorld - returns world coordinates of the tile inside of the chunk
+func (g Grid) LocalToWorld(chunk, local Point) Point {
+	origin := g.ChunkOrigin(chunk)
+
+	return Point{
+		X: origin.X + local.X,
+		Y: origin.Y + local.Y,
+	}
+}
+
+// ChunkOrigin - returns world coordinates of the chunk's first tile
+func (g Grid) ChunkOrigin(chunk Point) Point {
+	return Point{
+		X: chunk.X * g.ChunkSize,
+		Y: chunk.Y * g.ChunkSize,
+	}
+}
+
+// ChunkRect - returns world tiles covered by the chunk
+func (g Grid) ChunkRect(chunk Point) Rect {
+	origin := g.ChunkOrigin(chunk)
+
+	return Rect{
+		MinX: origin.X,
+		MinY: origin.Y,
+		MaxX: origin.X + g.ChunkSize - 1,
+		MaxY: origin.Y + g.ChunkSize - 1,
+	}
+}
+
+// ChunksInRect - returns coordinates of all chunks which contain tiles of the rectangle
+func (g Grid) ChunksInRect(rect Rect) (chunks []Point) {
+	min := g.WorldToChunk(Point{X: rect.MinX, Y: rect.MinY})
+	max := g.WorldToChunk(Point{X: rect.MaxX, Y: rect.MaxY})
+
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			chunks = append(chunks, Point{X: x, Y: y})
+		}
+	}
+
+	return chunks
+}
+
+// HeightmapIndex - returns index of the local tile in the chunk heightmap.
+// Heightmap is stored column by column, so the tile (x, y) is located at y+x*height
+func HeightmapIndex(local Point, height int) int {
+	return int(local.Y) + int(local.X)*height
+}
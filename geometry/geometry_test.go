@@ -0,0 +1,53 @@
+package geometry
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGrid_WorldToChunk(t *testing.T) {
+	grid := NewGrid(10)
+
+	assert.Equal(t, Point{X: 0, Y: 0}, grid.WorldToChunk(Point{X: 0, Y: 9}))
+	assert.Equal(t, Point{X: 1, Y: 2}, grid.WorldToChunk(Point{X: 10, Y: 25}))
+	assert.Equal(t, Point{X: -1, Y: -2}, grid.WorldToChunk(Point{X: -1, Y: -11}))
+	assert.Equal(t, Point{X: -1, Y: 0}, grid.WorldToChunk(Point{X: -10, Y: 0}))
+}
+
+func TestGrid_WorldToLocal(t *testing.T) {
+	grid := NewGrid(10)
+
+	assert.Equal(t, Point{X: 5, Y: 9}, grid.WorldToLocal(Point{X: 15, Y: 29}))
+	assert.Equal(t, Point{X: 9, Y: 0}, grid.WorldToLocal(Point{X: -1, Y: -10}))
+
+	world := Point{X: -37, Y: 42}
+	assert.Equal(t, world, grid.LocalToWorld(grid.WorldToChunk(world), grid.WorldToLocal(world)))
+}
+
+func TestGrid_ChunkRect(t *testing.T) {
+	grid := NewGrid(10)
+	rect := grid.ChunkRect(Point{X: -1, Y: 2})
+
+	assert.Equal(t, Rect{MinX: -10, MinY: 20, MaxX: -1, MaxY: 29}, rect)
+	assert.True(t, rect.Contains(Point{X: -10, Y: 29}))
+	assert.False(t, rect.Contains(Point{X: 0, Y: 29}))
+}
+
+func TestPointFromFloat(t *testing.T) {
+	assert.Equal(t, Point{X: 1, Y: -1}, PointFromFloat(1.7, -0.2))
+}
+
+func TestGrid_ChunksInRect(t *testing.T) {
+	grid := NewGrid(10)
+	chunks := grid.ChunksInRect(Rect{MinX: -5, MinY: 0, MaxX: 5, MaxY: 9})
+
+	assert.Equal(t, []Point{{X: -1, Y: 0}, {X: 0, Y: 0}}, chunks)
+}
+
+func TestCircleIntersectsRect(t *testing.T) {
+	rect := Rect{MinX: 0, MinY: 0, MaxX: 9, MaxY: 9}
+
+	assert.True(t, CircleIntersectsRect(Point{X: 5, Y: 5}, 1, rect))
+	assert.True(t, CircleIntersectsRect(Point{X: 12, Y: 5}, 3, rect))
+	assert.False(t, CircleIntersectsRect(Point{X: 12, Y: 12}, 3, rect))
+}
@@ -13,4 +13,5 @@ require (
 	github.com/spf13/viper v1.7.1
 	github.com/stretchr/testify v1.3.0
 	google.golang.org/grpc v1.32.0
+	google.golang.org/protobuf v1.23.0
 )
@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+func (s *SimpleLogic) AcceptAllianceInvite(
+	session *PlayerSession, request *rpc.AcceptAllianceInviteRequest) (*rpc.AcceptAllianceInviteResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":  request.SessionID,
+		"allianceID": request.AllianceID,
+	}).Info("AcceptAllianceInvite")
+
+	tx := session.Tx
+	character := session.SelectedCharacter
+
+	member, err := s.getAllianceMember(character.Name, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	if member != nil {
+		return nil, model.ErrAlreadyInAlliance
+	}
+
+	tx.SetAutoRollBack(false)
+	_, err = tx.GetAllianceInvite(request.AllianceID, character.Name)
+	tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrAllianceInviteNotFound
+	} else if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance invite")
+		return nil, model.ErrInternalServerError
+	}
+
+	alliance, err := tx.GetAlliance(request.AllianceID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := tx.AddAllianceMember(model.AllianceMember{
+		AllianceID:    alliance.ID,
+		CharacterName: character.Name,
+		Rank:          model.AllianceRankRecruit,
+		JoinedAt:      time.Now(),
+	}); err != nil {
+		s.log.WithError(err).Error("Failed to add alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := tx.RemoveAllianceInvites(character.Name); err != nil {
+		s.log.WithError(err).Error("Failed to remove alliance invites")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.setCharacterAlliance(session, character.Name, &alliance)
+
+	if err := s.sendAllianceSystemMessage(alliance.ID, consts.MessageAllianceJoined(character.Name), tx); err != nil {
+		return nil, err
+	}
+
+	return &rpc.AcceptAllianceInviteResponse{
+		Alliance:    alliance.ToRPC(),
+		EventTopics: s.eventTopics(session),
+	}, nil
+}
@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) AcceptDiplomacyProposal(
+	session *PlayerSession, request *rpc.AcceptDiplomacyProposalRequest) (*rpc.AcceptDiplomacyProposalResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":  request.SessionID,
+		"proposalID": request.ProposalID,
+	}).Info("AcceptDiplomacyProposal")
+
+	tx := session.Tx
+
+	proposal, modelErr := s.getDiplomacyProposal(request.ProposalID, tx)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	toTopic, modelErr := s.representDiplomacyParty(session, proposal.To())
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	from, fromTopic, modelErr := s.findDiplomacyParty(proposal.From(), tx)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	if err := tx.RemoveDiplomacyProposal(proposal.ID); err != nil {
+		s.log.WithError(err).Error("Failed to remove diplomacy proposal")
+		return nil, model.ErrInternalServerError
+	}
+
+	relation := model.NewDiplomacyRelation(from, proposal.To(), proposal.State)
+	if err := s.setDiplomacyRelation(relation, fromTopic, toTopic, tx); err != nil {
+		return nil, err
+	}
+
+	return &rpc.AcceptDiplomacyProposalResponse{
+		Relation: relation.ToRPC(),
+	}, nil
+}
@@ -0,0 +1,140 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var allianceTagRegexp = regexp.MustCompile("^[A-Za-z0-9]{2,5}$")
+
+// validateAlliance - checks the alliance name and tag requirements
+func validateAlliance(name string, tag string) model.Error {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > consts.AllianceNameMaxLength {
+		return model.NewError("alliance name must be from 1 to 40 characters long", rpc.Error_BAD_REQUEST)
+	}
+
+	if !allianceTagRegexp.MatchString(tag) {
+		return model.NewError("alliance tag must be 2-5 latin letters or digits", rpc.Error_BAD_REQUEST)
+	}
+
+	return nil
+}
+
+// getAllianceMember - returns the alliance membership of the character or nil if the character isn't in alliance
+func (s *SimpleLogic) getAllianceMember(name string, tx db.DatabaseTransaction) (*model.AllianceMember, error) {
+	tx.SetAutoRollBack(false)
+	member, err := tx.GetAllianceMember(name)
+	tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// getAlliance - returns the alliance or nil if it doesn't exist
+func (s *SimpleLogic) getAlliance(id int64, tx db.DatabaseTransaction) (*model.Alliance, error) {
+	tx.SetAutoRollBack(false)
+	alliance, err := tx.GetAlliance(id)
+	tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &alliance, nil
+}
+
+// checkAlliancePermission - returns membership of the session character if its rank has the permission
+func (s *SimpleLogic) checkAlliancePermission(
+	session *PlayerSession, permission model.AlliancePermission) (*model.AllianceMember, model.Error) {
+	member, err := s.getAllianceMember(session.SelectedCharacter.Name, session.Tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	if member == nil {
+		return nil, model.ErrNotInAlliance
+	}
+
+	if !member.Rank.Can(permission) {
+		return nil, model.ErrForbidden
+	}
+
+	return member, nil
+}
+
+// setCharacterAlliance - updates alliance and the alliance chat channel of all online sessions of the character,
+// nil alliance means the character left the alliance. The current session is expected to be locked already
+func (s *SimpleLogic) setCharacterAlliance(current *PlayerSession, name string, alliance *model.Alliance) {
+	var allianceID int64
+	var tag string
+	if alliance != nil {
+		allianceID = alliance.ID
+		tag = alliance.Tag
+	}
+
+	for _, session := range s.onlineSessions() {
+		if session != current {
+			session.Mutex.Lock()
+		}
+
+		character := session.SelectedCharacter
+		if character != nil && character.Name == name {
+			character.AllianceID = allianceID
+			character.AllianceTag = tag
+			session.ChatChannels = withAllianceChatChannel(session.ChatChannels, allianceID)
+		}
+
+		if session != current {
+			session.Mutex.Unlock()
+		}
+	}
+}
+
+// rotateAllianceTopics - replaces the tokens of the alliance topics when the character leaves the alliance,
+// so it can't receive the alliance events anymore. Online members get their new topics,
+// the current session is expected to be locked already
+func (s *SimpleLogic) rotateAllianceTopics(current *PlayerSession, allianceID int64) {
+	s.topicTokens.Rotate(model.AllianceTopic(allianceID))
+	s.topicTokens.Rotate(model.AllianceChatChannel(allianceID).Topic())
+
+	for _, session := range s.onlineSessions() {
+		if session != current {
+			session.Mutex.Lock()
+		}
+
+		character := session.SelectedCharacter
+		if character != nil && character.AllianceID == allianceID {
+			s.publishEvent(model.NewEventTopicsChangedEvent(session.EventTopic, s.eventTopics(session)))
+		}
+
+		if session != current {
+			session.Mutex.Unlock()
+		}
+	}
+}
+
+// sendAllianceSystemMessage - saves the system message to the alliance chat channel history and publishes it
+func (s *SimpleLogic) sendAllianceSystemMessage(allianceID int64, text string, tx db.DatabaseTransaction) model.Error {
+	if err := s.sendChannelSystemMessage(model.AllianceChatChannel(allianceID), text, tx); err != nil {
+		s.log.WithError(err).Error("Failed to send alliance system message")
+		return model.ErrInternalServerError
+	}
+
+	return nil
+}
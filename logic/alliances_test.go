@@ -0,0 +1,203 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAllianceRank_Can(t *testing.T) {
+	require.False(t, model.AllianceRankRecruit.Can(model.AlliancePermissionInvite))
+	require.False(t, model.AllianceRankMember.Can(model.AlliancePermissionKick))
+	require.True(t, model.AllianceRankOfficer.Can(model.AlliancePermissionInvite))
+	require.True(t, model.AllianceRankOfficer.Can(model.AlliancePermissionKick))
+	require.False(t, model.AllianceRankOfficer.Can(model.AlliancePermissionDisband))
+	require.True(t, model.AllianceRankLeader.Can(model.AlliancePermissionSetRank))
+	require.True(t, model.AllianceRankLeader.Can(model.AlliancePermissionDisband))
+}
+
+func TestValidateAlliance(t *testing.T) {
+	require.NoError(t, validateAlliance("Druzhina", "DRZ"))
+	require.Error(t, validateAlliance(" ", "DRZ"))
+	require.Error(t, validateAlliance("Druzhina", "D"))
+	require.Error(t, validateAlliance("Druzhina", "DRUZHI"))
+	require.Error(t, validateAlliance("Druzhina", "D-Z"))
+}
+
+func TestSimpleLogic_CreateAlliance(t *testing.T) {
+	logic, dbMock, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+	session.ChatChannels = []model.ChatChannel{model.GlobalChatChannel()}
+
+	dbMock.On("GetAllianceMember", "test").Return(model.AllianceMember{}, sql.ErrNoRows)
+	dbMock.On("AddAlliance", mock.MatchedBy(func(alliance model.Alliance) bool {
+		return alliance.Name == "Druzhina" && alliance.Tag == "DRZ"
+	})).Return(int64(3), nil)
+	dbMock.On("AddAllianceMember", mock.MatchedBy(func(member model.AllianceMember) bool {
+		return member.AllianceID == 3 && member.CharacterName == "test" && member.Rank == model.AllianceRankLeader
+	})).Return(nil)
+	dbMock.On("RemoveAllianceInvites", "test").Return(nil)
+
+	resp, err := logic.CreateAlliance(session, &rpc.CreateAllianceRequest{Name: " Druzhina ", Tag: "drz"})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), resp.Alliance.Id)
+	require.Equal(t, "DRZ", resp.Alliance.Tag)
+	require.Contains(t, resp.EventTopics, logic.topicTokens.Private("CHAT/ALLIANCE/3/"))
+	require.Contains(t, resp.EventTopics, logic.topicTokens.Private("ALLIANCE/3/"))
+
+	require.Equal(t, int64(3), session.SelectedCharacter.AllianceID)
+	require.Equal(t, "DRZ", session.SelectedCharacter.ToRPC().AllianceTag)
+
+	dbMock.AssertExpectations(t)
+}
+
+func TestSimpleLogic_CreateAlliance_NameTaken(t *testing.T) {
+	logic, dbMock, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	dbMock.On("GetAllianceMember", "test").Return(model.AllianceMember{}, sql.ErrNoRows)
+	dbMock.On("AddAlliance", mock.Anything).Return(int64(0), db.ErrDuplicatedUniqueKey)
+
+	resp, err := logic.CreateAlliance(session, &rpc.CreateAllianceRequest{Name: "Druzhina", Tag: "DRZ"})
+	require.EqualError(t, err, model.ErrAllianceNameTaken.Error())
+	require.Nil(t, resp)
+}
+
+func TestSimpleLogic_AcceptAllianceInvite(t *testing.T) {
+	logic, dbMock, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	alliance := model.Alliance{ID: 3, Name: "Druzhina", Tag: "DRZ"}
+
+	dbMock.On("GetAllianceMember", "test").Return(model.AllianceMember{}, sql.ErrNoRows)
+	dbMock.On("GetAllianceInvite", int64(3), "test").Return(model.AllianceInvite{AllianceID: 3}, nil)
+	dbMock.On("GetAlliance", int64(3)).Return(alliance, nil)
+	dbMock.On("AddAllianceMember", mock.MatchedBy(func(member model.AllianceMember) bool {
+		return member.Rank == model.AllianceRankRecruit
+	})).Return(nil)
+	dbMock.On("RemoveAllianceInvites", "test").Return(nil)
+	dbMock.On("AddChatMessage", mock.MatchedBy(func(message model.ChatMessage) bool {
+		return message.IsSystem && message.Channel == "ALLIANCE/3"
+	})).Return(int64(5), nil)
+
+	resp, err := logic.AcceptAllianceInvite(session, &rpc.AcceptAllianceInviteRequest{AllianceID: 3})
+	require.NoError(t, err)
+	require.Equal(t, "Druzhina", resp.Alliance.Name)
+	require.Contains(t, session.ChatChannels, model.AllianceChatChannel(3))
+
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private("CHAT/ALLIANCE/3/"), event.Topic)
+
+	dbMock.AssertExpectations(t)
+}
+
+func TestSimpleLogic_AcceptAllianceInvite_NotInvited(t *testing.T) {
+	logic, dbMock, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	dbMock.On("GetAllianceMember", "test").Return(model.AllianceMember{}, sql.ErrNoRows)
+	dbMock.On("GetAllianceInvite", int64(3), "test").Return(model.AllianceInvite{}, sql.ErrNoRows)
+
+	resp, err := logic.AcceptAllianceInvite(session, &rpc.AcceptAllianceInviteRequest{AllianceID: 3})
+	require.EqualError(t, err, model.ErrAllianceInviteNotFound.Error())
+	require.Nil(t, resp)
+}
+
+func TestSimpleLogic_KickFromAlliance(t *testing.T) {
+	logic, dbMock, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "officer", AllianceID: 3}
+
+	kicked := NewPlayerSession(2)
+	kicked.SelectedCharacter = &model.Character{ID: 2, Name: "recruit", AllianceID: 3, AllianceTag: "DRZ"}
+	kicked.ChatChannels = []model.ChatChannel{model.GlobalChatChannel(), model.AllianceChatChannel(3)}
+	logic.sessions[kicked.SessionID] = kicked
+
+	dbMock.On("GetAllianceMember", "officer").
+		Return(model.AllianceMember{AllianceID: 3, CharacterName: "officer", Rank: model.AllianceRankOfficer}, nil)
+	dbMock.On("GetCharacterByName", "recruit").Return(*kicked.SelectedCharacter, nil)
+	dbMock.On("GetAllianceMember", "recruit").
+		Return(model.AllianceMember{AllianceID: 3, CharacterName: "recruit", Rank: model.AllianceRankRecruit}, nil)
+	dbMock.On("GetAlliance", int64(3)).Return(model.Alliance{ID: 3, Name: "Druzhina", Tag: "DRZ"}, nil)
+	dbMock.On("RemoveAllianceMember", "recruit").Return(nil)
+	dbMock.On("AddChatMessage", mock.Anything).Return(int64(5), nil)
+
+	// the kicked member knows the alliance topics
+	allianceTopic := logic.topicTokens.Private(model.AllianceTopic(3))
+	chatTopic := logic.topicTokens.Private(model.AllianceChatChannel(3).Topic())
+
+	_, err := logic.KickFromAlliance(session, &rpc.KickFromAllianceRequest{Character: "recruit"})
+	require.NoError(t, err)
+
+	require.Zero(t, kicked.SelectedCharacter.AllianceID)
+	require.Empty(t, kicked.SelectedCharacter.AllianceTag)
+	require.Equal(t, []model.ChatChannel{model.GlobalChatChannel()}, kicked.ChatChannels)
+
+	require.Equal(t, 3, len(logic.EventsChan))
+
+	// the remaining member gets the new alliance topics
+	event := <-logic.EventsChan
+	require.Equal(t, session.EventTopic, event.Topic)
+	topics := event.Event.GetEventTopicsChangedEvent().EventTopics
+	require.Contains(t, topics, logic.topicTokens.Private(model.AllianceTopic(3)))
+	require.NotContains(t, topics, allianceTopic)
+
+	// alliance events don't reach the old topics anymore
+	event = <-logic.EventsChan
+	require.NotEqual(t, chatTopic, event.Topic)
+	require.Equal(t, logic.topicTokens.Private(model.AllianceChatChannel(3).Topic()), event.Topic)
+
+	event = <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(2)), event.Topic)
+
+	logic.publishEvent(model.NewEvent(model.AllianceTopic(3), &rpc.Event{}))
+	require.NotEqual(t, allianceTopic, (<-logic.EventsChan).Topic)
+
+	dbMock.AssertExpectations(t)
+}
+
+func TestSimpleLogic_KickFromAlliance_SameRank(t *testing.T) {
+	logic, dbMock, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "officer", AllianceID: 3}
+
+	dbMock.On("GetAllianceMember", "officer").
+		Return(model.AllianceMember{AllianceID: 3, CharacterName: "officer", Rank: model.AllianceRankOfficer}, nil)
+	dbMock.On("GetCharacterByName", "other").Return(model.Character{ID: 2, Name: "other", AllianceID: 3}, nil)
+	dbMock.On("GetAllianceMember", "other").
+		Return(model.AllianceMember{AllianceID: 3, CharacterName: "other", Rank: model.AllianceRankOfficer}, nil)
+
+	resp, err := logic.KickFromAlliance(session, &rpc.KickFromAllianceRequest{Character: "other"})
+	require.EqualError(t, err, model.ErrForbidden.Error())
+	require.Nil(t, resp)
+}
+
+func TestSimpleLogic_SetAllianceRank_NotLeader(t *testing.T) {
+	logic, dbMock, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "officer", AllianceID: 3}
+
+	dbMock.On("GetAllianceMember", "officer").
+		Return(model.AllianceMember{AllianceID: 3, CharacterName: "officer", Rank: model.AllianceRankOfficer}, nil)
+
+	resp, err := logic.SetAllianceRank(session, &rpc.SetAllianceRankRequest{Character: "other", Rank: rpc.AllianceRank_MEMBER})
+	require.EqualError(t, err, model.ErrForbidden.Error())
+	require.Nil(t, resp)
+
+	resp, err = logic.SetAllianceRank(session, &rpc.SetAllianceRankRequest{Character: "other", Rank: rpc.AllianceRank_LEADER})
+	require.EqualError(t, err, model.ErrBadRequest.Error())
+	require.Nil(t, resp)
+}
+
+func TestSimpleLogic_LeaveAlliance_Leader(t *testing.T) {
+	logic, dbMock, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "leader", AllianceID: 3}
+
+	dbMock.On("GetAllianceMember", "leader").
+		Return(model.AllianceMember{AllianceID: 3, CharacterName: "leader", Rank: model.AllianceRankLeader}, nil)
+
+	resp, err := logic.LeaveAlliance(session, &rpc.LeaveAllianceRequest{})
+	require.EqualError(t, err, model.ErrForbidden.Error())
+	require.Nil(t, resp)
+}
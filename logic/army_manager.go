@@ -0,0 +1,223 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+var (
+	armyUpdateFreq = 5 * time.Second
+)
+
+const (
+	// Max number of unit trainings completed in one transaction
+	unitTrainingBatchSize = 100
+	// Max number of march arrivals processed in one transaction
+	marchArrivalBatchSize = 100
+)
+
+type ArmyManager struct {
+	logic  *SimpleLogic
+	logger *log.Entry
+}
+
+func NewArmyManager(l *SimpleLogic) *ArmyManager {
+	return &ArmyManager{
+		logic:  l,
+		logger: log.WithField("module", "army_manager"),
+	}
+}
+
+// finishTraining - stations the trained units in the town, the online character gets them once the transaction
+// is committed
+func (a *ArmyManager) finishTraining(training model.UnitTraining, tx db.DatabaseTransaction, after *afterCommit) error {
+	units := model.Army{training.UnitType: training.Count}
+
+	if err := tx.AddTownUnits(training.TownID, units); err != nil {
+		return fmt.Errorf("failed to add town units: %w", err)
+	}
+
+	if err := tx.DeleteUnitTraining(training.ID); err != nil {
+		return fmt.Errorf("failed to delete training: %w", err)
+	}
+
+	after.add(func() {
+		a.logic.updateOnlineCharacter(nil, training.CharacterID, func(character *model.Character) {
+			character.Army.Add(units)
+		})
+
+		a.logic.publishEvent(model.NewUnitsTrainedEvent(training))
+	})
+	return nil
+}
+
+// completeTraining - completes all trainings which finish time has come
+func (a *ArmyManager) completeTraining(now time.Time, tx db.DatabaseTransaction, after *afterCommit) error {
+	for {
+		trainings, err := tx.GetFinishedUnitTraining(now, unitTrainingBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get finished trainings: %w", err)
+		}
+
+		for _, training := range trainings {
+			if err := a.finishTraining(training, tx, after); err != nil {
+				return fmt.Errorf("failed to complete training %d: %w", training.ID, err)
+			}
+		}
+
+		if len(trainings) < unitTrainingBatchSize {
+			return nil
+		}
+	}
+}
+
+// targetTown - returns the town the army marched to, false if it marched to the world tile or the town doesn't exist
+func (a *ArmyManager) targetTown(march model.March, tx db.DatabaseTransaction) (model.Town, bool, error) {
+	if march.TargetTownID == 0 {
+		return model.Town{}, false, nil
+	}
+
+	tx.SetAutoRollBack(false)
+	town, err := tx.GetTown(march.TargetTownID)
+	tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return town, false, nil
+	} else if err != nil {
+		return town, false, err
+	}
+
+	return town, true, nil
+}
+
+// arriveMarch - the army besieges the hostile town, then joins the garrison of its own (or just captured) town,
+// or camps at any other destination (including the towns of the protected characters and of the treaty partners).
+// The march is over if the army is defeated
+func (a *ArmyManager) arriveMarch(march model.March, now time.Time, tx db.DatabaseTransaction, after *afterCommit) error {
+	town, found, err := a.targetTown(march, tx)
+	if err != nil {
+		return fmt.Errorf("failed to get target town: %w", err)
+	}
+
+	if found {
+		defender, attacker, hostile, err := a.hostileDefender(march, town, now, tx)
+		if err != nil {
+			return err
+		}
+
+		if hostile {
+			report, err := a.siege(&march, town, defender, attacker, now, tx, after)
+			if err != nil {
+				return fmt.Errorf("failed to resolve battle: %w", err)
+			}
+
+			if march.Units.IsEmpty() {
+				if err := tx.DeleteMarch(march.ID); err != nil {
+					return fmt.Errorf("failed to delete march: %w", err)
+				}
+
+				return nil
+			}
+
+			if report.Captured {
+				town.OwnerName = march.CharacterName
+			}
+		}
+	}
+
+	stationed := found && town.OwnerName == march.CharacterName
+	if stationed {
+		if err := tx.AddTownUnits(march.TargetTownID, march.Units); err != nil {
+			return fmt.Errorf("failed to add town units: %w", err)
+		}
+
+		if err := tx.DeleteMarch(march.ID); err != nil {
+			return fmt.Errorf("failed to delete march: %w", err)
+		}
+	} else {
+		march.Status = model.MarchCamped
+		if err := tx.UpdateMarch(march); err != nil {
+			return fmt.Errorf("failed to update march: %w", err)
+		}
+	}
+
+	after.add(func() {
+		for _, topic := range a.logic.marchTopics(march, now) {
+			a.logic.publishEvent(model.NewArmyArrivedEvent(topic, march, stationed, now))
+		}
+	})
+	return nil
+}
+
+// arriveMarches - completes all marches which arrival time has come
+func (a *ArmyManager) arriveMarches(now time.Time, tx db.DatabaseTransaction, after *afterCommit) error {
+	for {
+		marches, err := tx.GetArrivedMarches(now, marchArrivalBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get arrived marches: %w", err)
+		}
+
+		for _, march := range marches {
+			if err := a.arriveMarch(march, now, tx, after); err != nil {
+				return fmt.Errorf("failed to complete march %d: %w", march.ID, err)
+			}
+		}
+
+		if len(marches) < marchArrivalBatchSize {
+			return nil
+		}
+	}
+}
+
+// publishPositions - notifies the observers about the armies still on their way
+func (a *ArmyManager) publishPositions(now time.Time, tx db.DatabaseTransaction) error {
+	marches, err := tx.GetMovingMarches()
+	if err != nil {
+		return fmt.Errorf("failed to get moving marches: %w", err)
+	}
+
+	for _, march := range marches {
+		a.logic.publishMarchPosition(march, now)
+	}
+	return nil
+}
+
+func (a *ArmyManager) Update() {
+	tx, err := a.logic.db.BeginTransaction(false, true)
+	if err != nil {
+		a.logger.WithError(err).Error("Failed to begin transaction")
+		return
+	}
+
+	now := time.Now()
+	var after afterCommit
+	if err := a.completeTraining(now, tx, &after); err != nil {
+		a.logger.WithError(err).Error("Failed to complete unit training")
+		rollBack(tx, a.logger)
+		return
+	}
+
+	if err := a.arriveMarches(now, tx, &after); err != nil {
+		a.logger.WithError(err).Error("Failed to complete marches")
+		rollBack(tx, a.logger)
+		return
+	}
+
+	if err := a.publishPositions(now, tx); err != nil {
+		a.logger.WithError(err).Error("Failed to publish army positions")
+		rollBack(tx, a.logger)
+		return
+	}
+
+	if err := tx.EndTransaction(); err != nil {
+		a.logger.WithError(err).Error("Failed to commit transaction")
+		return
+	}
+
+	after.run()
+}
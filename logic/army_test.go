@@ -0,0 +1,142 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSimpleLogic_TrainUnits(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:                1,
+		Name:              "test",
+		CurrentPopulation: 10,
+		Towns:             []model.Town{{ID: 1}},
+		Resources:         model.Resources{model.ResourceWood: 100, model.ResourceFood: 100},
+	}
+
+	queued := time.Now().Add(time.Minute)
+
+	db.On("GetTownBuildings", int64(1)).Return(model.CharacterBuildings{rpc.BuildingType_BARRACKS: 1}, nil)
+	db.On("GetCharacterUnitTraining", int64(1)).Return([]model.UnitTraining{
+		{ID: 1, TownID: 1, FinishesAt: queued},
+		{ID: 2, TownID: 2, FinishesAt: queued.Add(time.Hour)},
+	}, nil)
+	db.On("AddUnitTraining", mock.MatchedBy(func(training model.UnitTraining) bool {
+		return training.StartedAt.Equal(queued) &&
+			training.FinishesAt.Sub(training.StartedAt) == 3*model.Units[rpc.UnitType_MILITIA].TrainingTime
+	})).Return(int64(3), nil)
+	db.On("UpdateCharacter", mock.Anything).Return(nil)
+
+	resp, err := logic.TrainUnits(session, &rpc.TrainUnitsRequest{
+		TownID:   1,
+		UnitType: rpc.UnitType_MILITIA,
+		Count:    3,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), resp.Training.Id)
+
+	character := session.SelectedCharacter
+	require.Equal(t, uint64(7), character.CurrentPopulation)
+	require.Equal(t, model.Resources{model.ResourceWood: 70, model.ResourceFood: 40}, character.Resources)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_TrainUnits_Errors(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:                1,
+		Name:              "test",
+		CurrentPopulation: 1,
+		Towns:             []model.Town{{ID: 1}, {ID: 2}},
+		Resources:         model.Resources{model.ResourceWood: 100, model.ResourceFood: 100},
+	}
+
+	db.On("GetTownBuildings", int64(1)).Return(model.CharacterBuildings{rpc.BuildingType_BARRACKS: 1}, nil)
+	db.On("GetTownBuildings", int64(2)).Return(model.CharacterBuildings{}, nil)
+
+	_, err := logic.TrainUnits(session, &rpc.TrainUnitsRequest{TownID: 1, UnitType: rpc.UnitType_MILITIA})
+	require.Equal(t, model.ErrBadRequest, err)
+
+	_, err = logic.TrainUnits(session, &rpc.TrainUnitsRequest{TownID: 3, UnitType: rpc.UnitType_MILITIA, Count: 1})
+	require.Equal(t, model.ErrTownNotFound, err)
+
+	_, err = logic.TrainUnits(session, &rpc.TrainUnitsRequest{TownID: 2, UnitType: rpc.UnitType_MILITIA, Count: 1})
+	require.Equal(t, model.ErrBuildingRequired, err)
+
+	_, err = logic.TrainUnits(session, &rpc.TrainUnitsRequest{TownID: 1, UnitType: rpc.UnitType_MILITIA, Count: 2})
+	require.Equal(t, model.ErrNotEnoughPopulation, err)
+
+	_, err = logic.TrainUnits(session, &rpc.TrainUnitsRequest{TownID: 1, UnitType: rpc.UnitType_CAVALRY, Count: 1})
+	require.Equal(t, model.ErrNotEnoughPopulation, err)
+}
+
+func TestArmyManager_CompleteTraining(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test", Army: model.Army{rpc.UnitType_ARCHER: 2}}
+
+	now := time.Now()
+	training := model.UnitTraining{ID: 5, CharacterID: 1, TownID: 1, UnitType: rpc.UnitType_ARCHER, Count: 3}
+
+	db.On("GetFinishedUnitTraining", now, unitTrainingBatchSize).Return([]model.UnitTraining{training}, nil)
+	db.On("AddTownUnits", int64(1), model.Army{rpc.UnitType_ARCHER: 3}).Return(nil)
+	db.On("DeleteUnitTraining", int64(5)).Return(nil)
+
+	var after afterCommit
+	require.NoError(t, NewArmyManager(logic).completeTraining(now, db, &after))
+	require.Equal(t, model.Army{rpc.UnitType_ARCHER: 2}, session.SelectedCharacter.Army)
+
+	after.run()
+	require.Equal(t, model.Army{rpc.UnitType_ARCHER: 5}, session.SelectedCharacter.Army)
+
+	require.Equal(t, 1, len(logic.EventsChan))
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), event.Topic)
+	require.Equal(t, uint64(3), event.Event.GetUnitsTrainedEvent().Training.Count)
+}
+
+func TestSimpleLogic_UpdateSessionResources_ArmyUpkeep(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:              1,
+		Resources:       model.StorageCapacity(nil),
+		StorageCapacity: model.StorageCapacity(nil),
+		Army:            model.Army{rpc.UnitType_MILITIA: 10, rpc.UnitType_CAVALRY: 5},
+	}
+
+	db.On("AddOrUpdateResources", mock.Anything, mock.Anything).Return(nil)
+
+	logic.updateSessionResources(session)
+
+	require.Equal(t, uint64(model.BaseStorageCapacity-25), session.SelectedCharacter.Resources[model.ResourceFood])
+
+	// upkeep is limited by the food left
+	session.SelectedCharacter.Resources = model.Resources{model.ResourceFood: 10}
+	logic.updateSessionResources(session)
+
+	_, found := session.SelectedCharacter.Resources[model.ResourceFood]
+	require.False(t, found)
+}
+
+func TestSimpleLogic_GetArmy(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test", Army: model.Army{rpc.UnitType_CAVALRY: 2}}
+
+	db.On("GetCharacterArmy", "test").Return(map[int64]model.Army{
+		2: {rpc.UnitType_CAVALRY: 1},
+		1: {rpc.UnitType_CAVALRY: 1},
+	}, nil)
+	db.On("GetCharacterUnitTraining", int64(1)).Return([]model.UnitTraining{{ID: 1, TownID: 1}}, nil)
+
+	resp, err := logic.GetArmy(session, &rpc.GetArmyRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Towns))
+	require.Equal(t, int64(1), resp.Towns[0].TownID)
+	require.Equal(t, 1, len(resp.Training))
+	require.Equal(t, 2*model.Units[rpc.UnitType_CAVALRY].Upkeep, resp.FoodUpkeep)
+}
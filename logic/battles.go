@@ -0,0 +1,222 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/model"
+	"fmt"
+	"time"
+)
+
+// hostileDefender - returns the owner of the town and the attacker if the army fights for the town. The army fights
+// for the town of any other character it's at war or has no treaty with, except the protected characters
+func (a *ArmyManager) hostileDefender(march model.March, town model.Town,
+	now time.Time, tx db.DatabaseTransaction) (defender model.Character, attacker model.Character, hostile bool, err error) {
+	if town.OwnerName == march.CharacterName {
+		return defender, attacker, false, nil
+	}
+
+	if attacker, err = tx.GetCharacterByName(march.CharacterName); err != nil {
+		return defender, attacker, false, fmt.Errorf("failed to get attacker: %w", err)
+	}
+
+	if defender, err = tx.GetCharacterByName(town.OwnerName); err != nil {
+		return defender, attacker, false, fmt.Errorf("failed to get defender: %w", err)
+	}
+
+	state, err := a.logic.characterRelation(&attacker, &defender, tx)
+	if err != nil {
+		return defender, attacker, false, fmt.Errorf("failed to get diplomacy relation: %w", err)
+	}
+
+	return defender, attacker, state.CanAttack() && !defender.IsProtected(now), nil
+}
+
+// terrainBonus - defense multiplier given by the height of the town tile
+func (a *ArmyManager) terrainBonus(town model.Town, tx db.DatabaseTransaction) (float64, error) {
+	location := town.Location()
+	chunkLocation := a.logic.grid().WorldToChunk(location)
+
+	tx.SetAutoRollBack(false)
+	chunk, err := a.logic.getMapChunk(chunkLocation.X, chunkLocation.Y, tx)
+	tx.SetAutoRollBack(true)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get map chunk: %w", err)
+	}
+
+	// Towns are placed on the generated chunks only, there is no terrain to take into account otherwise
+	if chunk == nil {
+		return 1, nil
+	}
+
+	height, modelErr := a.logic.chunkHeightAt(chunk, location)
+	if modelErr != nil {
+		return 0, modelErr
+	}
+
+	return model.TerrainDefenseBonus(height, a.logic.config.WaterLevel), nil
+}
+
+// plunder - the winning attacker takes a share of the defender's resources
+func (a *ArmyManager) plunder(report *model.BattleReport, tx db.DatabaseTransaction, after *afterCommit) error {
+	resources, err := tx.GetResources(report.DefenderID)
+	if err != nil {
+		return fmt.Errorf("failed to get defender resources: %w", err)
+	}
+
+	before := resources.Clone()
+	report.Loot = model.Loot(resources)
+	resources.Subtract(report.Loot)
+
+	if err := tx.AddOrUpdateResources(report.DefenderID, resources); err != nil {
+		return fmt.Errorf("failed to take defender resources: %w", err)
+	}
+
+	loot := report.Loot
+	after.add(func() {
+		event := model.NewResourcesChangedEvent(report.DefenderID, before, resources)
+		a.logic.updateOnlineCharacter(nil, report.DefenderID, func(character *model.Character) {
+			before := character.Resources.Clone()
+			character.Resources.Subtract(character.Resources.Min(loot))
+			event = model.NewResourcesChangedEvent(character.ID, before, character.Resources)
+		})
+
+		a.logic.publishEvent(event)
+	})
+
+	if err := a.logic.giveCharacterResources(report.AttackerName, report.Loot, tx, after); err != nil {
+		return fmt.Errorf("failed to give loot: %w", err)
+	}
+
+	return nil
+}
+
+// capture - the town passes to the attacker with all its buildings, the last town of the defender stays with it
+func (a *ArmyManager) capture(report *model.BattleReport, town model.Town,
+	buildings model.CharacterBuildings, tx db.DatabaseTransaction, after *afterCommit) error {
+	towns, err := tx.GetTowns(report.DefenderName)
+	if err != nil {
+		return fmt.Errorf("failed to get defender towns: %w", err)
+	}
+
+	if len(towns) <= 1 {
+		return nil
+	}
+
+	err = a.logic.updateCharacter(report.DefenderID, tx, after, func(character *model.Character) {
+		character.LoseTown(town.ID, buildings)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update defender: %w", err)
+	}
+
+	err = a.logic.updateCharacter(report.AttackerID, tx, after, func(character *model.Character) {
+		character.GainTown(town, buildings)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update attacker: %w", err)
+	}
+
+	if err := tx.ChangeTownOwner(town.ID, report.AttackerName); err != nil {
+		return fmt.Errorf("failed to change town owner: %w", err)
+	}
+
+	report.Captured = true
+	return nil
+}
+
+// siege - the army attacks the garrison of the hostile town. Losses of both sides are removed,
+// the winning attacker loots the defender and captures the town if the march was ordered to.
+// The march is left with the survivors, the report is saved and delivered to both sides.
+// The attacker loses the peace shield bought while its army was marching
+func (a *ArmyManager) siege(march *model.March, town model.Town, defender, attacker model.Character, now time.Time,
+	tx db.DatabaseTransaction, after *afterCommit) (model.BattleReport, error) {
+	if attacker.IsProtected(now) {
+		err := a.logic.updateCharacter(march.CharacterID, tx, after, func(character *model.Character) {
+			character.DropProtection()
+		})
+		if err != nil {
+			return model.BattleReport{}, fmt.Errorf("failed to drop attacker protection: %w", err)
+		}
+	}
+
+	garrisons, err := tx.GetCharacterArmy(town.OwnerName)
+	if err != nil {
+		return model.BattleReport{}, fmt.Errorf("failed to get garrison: %w", err)
+	}
+
+	buildings, err := tx.GetTownBuildings(town.ID)
+	if err != nil {
+		return model.BattleReport{}, fmt.Errorf("failed to get town buildings: %w", err)
+	}
+
+	terrainBonus, err := a.terrainBonus(town, tx)
+	if err != nil {
+		return model.BattleReport{}, err
+	}
+
+	battle := model.Battle{
+		Attacker:           march.Units,
+		Defender:           garrisons[town.ID],
+		TerrainBonus:       terrainBonus,
+		FortificationBonus: buildings.DefenseBonus(),
+	}
+	result := battle.Resolve()
+
+	report := model.NewBattleReport(battle, result)
+	report.AttackerID = march.CharacterID
+	report.AttackerName = march.CharacterName
+	report.DefenderID = defender.ID
+	report.DefenderName = defender.Name
+	report.TownID = town.ID
+	report.TownName = town.Name
+	report.FoughtAt = now
+
+	if !result.DefenderLosses.IsEmpty() {
+		if err := tx.RemoveTownUnits(town.ID, result.DefenderLosses); err != nil {
+			return report, fmt.Errorf("failed to remove garrison losses: %w", err)
+		}
+	}
+
+	after.add(func() {
+		a.logic.updateOnlineCharacter(nil, defender.ID, func(character *model.Character) {
+			character.Army.Subtract(result.DefenderLosses)
+		})
+		a.logic.updateOnlineCharacter(nil, march.CharacterID, func(character *model.Character) {
+			character.Army.Subtract(result.AttackerLosses)
+		})
+	})
+
+	survivors := model.Army{}
+	survivors.Add(march.Units)
+	survivors.Subtract(result.AttackerLosses)
+	march.Units = survivors
+
+	if !survivors.IsEmpty() {
+		if err := tx.UpdateMarchUnits(march.ID, survivors); err != nil {
+			return report, fmt.Errorf("failed to update march units: %w", err)
+		}
+	}
+
+	if result.AttackerWon {
+		if err := a.plunder(&report, tx, after); err != nil {
+			return report, err
+		}
+
+		if march.Capture && !survivors.IsEmpty() {
+			if err := a.capture(&report, town, buildings, tx, after); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if report.ID, err = tx.AddBattleReport(report); err != nil {
+		return report, fmt.Errorf("failed to add battle report: %w", err)
+	}
+
+	after.add(func() {
+		a.logic.publishEvent(model.NewBattleEvent(report.AttackerID, report))
+		a.logic.publishEvent(model.NewBattleEvent(report.DefenderID, report))
+	})
+	return report, nil
+}
@@ -0,0 +1,214 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBattle_Resolve(t *testing.T) {
+	battle := model.Battle{
+		Attacker:           model.Army{rpc.UnitType_CAVALRY: 10},
+		Defender:           model.Army{rpc.UnitType_MILITIA: 10},
+		TerrainBonus:       1,
+		FortificationBonus: 1,
+	}
+
+	// militia counters the cavalry
+	result := battle.Resolve()
+	require.Equal(t, 80.0, result.AttackerStrength)
+	require.Equal(t, 45.0, result.DefenderStrength)
+	require.True(t, result.AttackerWon)
+	require.Equal(t, model.Army{rpc.UnitType_CAVALRY: 2}, result.AttackerLosses)
+	require.Equal(t, model.Army{rpc.UnitType_MILITIA: 10}, result.DefenderLosses)
+
+	// the battle is deterministic
+	require.Equal(t, result, battle.Resolve())
+
+	battle.FortificationBonus = model.CharacterBuildings{rpc.BuildingType_WALLS: 5}.DefenseBonus()
+	result = battle.Resolve()
+	require.Equal(t, 90.0, result.DefenderStrength)
+	require.False(t, result.AttackerWon)
+	require.Equal(t, model.Army{rpc.UnitType_CAVALRY: 10}, result.AttackerLosses)
+	require.Equal(t, model.Army{rpc.UnitType_MILITIA: 5}, result.DefenderLosses)
+
+	// the defender wins the draw and both sides are destroyed
+	battle = model.Battle{
+		Attacker:           model.Army{rpc.UnitType_MILITIA: 3},
+		Defender:           model.Army{rpc.UnitType_MILITIA: 2},
+		TerrainBonus:       1,
+		FortificationBonus: 1,
+	}
+	result = battle.Resolve()
+	require.Equal(t, result.AttackerStrength, result.DefenderStrength)
+	require.False(t, result.AttackerWon)
+	require.Equal(t, model.Army{rpc.UnitType_MILITIA: 3}, result.AttackerLosses)
+	require.Equal(t, model.Army{rpc.UnitType_MILITIA: 2}, result.DefenderLosses)
+
+	// defenders are stronger on the hills
+	battle.TerrainBonus = model.TerrainDefenseBonus(1.1, 0.1)
+	require.Equal(t, 1.5, battle.TerrainBonus)
+	require.Equal(t, 9.0, battle.Resolve().DefenderStrength)
+
+	// empty garrison can't resist
+	battle.Defender = nil
+	result = battle.Resolve()
+	require.True(t, result.AttackerWon)
+	require.True(t, result.AttackerLosses.IsEmpty())
+}
+
+func TestLoot(t *testing.T) {
+	loot := model.Loot(model.Resources{model.ResourceWood: 100, model.ResourceGold: 3})
+	require.Equal(t, model.Resources{model.ResourceWood: 25}, loot)
+}
+
+// mockSiege - the online attacker with 10 cavalry besieges the town of the offline defender garrisoned by the militia
+func mockSiege(garrison model.Army, shieldUntil time.Time) (*SimpleLogic, *DatabaseTransactionMock, *PlayerSession,
+	model.March) {
+	logic, db, session := NewLogicMock()
+	mockMarchTerrain(logic, db, 0.1)
+
+	session.SelectedCharacter = &model.Character{
+		ID:              1,
+		Name:            "attacker",
+		MaxPopulation:   100,
+		Towns:           []model.Town{{ID: 1, OwnerName: "attacker"}},
+		Resources:       model.Resources{},
+		StorageCapacity: model.StorageCapacity(nil),
+		Army:            model.Army{rpc.UnitType_CAVALRY: 10},
+		ShieldUntil:     shieldUntil,
+	}
+
+	march := model.March{
+		ID:            3,
+		CharacterID:   1,
+		CharacterName: "attacker",
+		HomeTownID:    1,
+		TargetTownID:  5,
+		Units:         model.Army{rpc.UnitType_CAVALRY: 10},
+		Capture:       true,
+	}
+
+	db.On("GetTown", int64(5)).Return(model.Town{ID: 5, Name: "Kyiv", OwnerName: "defender"}, nil)
+	db.On("GetCharacterByName", "attacker").Return(model.Character{ID: 1, Name: "attacker", ShieldUntil: shieldUntil}, nil)
+	db.On("GetCharacterByName", "defender").Return(model.Character{ID: 2, Name: "defender"}, nil)
+	db.On("GetDiplomacyRelation", mock.Anything, mock.Anything).Return(model.DiplomacyRelation{}, sql.ErrNoRows)
+	db.On("GetCharacter", int64(1)).Return(model.Character{
+		ID:            1,
+		Name:          "attacker",
+		MaxPopulation: 100,
+		ShieldUntil:   shieldUntil,
+	}, nil)
+	db.On("GetCharacterArmy", "defender").Return(map[int64]model.Army{5: garrison}, nil)
+	db.On("GetTownBuildings", int64(5)).Return(model.CharacterBuildings{rpc.BuildingType_HOUSE: 1}, nil)
+
+	return logic, db, session, march
+}
+
+func TestArmyManager_Siege_Capture(t *testing.T) {
+	logic, db, session, march := mockSiege(model.Army{rpc.UnitType_MILITIA: 10}, time.Time{})
+	now := time.Now()
+
+	db.On("GetArrivedMarches", now, marchArrivalBatchSize).Return([]model.March{march}, nil)
+	db.On("RemoveTownUnits", int64(5), model.Army{rpc.UnitType_MILITIA: 10}).Return(nil)
+	db.On("UpdateMarchUnits", int64(3), model.Army{rpc.UnitType_CAVALRY: 8}).Return(nil)
+	db.On("GetResources", int64(2)).Return(model.Resources{model.ResourceWood: 100}, nil)
+	db.On("AddOrUpdateResources", int64(2), model.Resources{model.ResourceWood: 75}).Return(nil)
+	db.On("GetCharacter", int64(2)).Return(model.Character{
+		ID:                2,
+		Name:              "defender",
+		MaxPopulation:     205,
+		CurrentPopulation: 200,
+		Resources:         model.Resources{model.ResourceWood: 100},
+	}, nil)
+	db.On("UpdateCharacter", mock.MatchedBy(func(character model.Character) bool {
+		return character.ID == 2 && character.MaxPopulation == 100 && character.CurrentPopulation == 100
+	})).Return(nil).Once()
+	db.On("UpdateCharacter", mock.Anything).Return(nil)
+	db.On("GetCharacterBuildings", "attacker").Return(model.CharacterBuildings{}, nil)
+	db.On("AddCharacterResources", "attacker", model.Resources{model.ResourceWood: 25},
+		model.StorageCapacity(model.CharacterBuildings{})).Return(nil)
+	db.On("GetTowns", "defender").Return([]model.Town{{ID: 4}, {ID: 5}}, nil)
+	db.On("ChangeTownOwner", int64(5), "attacker").Return(nil)
+	db.On("AddBattleReport", mock.MatchedBy(func(report model.BattleReport) bool {
+		return report.AttackerWon && report.Captured && report.DefenderID == 2 &&
+			report.Loot[model.ResourceWood] == 25
+	})).Return(int64(9), nil)
+	db.On("AddTownUnits", int64(5), model.Army{rpc.UnitType_CAVALRY: 8}).Return(nil)
+	db.On("DeleteMarch", int64(3)).Return(nil)
+
+	// the online attacker gets the town and the loot only after the commit
+	var after afterCommit
+	require.NoError(t, NewArmyManager(logic).arriveMarches(now, db, &after))
+	db.AssertExpectations(t)
+	require.Equal(t, 1, len(session.SelectedCharacter.Towns))
+	require.Equal(t, 0, len(logic.EventsChan))
+
+	after.run()
+	attacker := session.SelectedCharacter
+	require.Equal(t, model.Army{rpc.UnitType_CAVALRY: 8}, attacker.Army)
+	require.Equal(t, model.Resources{model.ResourceWood: 25}, attacker.Resources)
+	require.Equal(t, 2, len(attacker.Towns))
+	require.Equal(t, uint64(205), attacker.MaxPopulation)
+	require.Equal(t, uint64(1), attacker.Buildings[rpc.BuildingType_HOUSE])
+
+	reports := 0
+	for len(logic.EventsChan) > 0 {
+		event := <-logic.EventsChan
+		if battle := event.Event.GetBattleEvent(); battle != nil {
+			require.Equal(t, int64(9), battle.Report.Id)
+			require.Contains(t, []string{
+				logic.topicTokens.Private(model.CharacterTopic(1)),
+				logic.topicTokens.Private(model.CharacterTopic(2)),
+			}, event.Topic)
+			reports++
+		}
+		if arrived := event.Event.GetArmyArrivedEvent(); arrived != nil {
+			require.True(t, arrived.Stationed)
+		}
+	}
+	require.Equal(t, 2, reports)
+}
+
+func TestArmyManager_Siege_Defeat(t *testing.T) {
+	now := time.Now()
+	logic, db, session, march := mockSiege(model.Army{rpc.UnitType_MILITIA: 100}, now.Add(time.Hour))
+
+	db.On("GetArrivedMarches", now, marchArrivalBatchSize).Return([]model.March{march}, nil)
+	db.On("RemoveTownUnits", int64(5), mock.Anything).Return(nil)
+	db.On("UpdateCharacter", mock.MatchedBy(func(character model.Character) bool {
+		return character.ID == 1 && character.ShieldUntil.IsZero()
+	})).Return(nil).Once()
+	db.On("AddBattleReport", mock.MatchedBy(func(report model.BattleReport) bool {
+		return !report.AttackerWon && !report.Captured && len(report.Loot) == 0
+	})).Return(int64(9), nil)
+	db.On("DeleteMarch", int64(3)).Return(nil)
+
+	var after afterCommit
+	require.NoError(t, NewArmyManager(logic).arriveMarches(now, db, &after))
+	db.AssertExpectations(t)
+
+	require.False(t, session.SelectedCharacter.ShieldUntil.IsZero())
+
+	// the attacker loses the peace shield bought while its army was marching
+	after.run()
+	require.True(t, session.SelectedCharacter.ShieldUntil.IsZero())
+	require.True(t, session.SelectedCharacter.Army.IsEmpty())
+	require.Equal(t, 2, len(logic.EventsChan))
+}
+
+func TestSimpleLogic_GetBattleReports(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	db.On("GetBattleReports", int64(1), 0, 10).Return([]model.BattleReport{{ID: 2}, {ID: 1}}, nil)
+
+	resp, err := logic.GetBattleReports(session, &rpc.GetBattleReportsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Reports))
+	require.Equal(t, int64(2), resp.Reports[0].Id)
+}
@@ -0,0 +1,41 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// BuyPeaceShield - protects the towns of the character from the attacks, the shield can't be bought
+// while the character is protected already, so the protection can't be extended indefinitely
+func (s *SimpleLogic) BuyPeaceShield(session *PlayerSession, request *rpc.BuyPeaceShieldRequest) (*rpc.BuyPeaceShieldResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("BuyPeaceShield")
+
+	char := session.SelectedCharacter
+	now := time.Now()
+
+	if char.IsProtected(now) {
+		return nil, model.ErrAlreadyProtected
+	}
+
+	before := char.Resources.Clone()
+	if !char.Resources.Subtract(model.Resources{model.ResourceGold: s.config.PeaceShieldGoldCost}) {
+		return nil, model.ErrNotEnoughResources
+	}
+
+	char.ShieldUntil = now.Add(s.config.PeaceShieldDuration)
+
+	if err := session.Tx.UpdateCharacter(*char); err != nil {
+		s.log.WithError(err).Error("Failed to update character")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.publishEvent(model.NewResourcesChangedEvent(char.ID, before, char.Resources))
+
+	return &rpc.BuyPeaceShieldResponse{
+		ShieldUntil: char.ShieldUntil.Unix(),
+	}, nil
+}
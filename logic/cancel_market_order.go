@@ -0,0 +1,50 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+func (s *SimpleLogic) CancelMarketOrder(session *PlayerSession, request *rpc.CancelMarketOrderRequest) (*rpc.CancelMarketOrderResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"orderID":   request.OrderID,
+	}).Info("CancelMarketOrder")
+
+	order, modelErr := s.getCharacterMarketOrder(session, request.OrderID)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	if order.Status != model.MarketOrderOpen {
+		return nil, model.ErrBadRequest
+	}
+
+	tx := session.Tx
+	character := session.SelectedCharacter
+
+	order.Status = model.MarketOrderCancelled
+	order.UpdatedAt = time.Now()
+
+	if err := tx.UpdateMarketOrder(order); err != nil {
+		s.log.WithError(err).Error("Failed to update market order")
+		return nil, model.ErrInternalServerError
+	}
+
+	// Escrow was taken from the storage, so it's returned even if the storage is full now
+	resources := character.Resources.Clone()
+	resources.Add(order.Escrow(order.Remaining))
+
+	if err := tx.AddOrUpdateResources(character.ID, resources); err != nil {
+		s.log.WithError(err).Error("Failed to update character resources")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.setSessionResources(session, resources)
+
+	return &rpc.CancelMarketOrderResponse{
+		Order: order.ToRPC(),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+// CancelResearch - stops the research in progress, the cost of the tech is returned to the character
+// even if it doesn't fit the storage
+func (s *SimpleLogic) CancelResearch(session *PlayerSession, request *rpc.CancelResearchRequest) (*rpc.CancelResearchResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("CancelResearch")
+
+	research, modelErr := s.getActiveResearch(session)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	character := session.SelectedCharacter
+
+	if err := session.Tx.DeleteResearch(character.ID, research.Tech); err != nil {
+		s.log.WithError(err).Error("Failed to delete research")
+		return nil, model.ErrInternalServerError
+	}
+
+	before := character.Resources.Clone()
+	character.Resources.Add(s.techTree[research.Tech].Cost)
+
+	if err := session.Tx.AddOrUpdateResources(character.ID, character.Resources); err != nil {
+		s.log.WithError(err).Error("Failed to update character resources")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.publishEvent(model.NewResourcesChangedEvent(character.ID, before, character.Resources))
+
+	return &rpc.CancelResearchResponse{}, nil
+}
@@ -0,0 +1,155 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/model"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+var (
+	caravanUpdateFreq = 5 * time.Second
+)
+
+const (
+	// Max number of caravans delivered in one transaction
+	caravanDeliveryBatchSize = 100
+)
+
+// CaravanRisk - hook deciding which part of the cargo is lost on the way (robbers, hostile armies, etc.),
+// it's called for every caravan when it reaches the destination
+type CaravanRisk interface {
+	CaravanLoss(caravan model.Caravan, tx db.DatabaseTransaction) (model.Resources, error)
+}
+
+type CaravanManager struct {
+	logic  *SimpleLogic
+	logger *log.Entry
+	risks  []CaravanRisk
+	mutex  sync.RWMutex
+}
+
+func NewCaravanManager(l *SimpleLogic) *CaravanManager {
+	return &CaravanManager{
+		logic:  l,
+		logger: log.WithField("module", "caravan_manager"),
+	}
+}
+
+// AddRisk - registers the risk applied to all caravans
+func (c *CaravanManager) AddRisk(risk CaravanRisk) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.risks = append(c.risks, risk)
+}
+
+// overflow - returns the part of the cargo which doesn't fit into the receiver's storage
+func (c *CaravanManager) overflow(caravan model.Caravan, tx db.DatabaseTransaction) (model.Resources, error) {
+	buildings, err := tx.GetCharacterBuildings(caravan.ReceiverName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buildings: %w", err)
+	}
+
+	resources, err := tx.GetResources(caravan.ReceiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resources: %w", err)
+	}
+
+	return resources.Overflow(caravan.Resources, model.StorageCapacity(buildings)), nil
+}
+
+// arrive - applies the risks to the caravan, gives the rest of the cargo to the receiver and notifies both parties.
+// Cargo which doesn't fit into the receiver's storage is given back to the sender
+func (c *CaravanManager) arrive(caravan model.Caravan, tx db.DatabaseTransaction, after *afterCommit) error {
+	c.mutex.RLock()
+	risks := c.risks
+	c.mutex.RUnlock()
+
+	var lost model.Resources
+	for _, risk := range risks {
+		loss, err := risk.CaravanLoss(caravan, tx)
+		if err != nil {
+			return fmt.Errorf("failed to calculate loss: %w", err)
+		}
+
+		lost.Add(caravan.Lose(loss))
+	}
+
+	var returned model.Resources
+	if caravan.Status != model.CaravanLost {
+		caravan.Status = model.CaravanArrived
+
+		var err error
+		if returned, err = c.overflow(caravan, tx); err != nil {
+			return fmt.Errorf("failed to calculate overflow: %w", err)
+		}
+		caravan.Resources.Subtract(returned)
+
+		if err := c.logic.giveCharacterResources(caravan.ReceiverName, caravan.Resources, tx, after); err != nil {
+			return fmt.Errorf("failed to give resources to %s: %w", caravan.ReceiverName, err)
+		}
+
+		if !returned.IsEmpty() {
+			if err := c.logic.returnCharacterResources(nil, caravan.OwnerName, returned, tx, after); err != nil {
+				return fmt.Errorf("failed to return resources to %s: %w", caravan.OwnerName, err)
+			}
+		}
+	}
+
+	if err := tx.UpdateCaravan(caravan); err != nil {
+		return fmt.Errorf("failed to update caravan: %w", err)
+	}
+
+	after.add(func() {
+		c.logic.publishEvent(model.NewCaravanArrivedEvent(caravan.OwnerID, caravan, lost, returned))
+		if caravan.ReceiverID != caravan.OwnerID {
+			c.logic.publishEvent(model.NewCaravanArrivedEvent(caravan.ReceiverID, caravan, lost, returned))
+		}
+	})
+	return nil
+}
+
+// deliver - completes all caravans which arrival time has come
+func (c *CaravanManager) deliver(now time.Time, tx db.DatabaseTransaction, after *afterCommit) error {
+	for {
+		caravans, err := tx.GetArrivedCaravans(now, caravanDeliveryBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get arrived caravans: %w", err)
+		}
+
+		for _, caravan := range caravans {
+			if err := c.arrive(caravan, tx, after); err != nil {
+				return fmt.Errorf("failed to deliver caravan %d: %w", caravan.ID, err)
+			}
+		}
+
+		if len(caravans) < caravanDeliveryBatchSize {
+			return nil
+		}
+	}
+}
+
+func (c *CaravanManager) Update() {
+	tx, err := c.logic.db.BeginTransaction(false, true)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to begin transaction")
+		return
+	}
+
+	var after afterCommit
+	if err := c.deliver(time.Now(), tx, &after); err != nil {
+		c.logger.WithError(err).Error("Failed to deliver caravans")
+		rollBack(tx, c.logger)
+		return
+	}
+
+	if err := tx.EndTransaction(); err != nil {
+		c.logger.WithError(err).Error("Failed to commit transaction")
+		return
+	}
+
+	after.run()
+}
@@ -0,0 +1,179 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type caravanRiskMock struct {
+	loss model.Resources
+}
+
+func (r caravanRiskMock) CaravanLoss(caravan model.Caravan, tx db.DatabaseTransaction) (model.Resources, error) {
+	return r.loss, nil
+}
+
+func TestCaravan_Lose(t *testing.T) {
+	caravan := model.Caravan{Resources: model.Resources{model.ResourceWood: 10, model.ResourceFood: 5}}
+
+	lost := caravan.Lose(model.Resources{model.ResourceWood: 4, model.ResourceStone: 3})
+	require.Equal(t, model.Resources{model.ResourceWood: 4}, lost)
+	require.Equal(t, model.CaravanEnRoute, caravan.Status)
+
+	lost = caravan.Lose(model.Resources{model.ResourceWood: 100, model.ResourceFood: 100})
+	require.Equal(t, model.Resources{model.ResourceWood: 6, model.ResourceFood: 5}, lost)
+	require.Equal(t, model.CaravanLost, caravan.Status)
+}
+
+func TestSimpleLogic_SendCaravan(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		Name:      "sender",
+		Resources: model.Resources{model.ResourceWood: 100},
+		Towns:     []model.Town{{ID: 1, OwnerName: "sender"}},
+	}
+
+	db.On("GetTown", int64(2)).Return(model.Town{ID: 2, X: 30, Y: 40, OwnerName: "receiver"}, nil)
+	db.On("GetCharacterByName", "receiver").Return(model.Character{ID: 2, Name: "receiver"}, nil)
+	db.On("GetDiplomacyRelation", model.CharacterParty("sender"), model.CharacterParty("receiver")).
+		Return(model.DiplomacyRelation{}, sql.ErrNoRows)
+	db.On("AddCaravan", mock.MatchedBy(func(caravan model.Caravan) bool {
+		return caravan.ReceiverID == 2 && caravan.Resources[model.ResourceWood] == 40 &&
+			caravan.ArrivesAt.Sub(caravan.DepartedAt) == 50*logic.config.CaravanTileTravelTime
+	})).Return(int64(5), nil)
+	db.On("AddOrUpdateResources", mock.Anything, model.Resources{model.ResourceWood: 60}).Return(nil)
+
+	resp, err := logic.SendCaravan(session, &rpc.SendCaravanRequest{
+		FromTownID: 1,
+		ToTownID:   2,
+		Resources:  &rpc.Resources{Amounts: map[string]uint64{"wood": 40}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(5), resp.Caravan.Id)
+	require.Equal(t, rpc.CaravanStatus_EN_ROUTE, resp.Caravan.Status)
+	require.Equal(t, uint64(60), session.SelectedCharacter.Resources[model.ResourceWood])
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_SendCaravan_Errors(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		Name:      "sender",
+		Resources: model.Resources{model.ResourceWood: 10},
+		Towns:     []model.Town{{ID: 1, OwnerName: "sender"}},
+	}
+
+	db.On("GetTown", int64(2)).Return(model.Town{ID: 2, OwnerName: "enemy"}, nil)
+	db.On("GetTown", int64(3)).Return(model.Town{}, sql.ErrNoRows)
+	db.On("GetCharacterByName", "enemy").Return(model.Character{ID: 2, Name: "enemy"}, nil)
+	db.On("GetDiplomacyRelation", model.CharacterParty("sender"), model.CharacterParty("enemy")).
+		Return(model.DiplomacyRelation{State: model.DiplomacyWar}, nil)
+
+	_, err := logic.SendCaravan(session, &rpc.SendCaravanRequest{FromTownID: 1, ToTownID: 2})
+	require.Equal(t, model.ErrBadRequest, err)
+
+	_, err = logic.SendCaravan(session, &rpc.SendCaravanRequest{
+		FromTownID: 5, ToTownID: 2, Resources: &rpc.Resources{Amounts: map[string]uint64{"wood": 1}}})
+	require.Equal(t, model.ErrTownNotFound, err)
+
+	_, err = logic.SendCaravan(session, &rpc.SendCaravanRequest{
+		FromTownID: 1, ToTownID: 3, Resources: &rpc.Resources{Amounts: map[string]uint64{"wood": 1}}})
+	require.Equal(t, model.ErrTownNotFound, err)
+
+	_, err = logic.SendCaravan(session, &rpc.SendCaravanRequest{
+		FromTownID: 1, ToTownID: 2, Resources: &rpc.Resources{Amounts: map[string]uint64{"wood": 1}}})
+	require.Equal(t, model.ErrForbidden, err)
+	require.Equal(t, uint64(10), session.SelectedCharacter.Resources[model.ResourceWood])
+}
+
+func TestCaravanManager_Deliver(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 2, Name: "receiver", Resources: model.Resources{model.ResourceWood: 1},
+		StorageCapacity: model.StorageCapacity(nil)}
+
+	manager := NewCaravanManager(logic)
+	manager.AddRisk(caravanRiskMock{loss: model.Resources{model.ResourceWood: 3}})
+
+	now := time.Now()
+	caravan := model.Caravan{ID: 1, OwnerID: 1, OwnerName: "sender", ReceiverID: 2, ReceiverName: "receiver",
+		Resources: model.Resources{model.ResourceWood: 10}, ArrivesAt: now}
+
+	db.On("GetArrivedCaravans", now, caravanDeliveryBatchSize).Return([]model.Caravan{caravan}, nil)
+	db.On("UpdateCaravan", mock.MatchedBy(func(caravan model.Caravan) bool {
+		return caravan.Status == model.CaravanArrived && caravan.Resources[model.ResourceWood] == 7
+	})).Return(nil)
+	db.On("GetCharacterBuildings", "receiver").Return(model.CharacterBuildings{}, nil)
+	db.On("GetResources", int64(2)).Return(model.Resources{model.ResourceWood: 1}, nil)
+	db.On("AddCharacterResources", "receiver", model.Resources{model.ResourceWood: 7},
+		model.StorageCapacity(model.CharacterBuildings{})).Return(nil)
+
+	// the online receiver gets the cargo only after the commit
+	var after afterCommit
+	require.NoError(t, manager.deliver(now, db, &after))
+	require.Equal(t, uint64(1), session.SelectedCharacter.Resources[model.ResourceWood])
+	require.Equal(t, 0, len(logic.EventsChan))
+
+	after.run()
+	require.Equal(t, uint64(8), session.SelectedCharacter.Resources[model.ResourceWood])
+
+	// resources of the online receiver, then the arrival for both parties
+	require.Equal(t, 3, len(logic.EventsChan))
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(2)), (<-logic.EventsChan).Topic)
+
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), event.Topic)
+	require.Equal(t, uint64(3), event.Event.GetCaravanArrivedEvent().Lost.Amounts["wood"])
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(2)), (<-logic.EventsChan).Topic)
+
+	db.AssertExpectations(t)
+}
+
+func TestCaravanManager_Deliver_Overflow(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "sender"}
+
+	manager := NewCaravanManager(logic)
+
+	now := time.Now()
+	caravan := model.Caravan{ID: 1, OwnerID: 1, OwnerName: "sender", ReceiverID: 2, ReceiverName: "receiver",
+		Resources: model.Resources{model.ResourceWood: 10, model.ResourceStone: 5}, ArrivesAt: now}
+	capacity := model.StorageCapacity(model.CharacterBuildings{})
+
+	db.On("GetArrivedCaravans", now, caravanDeliveryBatchSize).Return([]model.Caravan{caravan}, nil)
+	db.On("GetCharacterBuildings", "receiver").Return(model.CharacterBuildings{}, nil)
+	db.On("GetResources", int64(2)).Return(model.Resources{model.ResourceWood: capacity[model.ResourceWood] - 4}, nil)
+
+	// the receiver gets only the part which fits into the storage, the rest goes back to the sender
+	delivered := model.Resources{model.ResourceWood: 4, model.ResourceStone: 5}
+	db.On("UpdateCaravan", mock.MatchedBy(func(caravan model.Caravan) bool {
+		return caravan.Status == model.CaravanArrived && caravan.Resources.IsEnough(delivered) &&
+			delivered.IsEnough(caravan.Resources)
+	})).Return(nil)
+	db.On("AddCharacterResources", "receiver", delivered, capacity).Return(nil)
+	db.On("AddCharacterResources", "sender", model.Resources{model.ResourceWood: 6}, model.Resources(nil)).Return(nil)
+
+	var after afterCommit
+	require.NoError(t, manager.deliver(now, db, &after))
+	after.run()
+
+	require.Equal(t, uint64(6), session.SelectedCharacter.Resources[model.ResourceWood])
+
+	// resources of the online sender, then the arrival for both parties
+	require.Equal(t, 3, len(logic.EventsChan))
+	require.NotNil(t, (<-logic.EventsChan).Event.GetResourcesChangedEvent())
+
+	event := (<-logic.EventsChan).Event.GetCaravanArrivedEvent()
+	require.Equal(t, uint64(6), event.Returned.Amounts["wood"])
+	require.Equal(t, uint64(4), event.Caravan.Resources.Amounts["wood"])
+
+	db.AssertExpectations(t)
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+)
+
+// regionOf - returns coordinates of the chat region containing the world tile
+func (s *SimpleLogic) regionOf(tile geometry.Point) geometry.Point {
+	regionSize := s.config.ChatRegionSize
+	if regionSize <= 0 {
+		regionSize = 1
+	}
+
+	return geometry.NewGrid(s.config.ChunkSize * regionSize).WorldToChunk(tile)
+}
+
+// chatChannels - returns all chat channels the character is member of: the global channel,
+// regions of the character's towns, channels of own and neighbour towns and the alliance channel
+func (s *SimpleLogic) chatChannels(character *model.Character, tx db.DatabaseTransaction) ([]model.ChatChannel, error) {
+	channels := []model.ChatChannel{model.GlobalChatChannel()}
+	known := map[model.ChatChannel]bool{channels[0]: true}
+
+	add := func(channel model.ChatChannel) {
+		if !known[channel] {
+			known[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+
+	for _, town := range character.Towns {
+		add(model.RegionChatChannel(s.regionOf(town.Location())))
+		add(model.TownChatChannel(town.ID))
+
+		neighbours, err := tx.GetTownsForRect(geometry.RectAround(town.Location(), consts.TownChatChannelRadius))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, neighbour := range neighbours {
+			if geometry.Distance(town.Location(), neighbour.Location()) <= consts.TownChatChannelRadius {
+				add(model.TownChatChannel(neighbour.ID))
+			}
+		}
+	}
+
+	if character.AllianceID != 0 {
+		add(model.AllianceChatChannel(character.AllianceID))
+	}
+
+	return channels, nil
+}
+
+// withAllianceChatChannel - replaces the alliance channel of the list, zero allianceID removes it
+func withAllianceChatChannel(channels []model.ChatChannel, allianceID int64) []model.ChatChannel {
+	var result []model.ChatChannel
+	for _, channel := range channels {
+		if channel.Type != model.ChatChannelAlliance {
+			result = append(result, channel)
+		}
+	}
+
+	if allianceID != 0 {
+		result = append(result, model.AllianceChatChannel(allianceID))
+	}
+
+	return result
+}
+
+// updateChatChannels - refreshes chat channels of the session character,
+// should be called when the character's towns are changed
+func (s *SimpleLogic) updateChatChannels(session *PlayerSession) error {
+	channels, err := s.chatChannels(session.SelectedCharacter, session.Tx)
+	if err != nil {
+		return err
+	}
+
+	session.ChatChannels = channels
+	return nil
+}
+
+// isChatChannelMember - checks if the session character can read and write to the channel
+func (s *SimpleLogic) isChatChannelMember(session *PlayerSession, channel model.ChatChannel) bool {
+	if channel.Type == model.ChatChannelGlobal {
+		return true
+	}
+
+	for _, member := range session.ChatChannels {
+		if member == channel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// chatChannelTopics - returns event topics of the session chat channels except the global one
+func (s *SimpleLogic) chatChannelTopics(session *PlayerSession) (topics []string) {
+	for _, channel := range session.ChatChannels {
+		if channel.Type != model.ChatChannelGlobal {
+			topics = append(topics, channel.Topic())
+		}
+	}
+
+	return topics
+}
+
+// getChatChannel - parses the channel name and checks that the session character is its member
+func (s *SimpleLogic) getChatChannel(session *PlayerSession, name string) (model.ChatChannel, model.Error) {
+	channel, err := model.ParseChatChannel(name)
+	if err != nil {
+		return channel, model.ErrUnknownChatChannel
+	}
+
+	if !s.isChatChannelMember(session, channel) {
+		return channel, model.ErrNotChatChannelMember
+	}
+
+	return channel, nil
+}
@@ -0,0 +1,115 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseChatChannel(t *testing.T) {
+	channels := map[string]model.ChatChannel{
+		"":            model.GlobalChatChannel(),
+		"GLOBAL":      model.GlobalChatChannel(),
+		"REGION/-1/2": model.RegionChatChannel(geometry.Point{X: -1, Y: 2}),
+		"TOWN/15":     model.TownChatChannel(15),
+		"ALLIANCE/3":  model.AllianceChatChannel(3),
+	}
+
+	for name, expected := range channels {
+		channel, err := model.ParseChatChannel(name)
+		require.NoError(t, err, name)
+		require.Equal(t, expected, channel, name)
+	}
+
+	for _, name := range []string{"TOWN/1abc", "REGION/1", "SOMETHING/1/2"} {
+		_, err := model.ParseChatChannel(name)
+		require.Error(t, err, name)
+	}
+
+	require.Equal(t, "GLOBAL", model.GlobalChatChannel().Topic())
+	require.Equal(t, "CHAT/TOWN/15/", model.TownChatChannel(15).Topic())
+}
+
+func TestSimpleLogic_RegionOf(t *testing.T) {
+	logic, _, _ := NewLogicMock()
+	logic.config.ChunkSize = 10
+	logic.config.ChatRegionSize = 2
+
+	require.Equal(t, geometry.Point{X: 0, Y: 0}, logic.regionOf(geometry.Point{X: 19, Y: 0}))
+	require.Equal(t, geometry.Point{X: 1, Y: -1}, logic.regionOf(geometry.Point{X: 20, Y: -1}))
+}
+
+func TestSimpleLogic_SendChatMessage_Channel(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+	session.ChatChannels = []model.ChatChannel{model.GlobalChatChannel(), model.TownChatChannel(5)}
+
+	db.On("GetChatMute", "test").Return(model.ChatMute{}, sql.ErrNoRows)
+	db.On("AddChatMessage", mock.MatchedBy(func(message model.ChatMessage) bool {
+		return message.Channel == "TOWN/5"
+	})).Return(int64(3), nil)
+
+	resp, err := logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: "hi", Channel: "TOWN/5"})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), resp.MessageID)
+
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private("CHAT/TOWN/5/"), event.Topic)
+	require.Equal(t, "TOWN/5", event.Event.GetChatMessageEvent().Message.Channel)
+}
+
+func TestSimpleLogic_SendChatMessage_NotMember(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockNotMuted(db)
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+	session.ChatChannels = []model.ChatChannel{model.GlobalChatChannel()}
+
+	resp, err := logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: "hi", Channel: "ALLIANCE/1"})
+	require.EqualError(t, err, model.ErrNotChatChannelMember.Error())
+	require.Nil(t, resp)
+
+	resp, err = logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: "hi", Channel: "UNKNOWN"})
+	require.EqualError(t, err, model.ErrUnknownChatChannel.Error())
+	require.Nil(t, resp)
+}
+
+func TestSimpleLogic_GetChatHistory_Channel(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+	session.ChatChannels = []model.ChatChannel{model.GlobalChatChannel(), model.RegionChatChannel(geometry.Point{X: 1, Y: 2})}
+
+	messages := []model.ChatMessage{{ID: 1, Sender: "test", Text: "hi", Channel: "REGION/1/2"}}
+	db.On("GetChatMessages", "REGION/1/2", int64(0), int64(0), 10).Return(messages, nil)
+
+	resp, err := logic.GetChatHistory(session, &rpc.GetChatHistoryRequest{Channel: "REGION/1/2"})
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 1)
+	require.Equal(t, "REGION/1/2", resp.Messages[0].Channel)
+}
+
+func TestSimpleLogic_GetChatHistory_Cursors(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+	session.ChatChannels = []model.ChatChannel{model.GlobalChatChannel()}
+
+	messages := []model.ChatMessage{{ID: 9, Sender: "test", Text: "hi", Channel: "GLOBAL"}}
+	db.On("GetChatMessages", "GLOBAL", int64(10), int64(0), consts.DefaultChatHistoryMaxPageSize).Return(messages, nil)
+
+	resp, err := logic.GetChatHistory(session, &rpc.GetChatHistoryRequest{BeforeID: 10, Count: 1000})
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 1)
+	require.Equal(t, int64(9), resp.Messages[0].Id)
+
+	resp, err = logic.GetChatHistory(session, &rpc.GetChatHistoryRequest{AfterID: -1})
+	require.EqualError(t, err, model.ErrBadRequest.Error())
+	require.Nil(t, resp)
+
+	db.AssertExpectations(t)
+}
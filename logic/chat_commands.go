@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	"sort"
+	"strings"
+)
+
+const (
+	chatCommandPrefix = "/"
+)
+
+// ChatCommand - command typed into the chat instead of the message, e.g. /whisper <character> <text>
+type ChatCommand struct {
+	Name          string // Command name without the prefix
+	Usage         string
+	Description   string
+	ModeratorOnly bool
+	// Execute - runs the command with the arguments (text after the command name).
+	// Returns the answer shown only to the command author
+	Execute func(s *SimpleLogic, session *PlayerSession, args string) (string, model.Error)
+}
+
+// ChatCommandRegistry - chat commands available to the players
+type ChatCommandRegistry struct {
+	commands map[string]ChatCommand
+}
+
+func NewChatCommandRegistry() *ChatCommandRegistry {
+	return &ChatCommandRegistry{
+		commands: make(map[string]ChatCommand),
+	}
+}
+
+// Register - adds the command or replaces the command with the same name
+func (r *ChatCommandRegistry) Register(command ChatCommand) {
+	r.commands[strings.ToLower(command.Name)] = command
+}
+
+func (r *ChatCommandRegistry) Get(name string) (ChatCommand, bool) {
+	command, found := r.commands[strings.ToLower(name)]
+	return command, found
+}
+
+// Available - returns commands available to the session sorted by name
+func (r *ChatCommandRegistry) Available(session *PlayerSession) (result []ChatCommand) {
+	for _, command := range r.commands {
+		if !command.ModeratorOnly || session.IsModerator {
+			result = append(result, command)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// RegisterChatCommand - makes the command available in the chat
+func (s *SimpleLogic) RegisterChatCommand(command ChatCommand) {
+	s.chatCommands.Register(command)
+}
+
+func isChatCommand(text string) bool {
+	return strings.HasPrefix(text, chatCommandPrefix)
+}
+
+// parseChatCommand - splits the text into the command name and the arguments
+func parseChatCommand(text string) (name string, args string) {
+	text = strings.TrimPrefix(text, chatCommandPrefix)
+	parts := strings.SplitN(strings.TrimSpace(text), " ", 2)
+
+	name = parts[0]
+	if len(parts) == 2 {
+		args = strings.TrimSpace(parts[1])
+	}
+
+	return name, args
+}
+
+// executeChatCommand - runs the command and sends its answer as a private system message.
+// User errors (unknown command, wrong arguments, etc.) are reported in the answer too,
+// the changes the failed command made before the error are rolled back
+func (s *SimpleLogic) executeChatCommand(session *PlayerSession, text string) model.Error {
+	name, args := parseChatCommand(text)
+
+	command, found := s.chatCommands.Get(name)
+	if !found || (command.ModeratorOnly && !session.IsModerator) {
+		s.sendCommandAnswer(session, consts.MessageUnknownChatCommand(name))
+		return nil
+	}
+
+	answer, err := command.Execute(s, session, args)
+	if err != nil {
+		if rollBackErr := session.Tx.RollBack(); rollBackErr != nil {
+			s.log.WithError(rollBackErr).Error("Failed to roll back chat command")
+			return model.ErrInternalServerError
+		}
+
+		if err == model.ErrInternalServerError {
+			return err
+		}
+
+		answer = err.GetMessage()
+	}
+
+	s.sendCommandAnswer(session, answer)
+	return nil
+}
+
+func (s *SimpleLogic) sendCommandAnswer(session *PlayerSession, answer string) {
+	if answer != "" {
+		s.publishEvent(model.NewPrivateSystemChatMessageEvent(session.EventTopic, answer))
+	}
+}
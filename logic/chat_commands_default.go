@@ -0,0 +1,186 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// registerDefaultChatCommands - registers commands available out of the box
+func (s *SimpleLogic) registerDefaultChatCommands() {
+	s.chatCommands = NewChatCommandRegistry()
+
+	s.RegisterChatCommand(ChatCommand{
+		Name:        "help",
+		Usage:       "/help",
+		Description: "show the list of commands",
+		Execute:     helpCommand,
+	})
+	s.RegisterChatCommand(ChatCommand{
+		Name:        "who",
+		Usage:       "/who",
+		Description: "show characters which are online",
+		Execute:     whoCommand,
+	})
+	s.RegisterChatCommand(ChatCommand{
+		Name:        "whisper",
+		Usage:       "/whisper <character> <text>",
+		Description: "send a private message",
+		Execute:     whisperCommand,
+	})
+	s.RegisterChatCommand(ChatCommand{
+		Name:        "town",
+		Usage:       "/town",
+		Description: "show your towns",
+		Execute:     townCommand,
+	})
+	s.RegisterChatCommand(ChatCommand{
+		Name:          "mute",
+		Usage:         "/mute <character> <minutes> [reason]",
+		Description:   "forbid the character to write to the chat",
+		ModeratorOnly: true,
+		Execute:       muteCommand,
+	})
+	s.RegisterChatCommand(ChatCommand{
+		Name:          "kick",
+		Usage:         "/kick <character>",
+		Description:   "close all sessions of the character",
+		ModeratorOnly: true,
+		Execute:       kickCommand,
+	})
+	s.RegisterChatCommand(ChatCommand{
+		Name:          "broadcast",
+		Usage:         "/broadcast <text>",
+		Description:   "send a system message to all players",
+		ModeratorOnly: true,
+		Execute:       broadcastCommand,
+	})
+}
+
+func usageError(usage string) model.Error {
+	return model.NewError(consts.MessageChatCommandUsage(usage), rpc.Error_BAD_REQUEST)
+}
+
+func helpCommand(s *SimpleLogic, session *PlayerSession, _ string) (string, model.Error) {
+	lines := []string{"Available commands:"}
+	for _, command := range s.chatCommands.Available(session) {
+		lines = append(lines, fmt.Sprintf("%s - %s", command.Usage, command.Description))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func whoCommand(s *SimpleLogic, _ *PlayerSession, _ string) (string, model.Error) {
+	var names []string
+	known := make(map[string]bool)
+
+	for _, other := range s.onlineSessions() {
+		character := other.SelectedCharacter
+		if character != nil && !known[character.Name] {
+			known[character.Name] = true
+			names = append(names, character.Name)
+		}
+	}
+
+	sort.Strings(names)
+	return fmt.Sprintf("Online (%d): %s", len(names), strings.Join(names, ", ")), nil
+}
+
+func whisperCommand(s *SimpleLogic, session *PlayerSession, args string) (string, model.Error) {
+	parts := strings.SplitN(args, " ", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		return "", usageError("/whisper <character> <text>")
+	}
+
+	// The mute and the flood limit are already checked for the command
+	if _, err := s.sendDirectMessage(session, parts[0], strings.TrimSpace(parts[1])); err != nil {
+		return "", err
+	}
+
+	// The message itself is delivered to the author by the direct message event
+	return "", nil
+}
+
+func townCommand(_ *SimpleLogic, session *PlayerSession, _ string) (string, model.Error) {
+	towns := session.SelectedCharacter.Towns
+	if len(towns) == 0 {
+		return "You don't have towns yet", nil
+	}
+
+	lines := []string{"Your towns:"}
+	for _, town := range towns {
+		lines = append(lines, fmt.Sprintf("%s at (%d, %d), population %d, territory radius %.0f",
+			town.Name, town.X, town.Y, town.Population, town.TerritoryRadius()))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func muteCommand(s *SimpleLogic, session *PlayerSession, args string) (string, model.Error) {
+	parts := strings.SplitN(args, " ", 3)
+	if len(parts) < 2 {
+		return "", usageError("/mute <character> <minutes> [reason]")
+	}
+
+	minutes, parseErr := strconv.ParseInt(parts[1], 10, 64)
+	if parseErr != nil || minutes <= 0 || minutes > consts.ChatMuteMaxDuration/60 {
+		return "", usageError("/mute <character> <minutes> [reason]")
+	}
+
+	request := &rpc.MuteCharacterRequest{
+		SessionID: session.SessionID,
+		Character: parts[0],
+		Duration:  minutes * 60,
+	}
+	if len(parts) == 3 {
+		request.Reason = parts[2]
+	}
+
+	if _, err := s.MuteCharacter(session, request); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s is muted for %d minutes", request.Character, minutes), nil
+}
+
+func kickCommand(s *SimpleLogic, session *PlayerSession, args string) (string, model.Error) {
+	name := strings.TrimSpace(args)
+	if name == "" {
+		return "", usageError("/kick <character>")
+	}
+
+	kicked := s.closeCharacterSessions(name)
+	if kicked == nil {
+		return fmt.Sprintf("%s isn't online", name), nil
+	}
+
+	if err := s.logModeration(session, model.ModerationLogEntry{
+		ModeratorName: session.SelectedCharacter.Name,
+		Action:        model.ModerationActionKick,
+		TargetName:    name,
+	}); err != nil {
+		return "", err
+	}
+
+	s.publishEvent(model.NewPrivateSystemChatMessageEvent(model.CharacterTopic(kicked.ID), consts.MessageKicked))
+	s.publishEvent(model.NewCharacterStatusEvent(name, false))
+
+	return fmt.Sprintf("%s is kicked", name), nil
+}
+
+func broadcastCommand(s *SimpleLogic, session *PlayerSession, args string) (string, model.Error) {
+	if strings.TrimSpace(args) == "" {
+		return "", usageError("/broadcast <text>")
+	}
+
+	if err := s.sendSystemChatMessage(args, session.Tx); err != nil {
+		s.log.WithError(err).Error("Failed to send system chat message")
+		return "", model.ErrInternalServerError
+	}
+
+	return "", nil
+}
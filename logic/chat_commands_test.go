@@ -0,0 +1,178 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// mockNotMuted - none of the characters is muted
+func mockNotMuted(db *DatabaseTransactionMock) {
+	db.On("GetChatMute", mock.Anything).Return(model.ChatMute{}, sql.ErrNoRows)
+}
+
+// sendCommand - sends the chat command and returns the private answer
+func sendCommand(t *testing.T, logic *SimpleLogic, session *PlayerSession, text string) string {
+	resp, err := logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: text})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	require.NotZero(t, len(logic.EventsChan), "command should answer")
+	event := <-logic.EventsChan
+	require.Equal(t, session.EventTopic, event.Topic)
+
+	return event.Event.GetChatMessageEvent().Message.Text
+}
+
+func TestParseChatCommand(t *testing.T) {
+	name, args := parseChatCommand("/whisper  friend hello there ")
+	require.Equal(t, "whisper", name)
+	require.Equal(t, "friend hello there", args)
+
+	name, args = parseChatCommand("/who")
+	require.Equal(t, "who", name)
+	require.Empty(t, args)
+}
+
+func TestSimpleLogic_ChatCommand_Help(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockNotMuted(db)
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "player"}
+
+	answer := sendCommand(t, logic, session, "/help")
+	require.Contains(t, answer, "/whisper <character> <text>")
+	require.NotContains(t, answer, "/kick")
+
+	session.IsModerator = true
+	answer = sendCommand(t, logic, session, "/HELP")
+	require.Contains(t, answer, "/kick <character>")
+}
+
+func TestSimpleLogic_ChatCommand_Unknown(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockNotMuted(db)
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "player"}
+
+	require.Equal(t, consts.MessageUnknownChatCommand("dance"), sendCommand(t, logic, session, "/dance"))
+
+	// Moderator commands are hidden from the players
+	require.Equal(t, consts.MessageUnknownChatCommand("kick"), sendCommand(t, logic, session, "/kick someone"))
+}
+
+func TestSimpleLogic_ChatCommand_Who(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockNotMuted(db)
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "player"}
+
+	other := NewPlayerSession(2)
+	other.SelectedCharacter = &model.Character{ID: 2, Name: "another"}
+	logic.sessions[other.SessionID] = other
+	logic.sessions["no character"] = NewPlayerSession(3)
+
+	require.Equal(t, "Online (2): another, player", sendCommand(t, logic, session, "/who"))
+}
+
+func TestSimpleLogic_ChatCommand_WhisperUsage(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockNotMuted(db)
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "player"}
+
+	require.Equal(t, consts.MessageChatCommandUsage("/whisper <character> <text>"),
+		sendCommand(t, logic, session, "/whisper friend"))
+}
+
+func TestSimpleLogic_ChatCommand_Kick(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockNotMuted(db)
+	logic.config.ChatMessageMaxLength = 100
+	session.IsModerator = true
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "moderator"}
+
+	target := NewPlayerSession(2)
+	target.SelectedCharacter = &model.Character{ID: 2, Name: "troll"}
+	logic.sessions[target.SessionID] = target
+
+	db.On("AddModerationLogEntry", mock.MatchedBy(func(entry model.ModerationLogEntry) bool {
+		return entry.Action == model.ModerationActionKick && entry.TargetName == "troll"
+	})).Return(nil)
+
+	_, err := logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: "/kick troll"})
+	require.NoError(t, err)
+	require.NotContains(t, logic.sessions, target.SessionID)
+
+	notice := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(2)), notice.Topic)
+	require.Equal(t, consts.MessageKicked, notice.Event.GetChatMessageEvent().Message.Text)
+
+	status := <-logic.EventsChan
+	require.False(t, status.Event.GetCharacterStatusEvent().Online)
+
+	answer := <-logic.EventsChan
+	require.Equal(t, "troll is kicked", answer.Event.GetChatMessageEvent().Message.Text)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_RegisterChatCommand(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockNotMuted(db)
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "player"}
+
+	logic.RegisterChatCommand(ChatCommand{
+		Name:        "roll",
+		Usage:       "/roll",
+		Description: "roll a dice",
+		Execute: func(s *SimpleLogic, session *PlayerSession, args string) (string, model.Error) {
+			return "4", nil
+		},
+	})
+
+	require.Equal(t, "4", sendCommand(t, logic, session, "/roll"))
+	require.Contains(t, sendCommand(t, logic, session, "/help"), "/roll - roll a dice")
+}
+
+func TestSimpleLogic_ChatCommand_Muted(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "player"}
+
+	mute := model.ChatMute{CharacterName: "player", ExpiresAt: time.Now().Add(time.Hour), Reason: "spam"}
+	db.On("GetChatMute", "player").Return(mute, nil)
+
+	resp, err := logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: "/who"})
+	require.EqualError(t, err, model.ErrCharacterMuted.Error())
+	require.Nil(t, resp)
+
+	// Only the mute notice, the command isn't answered
+	require.Equal(t, 1, len(logic.EventsChan))
+	notice := <-logic.EventsChan
+	require.Equal(t, consts.MessageMuted(mute.ExpiresAt, "spam"), notice.Event.GetChatMessageEvent().Message.Text)
+}
+
+func TestSimpleLogic_ChatCommand_FailedRollsBack(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockNotMuted(db)
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "player"}
+
+	logic.RegisterChatCommand(ChatCommand{
+		Name:  "fail",
+		Usage: "/fail",
+		Execute: func(s *SimpleLogic, session *PlayerSession, args string) (string, model.Error) {
+			return "", model.ErrBadRequest
+		},
+	})
+
+	require.Equal(t, model.ErrBadRequest.GetMessage(), sendCommand(t, logic, session, "/fail"))
+	require.True(t, db.IsCompleted(), "partial writes of the failed command should be rolled back")
+}
@@ -0,0 +1,64 @@
+package logic
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ChatFilter - masks banned words in the chat messages.
+// Words are matched case-insensitively anywhere in the text
+type ChatFilter struct {
+	pattern *regexp.Regexp
+}
+
+func NewChatFilter(bannedWords []string) *ChatFilter {
+	var quoted []string
+	for _, word := range bannedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+
+	if len(quoted) == 0 {
+		return &ChatFilter{}
+	}
+
+	return &ChatFilter{
+		pattern: regexp.MustCompile("(?i)" + strings.Join(quoted, "|")),
+	}
+}
+
+// BannedWords - returns the banned words found in the text in lower case, every word is returned once
+func (f *ChatFilter) BannedWords(text string) []string {
+	if f.pattern == nil {
+		return nil
+	}
+
+	var words []string
+	found := make(map[string]bool)
+	for _, word := range f.pattern.FindAllString(text, -1) {
+		if word = strings.ToLower(word); !found[word] {
+			found[word] = true
+			words = append(words, word)
+		}
+	}
+
+	return words
+}
+
+// Mask - replaces every letter of the banned words with an asterisk.
+// Returns true if the text contained banned words
+func (f *ChatFilter) Mask(text string) (string, bool) {
+	if f.pattern == nil {
+		return text, false
+	}
+
+	masked := false
+	result := f.pattern.ReplaceAllStringFunc(text, func(word string) string {
+		masked = true
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	})
+
+	return result, masked
+}
@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// rejectChatMessage - notifies the session about the rejected message and returns the error
+func (s *SimpleLogic) rejectChatMessage(session *PlayerSession, err model.Error, notice string) model.Error {
+	s.publishEvent(model.NewPrivateSystemChatMessageEvent(session.EventTopic, notice))
+	return err
+}
+
+// checkChatRateLimit - returns an error if the session character sends messages and commands too often
+func (s *SimpleLogic) checkChatRateLimit(session *PlayerSession) model.Error {
+	if !s.chatLimiter.Allow(session.SelectedCharacter.ID, time.Now()) {
+		return s.rejectChatMessage(session, model.ErrChatRateLimited, consts.MessageChatRateLimited)
+	}
+
+	return nil
+}
+
+// moderateChatMessage - masks banned words of the message.
+// Returns text which should be sent instead of the original one
+func (s *SimpleLogic) moderateChatMessage(session *PlayerSession, text string) (string, model.Error) {
+	character := session.SelectedCharacter
+
+	masked, isMasked := s.chatFilter.Mask(text)
+	if !isMasked {
+		return text, nil
+	}
+
+	if err := s.logModeration(session, model.ModerationLogEntry{
+		ModeratorName: consts.SystemUserName,
+		Action:        model.ModerationActionFilter,
+		TargetName:    character.Name,
+		// The message itself isn't stored, it could be a private one
+		Reason: consts.ModerationReasonBannedWords(s.chatFilter.BannedWords(text)),
+	}); err != nil {
+		return "", err
+	}
+
+	s.publishEvent(model.NewPrivateSystemChatMessageEvent(session.EventTopic, consts.MessageWordsMasked))
+	return masked, nil
+}
+
+// checkChatMute - returns an error if the session character is muted
+func (s *SimpleLogic) checkChatMute(session *PlayerSession) model.Error {
+	tx := session.Tx
+
+	tx.SetAutoRollBack(false)
+	mute, err := tx.GetChatMute(session.SelectedCharacter.Name, time.Now())
+	tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		s.log.WithError(err).Error("Failed to get chat mute")
+		return model.ErrInternalServerError
+	}
+
+	return s.rejectChatMessage(session, model.ErrCharacterMuted, consts.MessageMuted(mute.ExpiresAt, mute.Reason))
+}
+
+func (s *SimpleLogic) logModeration(session *PlayerSession, entry model.ModerationLogEntry) model.Error {
+	entry.CreatedAt = time.Now()
+
+	if err := session.Tx.AddModerationLogEntry(entry); err != nil {
+		s.log.WithError(err).Error("Failed to add moderation log entry")
+		return model.ErrInternalServerError
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"fmt"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestChatRateLimiter(t *testing.T) {
+	limiter := NewChatRateLimiter(2, time.Second)
+	now := time.Now()
+
+	require.True(t, limiter.Allow(1, now))
+	require.True(t, limiter.Allow(1, now.Add(100*time.Millisecond)))
+	require.False(t, limiter.Allow(1, now.Add(200*time.Millisecond)))
+	require.True(t, limiter.Allow(2, now.Add(200*time.Millisecond)), "limit is per character")
+
+	// The first message leaves the window
+	require.True(t, limiter.Allow(1, now.Add(time.Second)))
+	require.False(t, limiter.Allow(1, now.Add(time.Second)))
+}
+
+func TestChatFilter_Mask(t *testing.T) {
+	filter := NewChatFilter([]string{"darn", "дурак", " "})
+
+	text, masked := filter.Mask("Darn it, ты дурак")
+	require.True(t, masked)
+	require.Equal(t, "**** it, ты *****", text)
+
+	text, masked = filter.Mask("hello")
+	require.False(t, masked)
+	require.Equal(t, "hello", text)
+
+	text, masked = NewChatFilter(nil).Mask("darn")
+	require.False(t, masked)
+	require.Equal(t, "darn", text)
+}
+
+func TestChatFilter_BannedWords(t *testing.T) {
+	filter := NewChatFilter([]string{"darn", "дурак"})
+
+	require.Equal(t, []string{"darn", "дурак"}, filter.BannedWords("Darn it, ты дурак, darn"))
+	require.Empty(t, filter.BannedWords("hello"))
+	require.Empty(t, NewChatFilter(nil).BannedWords("darn"))
+}
+
+func TestSimpleLogic_SendChatMessage_RateLimited(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChatMessageMaxLength = 100
+	logic.chatLimiter = NewChatRateLimiter(1, time.Minute)
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	db.On("GetChatMute", "test").Return(model.ChatMute{}, sql.ErrNoRows)
+	db.On("AddChatMessage", mock.Anything).Return(int64(1), nil)
+
+	_, err := logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: "first"})
+	require.NoError(t, err)
+	<-logic.EventsChan
+
+	resp, err := logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: "second"})
+	require.EqualError(t, err, model.ErrChatRateLimited.Error())
+	require.Nil(t, resp)
+
+	notice := <-logic.EventsChan
+	require.Equal(t, session.EventTopic, notice.Topic)
+	require.Equal(t, consts.MessageChatRateLimited, notice.Event.GetChatMessageEvent().Message.Text)
+}
+
+func TestSimpleLogic_SendChatMessage_Masked(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChatMessageMaxLength = 100
+	logic.chatFilter = NewChatFilter([]string{"darn"})
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	db.On("GetChatMute", "test").Return(model.ChatMute{}, sql.ErrNoRows)
+	db.On("AddModerationLogEntry", mock.MatchedBy(func(entry model.ModerationLogEntry) bool {
+		return entry.Action == model.ModerationActionFilter && entry.TargetName == "test" && entry.Reason == "banned words: darn"
+	})).Return(nil)
+	db.On("AddChatMessage", mock.MatchedBy(func(message model.ChatMessage) bool {
+		return message.Text == "**** it"
+	})).Return(int64(1), nil)
+
+	_, err := logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: "darn it"})
+	require.NoError(t, err)
+
+	notice := <-logic.EventsChan
+	require.Equal(t, consts.MessageWordsMasked, notice.Event.GetChatMessageEvent().Message.Text)
+
+	message := <-logic.EventsChan
+	require.Equal(t, "**** it", message.Event.GetChatMessageEvent().Message.Text)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_SendChatMessage_Muted(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	mute := model.ChatMute{CharacterName: "test", ExpiresAt: time.Now().Add(time.Hour), Reason: "spam"}
+	db.On("GetChatMute", "test").Return(mute, nil)
+
+	resp, err := logic.SendChatMessage(session, &rpc.SendChatMessageRequest{Text: "hello"})
+	require.EqualError(t, err, model.ErrCharacterMuted.Error())
+	require.Nil(t, resp)
+
+	notice := <-logic.EventsChan
+	require.Equal(t, session.EventTopic, notice.Topic)
+	require.Equal(t, consts.MessageMuted(mute.ExpiresAt, "spam"), notice.Event.GetChatMessageEvent().Message.Text)
+}
+
+func TestSimpleLogic_MuteCharacter(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.IsModerator = true
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "moderator"}
+
+	db.On("GetCharacterByName", "spammer").Return(model.Character{ID: 2, Name: "spammer"}, nil)
+	db.On("AddChatMute", mock.MatchedBy(func(mute model.ChatMute) bool {
+		return mute.CharacterName == "spammer" && mute.ModeratorName == "moderator" &&
+			time.Until(mute.ExpiresAt) > 59*time.Second
+	})).Return(nil)
+	db.On("AddModerationLogEntry", mock.MatchedBy(func(entry model.ModerationLogEntry) bool {
+		return entry.Action == model.ModerationActionMute && entry.TargetName == "spammer"
+	})).Return(nil)
+
+	resp, err := logic.MuteCharacter(session, &rpc.MuteCharacterRequest{Character: "spammer", Duration: 60})
+	require.NoError(t, err)
+	require.NotZero(t, resp.ExpiresAt)
+
+	notice := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(2)), notice.Topic)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_MuteCharacter_TooLong(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChatMessageMaxLength = 100
+	session.IsModerator = true
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "moderator"}
+
+	resp, err := logic.MuteCharacter(session, &rpc.MuteCharacterRequest{Character: "spammer", Duration: math.MaxInt64})
+	require.EqualError(t, err, model.ErrBadRequest.Error())
+	require.Nil(t, resp)
+
+	// Minutes of the chat command are checked before they are converted to seconds
+	mockNotMuted(db)
+	answer := sendCommand(t, logic, session, fmt.Sprintf("/mute spammer %d", math.MaxInt64/60+1))
+	require.Equal(t, consts.MessageChatCommandUsage("/mute <character> <minutes> [reason]"), answer)
+	db.AssertNotCalled(t, "AddChatMute", mock.Anything)
+}
+
+func TestSimpleLogic_MuteCharacter_NotModerator(t *testing.T) {
+	logic, _, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "player"}
+
+	resp, err := logic.MuteCharacter(session, &rpc.MuteCharacterRequest{Character: "spammer", Duration: 60})
+	require.EqualError(t, err, model.ErrForbidden.Error())
+	require.Nil(t, resp)
+}
@@ -0,0 +1,48 @@
+package logic
+
+import (
+	"sync"
+	"time"
+)
+
+// ChatRateLimiter - allows every character to send at most 'limit' messages during the sliding window.
+// Limiter with zero limit allows everything
+type ChatRateLimiter struct {
+	mutex    sync.Mutex
+	limit    int
+	window   time.Duration
+	messages map[int64][]time.Time // Send time of the character messages inside of the window
+}
+
+func NewChatRateLimiter(limit int, window time.Duration) *ChatRateLimiter {
+	return &ChatRateLimiter{
+		limit:    limit,
+		window:   window,
+		messages: make(map[int64][]time.Time),
+	}
+}
+
+// Allow - registers the message and returns true if the character hasn't reached the limit yet
+func (l *ChatRateLimiter) Allow(characterID int64, now time.Time) bool {
+	if l.limit <= 0 {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	recent := l.messages[characterID][:0]
+	for _, sent := range l.messages[characterID] {
+		if now.Sub(sent) < l.window {
+			recent = append(recent, sent)
+		}
+	}
+
+	if len(recent) >= l.limit {
+		l.messages[characterID] = recent
+		return false
+	}
+
+	l.messages[characterID] = append(recent, now)
+	return true
+}
@@ -0,0 +1,102 @@
+package logic
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"container/list"
+	"github.com/golang/protobuf/proto"
+	"sync"
+)
+
+type chunkKey struct {
+	X int64
+	Y int64
+}
+
+type chunkCacheEntry struct {
+	key   chunkKey
+	chunk *rpc.WorldMapChunk
+}
+
+// ChunkCache - LRU cache of the decoded map chunks (without towns and resources, they change too often).
+// Cache with zero capacity doesn't store anything
+type ChunkCache struct {
+	mutex    sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[chunkKey]*list.Element
+}
+
+func NewChunkCache(capacity int) *ChunkCache {
+	return &ChunkCache{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[chunkKey]*list.Element),
+	}
+}
+
+// Get - returns copy of the cached chunk or nil if the chunk isn't cached
+func (c *ChunkCache) Get(x, y int64) *rpc.WorldMapChunk {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, found := c.items[chunkKey{X: x, Y: y}]
+	if !found {
+		return nil
+	}
+
+	c.order.MoveToFront(element)
+	return proto.Clone(element.Value.(*chunkCacheEntry).chunk).(*rpc.WorldMapChunk)
+}
+
+// Put - stores copy of the chunk, the least recently used chunk is evicted if the cache is full
+func (c *ChunkCache) Put(chunk *rpc.WorldMapChunk) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := chunkKey{X: int64(chunk.X), Y: int64(chunk.Y)}
+	entry := &chunkCacheEntry{
+		key:   key,
+		chunk: proto.Clone(chunk).(*rpc.WorldMapChunk),
+	}
+	entry.chunk.Towns = nil
+	entry.chunk.Trees, entry.chunk.Stones, entry.chunk.Animals, entry.chunk.Plants = 0, 0, 0, 0
+
+	if element, found := c.items[key]; found {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*chunkCacheEntry).key)
+	}
+}
+
+// Invalidate - removes the chunk from the cache
+func (c *ChunkCache) Invalidate(x, y int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := chunkKey{X: x, Y: y}
+	if element, found := c.items[key]; found {
+		c.order.Remove(element)
+		delete(c.items, key)
+	}
+}
+
+// Clear - removes all chunks from the cache
+func (c *ChunkCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.order.Init()
+	c.items = make(map[chunkKey]*list.Element)
+}
@@ -0,0 +1,57 @@
+package logic
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestChunkCache_LeastRecentlyUsedIsEvicted(t *testing.T) {
+	cache := NewChunkCache(2)
+
+	cache.Put(&rpc.WorldMapChunk{X: 0, Y: 0, Data: []float32{1}})
+	cache.Put(&rpc.WorldMapChunk{X: 1, Y: 0, Data: []float32{2}})
+
+	// Touch the first chunk so the second one becomes the oldest
+	require.NotNil(t, cache.Get(0, 0))
+
+	cache.Put(&rpc.WorldMapChunk{X: 2, Y: 0, Data: []float32{3}})
+
+	assert.NotNil(t, cache.Get(0, 0))
+	assert.Nil(t, cache.Get(1, 0))
+	assert.NotNil(t, cache.Get(2, 0))
+}
+
+func TestChunkCache_ReturnsCopies(t *testing.T) {
+	cache := NewChunkCache(1)
+	cache.Put(&rpc.WorldMapChunk{X: 0, Y: 0, Data: []float32{1}, Towns: []*rpc.Town{{Name: "town"}}, Trees: 5})
+
+	chunk := cache.Get(0, 0)
+	require.NotNil(t, chunk)
+	assert.Empty(t, chunk.Towns)
+	assert.Zero(t, chunk.Trees)
+
+	chunk.Data[0] = 10
+	assert.Equal(t, float32(1), cache.Get(0, 0).Data[0])
+}
+
+func TestChunkCache_Invalidate(t *testing.T) {
+	cache := NewChunkCache(2)
+	cache.Put(&rpc.WorldMapChunk{X: 0, Y: 0, Data: []float32{1}})
+	cache.Put(&rpc.WorldMapChunk{X: 0, Y: 1, Data: []float32{1}})
+
+	cache.Invalidate(0, 0)
+	assert.Nil(t, cache.Get(0, 0))
+	assert.NotNil(t, cache.Get(0, 1))
+
+	cache.Clear()
+	assert.Nil(t, cache.Get(0, 1))
+}
+
+func TestChunksInRadius(t *testing.T) {
+	chunks := chunksInRadius(0, 0, 1)
+
+	require.Len(t, chunks, 5)
+	assert.Equal(t, chunkKey{X: 0, Y: 0}, chunks[0])
+}
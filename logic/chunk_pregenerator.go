@@ -0,0 +1,146 @@
+package logic
+
+import (
+	db2 "abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/generation"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ChunkPregenerator - generates map chunks around the spawn ahead of time,
+// so players don't wait for the generation inside of their requests
+type ChunkPregenerator struct {
+	db        db2.Database
+	generator generation.TerrainGenerator
+	config    Config
+	cache     *ChunkCache
+	log       *logrus.Entry
+}
+
+func NewChunkPregenerator(
+	database db2.Database,
+	generator generation.TerrainGenerator,
+	cache *ChunkCache,
+	config Config) *ChunkPregenerator {
+	return &ChunkPregenerator{
+		db:        database,
+		generator: generator,
+		config:    config,
+		cache:     cache,
+		log:       logrus.WithField("module", "chunk_pregenerator"),
+	}
+}
+
+// chunksInRadius - returns chunk coordinates inside of the circle sorted from the center to the edge
+func chunksInRadius(centerX, centerY, radius int) []chunkKey {
+	var chunks []chunkKey
+	for x := centerX - radius; x <= centerX+radius; x++ {
+		for y := centerY - radius; y <= centerY+radius; y++ {
+			dx, dy := x-centerX, y-centerY
+			if dx*dx+dy*dy <= radius*radius {
+				chunks = append(chunks, chunkKey{X: int64(x), Y: int64(y)})
+			}
+		}
+	}
+
+	distance := func(key chunkKey) int64 {
+		dx, dy := key.X-int64(centerX), key.Y-int64(centerY)
+		return dx*dx + dy*dy
+	}
+
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return distance(chunks[i]) < distance(chunks[j])
+	})
+
+	return chunks
+}
+
+// pregenerateChunk - generates and saves the chunk if it doesn't exist yet.
+// Returns true if the chunk was generated
+func (p *ChunkPregenerator) pregenerateChunk(key chunkKey) (bool, error) {
+	tx, err := p.db.BeginTransaction(false, true)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	tx.SetAutoRollBack(false)
+	chunk, err := tx.GetMapChunk(key.X, key.Y)
+	tx.SetAutoRollBack(true)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("failed to get map chunk: %w", err)
+	}
+
+	if len(chunk.Data) != 0 {
+		return false, tx.EndTransaction()
+	}
+
+	rpcChunk := generateMapChunk(p.generator, p.config, int(key.X), int(key.Y))
+	if err := saveMapChunk(rpcChunk, tx, p.cache); err != nil {
+		return false, fmt.Errorf("failed to save map chunk: %w", err)
+	}
+
+	return true, tx.EndTransaction()
+}
+
+// Pregenerate - generates all missing chunks in the radius (in chunks) around the spawn
+// using config.PregenWorkers goroutines
+func (p *ChunkPregenerator) Pregenerate(radius int) error {
+	chunks := chunksInRadius(spawnChunkX, spawnChunkY, radius)
+
+	workers := p.config.PregenWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	p.log.WithFields(logrus.Fields{
+		"radius":  radius,
+		"chunks":  len(chunks),
+		"workers": workers,
+	}).Info("Chunk pregeneration started")
+
+	var generated int64
+	var firstErr error
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+
+	keys := make(chan chunkKey)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for key := range keys {
+				isGenerated, err := p.pregenerateChunk(key)
+				if err != nil {
+					p.log.WithError(err).
+						WithField("x", key.X).
+						WithField("y", key.Y).
+						Error("Failed to pregenerate chunk")
+					errOnce.Do(func() { firstErr = err })
+					continue
+				}
+
+				if isGenerated {
+					atomic.AddInt64(&generated, 1)
+				}
+			}
+		}()
+	}
+
+	for _, key := range chunks {
+		keys <- key
+	}
+
+	close(keys)
+	wg.Wait()
+
+	p.log.WithField("generated", generated).Info("Chunk pregeneration finished")
+	return firstErr
+}
@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+func (s *SimpleLogic) CreateAlliance(session *PlayerSession, request *rpc.CreateAllianceRequest) (*rpc.CreateAllianceResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"name":      request.Name,
+		"tag":       request.Tag,
+	}).Info("CreateAlliance")
+
+	if err := validateAlliance(request.Name, request.Tag); err != nil {
+		return nil, err
+	}
+
+	tx := session.Tx
+	character := session.SelectedCharacter
+
+	member, err := s.getAllianceMember(character.Name, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	if member != nil {
+		return nil, model.ErrAlreadyInAlliance
+	}
+
+	alliance := model.Alliance{
+		Name:      strings.TrimSpace(request.Name),
+		Tag:       strings.ToUpper(request.Tag),
+		CreatedAt: time.Now(),
+	}
+
+	alliance.ID, err = tx.AddAlliance(alliance)
+	if errors.Is(err, db.ErrDuplicatedUniqueKey) {
+		return nil, model.ErrAllianceNameTaken
+	} else if err != nil {
+		s.log.WithError(err).Error("Failed to add alliance")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := tx.AddAllianceMember(model.AllianceMember{
+		AllianceID:    alliance.ID,
+		CharacterName: character.Name,
+		Rank:          model.AllianceRankLeader,
+		JoinedAt:      alliance.CreatedAt,
+	}); err != nil {
+		s.log.WithError(err).Error("Failed to add alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := tx.RemoveAllianceInvites(character.Name); err != nil {
+		s.log.WithError(err).Error("Failed to remove alliance invites")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.setCharacterAlliance(session, character.Name, &alliance)
+
+	return &rpc.CreateAllianceResponse{
+		Alliance:    alliance.ToRPC(),
+		EventTopics: s.eventTopics(session),
+	}, nil
+}
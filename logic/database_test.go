@@ -2,9 +2,12 @@ package logic
 
 import (
 	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/geometry"
 	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
 func NewLogicMock() (*SimpleLogic, *DatabaseTransactionMock, *PlayerSession) {
@@ -12,9 +15,19 @@ func NewLogicMock() (*SimpleLogic, *DatabaseTransactionMock, *PlayerSession) {
 	var db DatabaseMock
 	s.db = &db
 	s.sessions = make(map[string]*PlayerSession)
+	s.chunkCache = NewChunkCache(0)
+	s.config.ChatHistoryMaxPageSize = consts.DefaultChatHistoryMaxPageSize
+	s.config.DirectMessagesMaxPageSize = consts.DefaultDirectMessagesMaxPageSize
+	s.config.CaravanTileTravelTime = consts.DefaultCaravanTileTravelTime
+	s.config.MarchMaxDistance = consts.DefaultMarchMaxDistance
 
 	s.log = log.WithField("module", "test")
-	s.EventsChan = make(chan model.EventWrapper, 1)
+	s.EventsChan = make(chan model.EventWrapper, 100)
+	s.eventLog = NewEventLog(100)
+	s.topicTokens = NewTopicTokens()
+	s.chatLimiter = NewChatRateLimiter(0, 0)
+	s.chatFilter = NewChatFilter(nil)
+	s.registerDefaultChatCommands()
 
 	session := NewPlayerSession(1)
 	s.sessions[session.SessionID] = session
@@ -48,21 +61,27 @@ type DatabaseTransactionMock struct {
 	isCompleted bool
 }
 
+func (d *DatabaseTransactionMock) GetCharacterBuildings(characterName string) (model.CharacterBuildings, error) {
+	args := d.Called(characterName)
+	return args.Get(0).(model.CharacterBuildings), args.Error(1)
+}
+
 func (d *DatabaseTransactionMock) GetProductionRates(characterID int64) (model.Resources, error) {
 	panic("implement me")
 }
 
-func (d *DatabaseTransactionMock) AddOrUpdateResources(resources model.Resources) error {
-	args := d.Called(resources)
+func (d *DatabaseTransactionMock) AddOrUpdateResources(characterID int64, resources model.Resources) error {
+	args := d.Called(characterID, resources)
 	return args.Error(0)
 }
 
-func (d *DatabaseTransactionMock) AddOrUpdateProductionRates(rates model.Resources) error {
+func (d *DatabaseTransactionMock) AddOrUpdateProductionRates(characterID int64, rates model.Resources) error {
 	panic("implement me")
 }
 
 func (d *DatabaseTransactionMock) AddTownBuilding(townID int64, building model.Building) error {
-	panic("implement me")
+	args := d.Called(townID, building)
+	return args.Error(0)
 }
 
 func (d *DatabaseTransactionMock) GetAllBuildings() (map[int64]model.CharacterBuildings, error) {
@@ -74,6 +93,11 @@ func (d *DatabaseTransactionMock) EndTransaction() error {
 	return nil
 }
 
+func (d *DatabaseTransactionMock) RollBack() error {
+	d.isCompleted = true
+	return nil
+}
+
 func (d *DatabaseTransactionMock) IsCompleted() bool {
 	return d.isCompleted
 }
@@ -110,12 +134,40 @@ func (d *DatabaseTransactionMock) IncrementMapResources(resources model.ChunkRes
 	panic("implement me")
 }
 
-func (d *DatabaseTransactionMock) GetTownsForRect(xStart, xEnd, yStart, yEnd int) ([]model.Town, error) {
-	panic("implement me")
+func (d *DatabaseTransactionMock) GetTownsForRect(rect geometry.Rect) ([]model.Town, error) {
+	args := d.Called(rect)
+	return args.Get(0).([]model.Town), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetMapChunkResources(x, y int64) (model.ChunkResources, error) {
+	args := d.Called(x, y)
+	return args.Get(0).(model.ChunkResources), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetMapChunksResources() ([]model.WorldMapChunk, error) {
+	args := d.Called()
+	return args.Get(0).([]model.WorldMapChunk), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) SubtractMapChunkResources(x, y int64, resources model.ChunkResources) error {
+	args := d.Called(x, y, resources)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) AddCharacterResources(
+	characterName string, resources model.Resources, capacity model.Resources) error {
+	args := d.Called(characterName, resources, capacity)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetNewestCapitals(count int) ([]model.Town, error) {
+	args := d.Called(count)
+	return args.Get(0).([]model.Town), args.Error(1)
 }
 
 func (d *DatabaseTransactionMock) GetAllTowns() ([]model.Town, error) {
-	panic("implement me")
+	args := d.Called()
+	return args.Get(0).([]model.Town), args.Error(1)
 }
 
 func (d *DatabaseTransactionMock) AddTown(town model.Town) error {
@@ -143,6 +195,11 @@ func (d *DatabaseTransactionMock) GetCharacter(id int64) (model.Character, error
 	return args.Get(0).(model.Character), args.Error(1)
 }
 
+func (d *DatabaseTransactionMock) GetCharacterByName(name string) (model.Character, error) {
+	args := d.Called(name)
+	return args.Get(0).(model.Character), args.Error(1)
+}
+
 func (d *DatabaseTransactionMock) AddCharacter(name string) (int, error) {
 	args := d.Called(name)
 	return args.Int(0), args.Error(1)
@@ -173,11 +230,337 @@ func (d *DatabaseTransactionMock) AddAccount(login string, password string, salt
 }
 
 func (d *DatabaseTransactionMock) AddChatMessage(message model.ChatMessage) (int64, error) {
-	panic("implement me")
+	args := d.Called(message)
+	return args.Get(0).(int64), args.Error(1)
 }
 
-func (d *DatabaseTransactionMock) GetChatMessages(offset int, count int) ([]model.ChatMessage, error) {
-	panic("implement me")
+func (d *DatabaseTransactionMock) GetChatMessages(
+	channel string, beforeID int64, afterID int64, count int) ([]model.ChatMessage, error) {
+	args := d.Called(channel, beforeID, afterID, count)
+	return args.Get(0).([]model.ChatMessage), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) AddDirectMessage(message model.DirectMessage) (int64, error) {
+	args := d.Called(message)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetDirectMessages(
+	characterName string, interlocutor string, offset int, count int) ([]model.DirectMessage, error) {
+	args := d.Called(characterName, interlocutor, offset, count)
+	return args.Get(0).([]model.DirectMessage), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetConversations(characterName string) ([]model.Conversation, error) {
+	args := d.Called(characterName)
+	return args.Get(0).([]model.Conversation), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetChatMute(characterName string, now time.Time) (model.ChatMute, error) {
+	args := d.Called(characterName)
+	return args.Get(0).(model.ChatMute), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) AddChatMute(mute model.ChatMute) error {
+	args := d.Called(mute)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) RemoveChatMute(characterName string) error {
+	args := d.Called(characterName)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) AddModerationLogEntry(entry model.ModerationLogEntry) error {
+	args := d.Called(entry)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetModerationLog(offset int, count int) ([]model.ModerationLogEntry, error) {
+	args := d.Called(offset, count)
+	return args.Get(0).([]model.ModerationLogEntry), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) AddAlliance(alliance model.Alliance) (int64, error) {
+	args := d.Called(alliance)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetAlliance(id int64) (model.Alliance, error) {
+	args := d.Called(id)
+	return args.Get(0).(model.Alliance), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) DeleteAlliance(id int64) error {
+	args := d.Called(id)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) AddAllianceMember(member model.AllianceMember) error {
+	args := d.Called(member)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetAllianceMember(characterName string) (model.AllianceMember, error) {
+	args := d.Called(characterName)
+	return args.Get(0).(model.AllianceMember), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetAllianceMembers(allianceID int64) ([]model.AllianceMember, error) {
+	args := d.Called(allianceID)
+	return args.Get(0).([]model.AllianceMember), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) UpdateAllianceMemberRank(characterName string, rank model.AllianceRank) error {
+	args := d.Called(characterName, rank)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) RemoveAllianceMember(characterName string) error {
+	args := d.Called(characterName)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) AddAllianceInvite(invite model.AllianceInvite) error {
+	args := d.Called(invite)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetAllianceInvite(allianceID int64, characterName string) (model.AllianceInvite, error) {
+	args := d.Called(allianceID, characterName)
+	return args.Get(0).(model.AllianceInvite), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) RemoveAllianceInvites(characterName string) error {
+	args := d.Called(characterName)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetAllianceByTag(tag string) (model.Alliance, error) {
+	args := d.Called(tag)
+	return args.Get(0).(model.Alliance), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetDiplomacyRelation(
+	first model.DiplomacyParty, second model.DiplomacyParty) (model.DiplomacyRelation, error) {
+	args := d.Called(first, second)
+	return args.Get(0).(model.DiplomacyRelation), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetDiplomacyRelations(party model.DiplomacyParty) ([]model.DiplomacyRelation, error) {
+	args := d.Called(party)
+	return args.Get(0).([]model.DiplomacyRelation), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) SetDiplomacyRelation(relation model.DiplomacyRelation) error {
+	args := d.Called(relation)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) AddDiplomacyProposal(proposal model.DiplomacyProposal) (int64, error) {
+	args := d.Called(proposal)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetDiplomacyProposal(id int64) (model.DiplomacyProposal, error) {
+	args := d.Called(id)
+	return args.Get(0).(model.DiplomacyProposal), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetDiplomacyProposals(party model.DiplomacyParty) ([]model.DiplomacyProposal, error) {
+	args := d.Called(party)
+	return args.Get(0).([]model.DiplomacyProposal), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) RemoveDiplomacyProposal(id int64) error {
+	args := d.Called(id)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) RemoveDiplomacyParty(party model.DiplomacyParty) error {
+	args := d.Called(party)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) AddMarketOrder(order model.MarketOrder) (int64, error) {
+	args := d.Called(order)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetMarketOrder(id int64) (model.MarketOrder, error) {
+	args := d.Called(id)
+	return args.Get(0).(model.MarketOrder), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) UpdateMarketOrder(order model.MarketOrder) error {
+	args := d.Called(order)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetMatchingMarketOrders(order model.MarketOrder, count int) ([]model.MarketOrder, error) {
+	args := d.Called(order, count)
+	return args.Get(0).([]model.MarketOrder), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetOrderBook(
+	resource, priceResource model.ResourceType, side model.MarketOrderSide, depth int) ([]model.MarketOrder, error) {
+	args := d.Called(resource, priceResource, side, depth)
+	return args.Get(0).([]model.MarketOrder), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetCharacterMarketOrders(characterID int64, offset int, count int) ([]model.MarketOrder, error) {
+	args := d.Called(characterID, offset, count)
+	return args.Get(0).([]model.MarketOrder), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) AddCaravan(caravan model.Caravan) (int64, error) {
+	args := d.Called(caravan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) UpdateCaravan(caravan model.Caravan) error {
+	args := d.Called(caravan)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetCharacterCaravans(characterID int64) ([]model.Caravan, error) {
+	args := d.Called(characterID)
+	return args.Get(0).([]model.Caravan), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetArrivedCaravans(now time.Time, count int) ([]model.Caravan, error) {
+	args := d.Called(now, count)
+	return args.Get(0).([]model.Caravan), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) AddResearch(research model.Research) error {
+	args := d.Called(research)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) DeleteResearch(characterID int64, tech string) error {
+	args := d.Called(characterID, tech)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) CompleteResearch(research model.Research) error {
+	args := d.Called(research)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetActiveResearch(characterID int64) (model.Research, error) {
+	args := d.Called(characterID)
+	return args.Get(0).(model.Research), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetCharacterTechs(characterID int64) (model.CharacterTechs, error) {
+	args := d.Called(characterID)
+	return args.Get(0).(model.CharacterTechs), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetFinishedResearch(now time.Time, count int) ([]model.Research, error) {
+	args := d.Called(now, count)
+	return args.Get(0).([]model.Research), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetTownBuildings(townID int64) (model.CharacterBuildings, error) {
+	args := d.Called(townID)
+	return args.Get(0).(model.CharacterBuildings), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) AddUnitTraining(training model.UnitTraining) (int64, error) {
+	args := d.Called(training)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) DeleteUnitTraining(id int64) error {
+	args := d.Called(id)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetCharacterUnitTraining(characterID int64) ([]model.UnitTraining, error) {
+	args := d.Called(characterID)
+	return args.Get(0).([]model.UnitTraining), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetFinishedUnitTraining(now time.Time, count int) ([]model.UnitTraining, error) {
+	args := d.Called(now, count)
+	return args.Get(0).([]model.UnitTraining), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) AddTownUnits(townID int64, army model.Army) error {
+	args := d.Called(townID, army)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetCharacterArmy(characterName string) (map[int64]model.Army, error) {
+	args := d.Called(characterName)
+	return args.Get(0).(map[int64]model.Army), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) RemoveTownUnits(townID int64, army model.Army) error {
+	args := d.Called(townID, army)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) AddMarch(march model.March) (int64, error) {
+	args := d.Called(march)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) UpdateMarch(march model.March) error {
+	args := d.Called(march)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) DeleteMarch(id int64) error {
+	args := d.Called(id)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) GetMarch(id int64) (model.March, error) {
+	args := d.Called(id)
+	return args.Get(0).(model.March), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetCharacterMarches(characterID int64) ([]model.March, error) {
+	args := d.Called(characterID)
+	return args.Get(0).([]model.March), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetMovingMarches() ([]model.March, error) {
+	args := d.Called()
+	return args.Get(0).([]model.March), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetArrivedMarches(now time.Time, count int) ([]model.March, error) {
+	args := d.Called(now, count)
+	return args.Get(0).([]model.March), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) UpdateMarchUnits(marchID int64, units model.Army) error {
+	args := d.Called(marchID, units)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) AddBattleReport(report model.BattleReport) (int64, error) {
+	args := d.Called(report)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) GetBattleReports(characterID int64, offset int, count int) ([]model.BattleReport, error) {
+	args := d.Called(characterID, offset, count)
+	return args.Get(0).([]model.BattleReport), args.Error(1)
+}
+
+func (d *DatabaseTransactionMock) ChangeTownOwner(townID int64, ownerName string) error {
+	args := d.Called(townID, ownerName)
+	return args.Error(0)
+}
+
+func (d *DatabaseTransactionMock) MarkDirectMessagesRead(recipientName string, senderName string) error {
+	args := d.Called(recipientName, senderName)
+	return args.Error(0)
 }
 
 func (d *DatabaseTransactionMock) GetMapChunk(x, y int64) (model.WorldMapChunk, error) {
@@ -190,6 +573,11 @@ func (d *DatabaseTransactionMock) SaveMapChunkOrUpdate(chunk model.WorldMapChunk
 	return args.Error(0)
 }
 
+func (d *DatabaseTransactionMock) GetTown(id int64) (model.Town, error) {
+	args := d.Called(id)
+	return args.Get(0).(model.Town), args.Error(1)
+}
+
 func (d *DatabaseTransactionMock) GetTowns(ownerName string) ([]model.Town, error) {
 	args := d.Called(ownerName)
 	return args.Get(0).([]model.Town), args.Error(1)
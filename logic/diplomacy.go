@@ -0,0 +1,150 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/model"
+	"database/sql"
+	"errors"
+)
+
+// diplomacyState - returns the state between two parties, neutral if they have no relation
+func (s *SimpleLogic) diplomacyState(first, second model.DiplomacyParty, tx db.DatabaseTransaction) (model.DiplomacyState, error) {
+	tx.SetAutoRollBack(false)
+	relation, err := tx.GetDiplomacyRelation(first, second)
+	tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.DiplomacyNeutral, nil
+	} else if err != nil {
+		return model.DiplomacyNeutral, err
+	}
+
+	return relation.State, nil
+}
+
+// characterRelation - returns the effective diplomacy state between two characters.
+// Members of the same alliance are allied, otherwise the most specific relation is used:
+// between the characters, then between a character and the alliance of another one, then between the alliances
+func (s *SimpleLogic) characterRelation(first, second *model.Character, tx db.DatabaseTransaction) (model.DiplomacyState, error) {
+	if first.AllianceID != 0 && first.AllianceID == second.AllianceID {
+		return model.DiplomacyAllied, nil
+	}
+
+	firstCharacter := model.CharacterParty(first.Name)
+	secondCharacter := model.CharacterParty(second.Name)
+	pairs := [][2]model.DiplomacyParty{{firstCharacter, secondCharacter}}
+
+	if second.AllianceTag != "" {
+		pairs = append(pairs, [2]model.DiplomacyParty{firstCharacter, model.AllianceParty(second.AllianceTag)})
+	}
+	if first.AllianceTag != "" {
+		pairs = append(pairs, [2]model.DiplomacyParty{model.AllianceParty(first.AllianceTag), secondCharacter})
+	}
+	if first.AllianceTag != "" && second.AllianceTag != "" {
+		pairs = append(pairs, [2]model.DiplomacyParty{
+			model.AllianceParty(first.AllianceTag), model.AllianceParty(second.AllianceTag)})
+	}
+
+	for _, pair := range pairs {
+		state, err := s.diplomacyState(pair[0], pair[1], tx)
+		if err != nil || state != model.DiplomacyNeutral {
+			return state, err
+		}
+	}
+
+	return model.DiplomacyNeutral, nil
+}
+
+// findDiplomacyParty - checks that the party exists and returns it with the topic of its events.
+// Character name is replaced with the stored one
+func (s *SimpleLogic) findDiplomacyParty(
+	party model.DiplomacyParty, tx db.DatabaseTransaction) (model.DiplomacyParty, string, model.Error) {
+	switch party.Type {
+	case model.DiplomacyPartyCharacter:
+		character, err := s.findCharacter(party.Name, tx)
+		if err != nil {
+			s.log.WithError(err).Error("Failed to get character")
+			return party, "", model.ErrInternalServerError
+		}
+
+		if character == nil {
+			return party, "", model.ErrCharacterNotFound
+		}
+
+		return model.CharacterParty(character.Name), model.CharacterTopic(character.ID), nil
+	case model.DiplomacyPartyAlliance:
+		tx.SetAutoRollBack(false)
+		alliance, err := tx.GetAllianceByTag(party.Name)
+		tx.SetAutoRollBack(true)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return party, "", model.ErrAllianceNotFound
+		} else if err != nil {
+			s.log.WithError(err).Error("Failed to get alliance")
+			return party, "", model.ErrInternalServerError
+		}
+
+		return model.AllianceParty(alliance.Tag), model.AllianceTopic(alliance.ID), nil
+	default:
+		return party, "", model.ErrBadRequest
+	}
+}
+
+// sessionDiplomacyParty - returns the party the session character acts for: itself or its alliance
+func (s *SimpleLogic) sessionDiplomacyParty(session *PlayerSession, asAlliance bool) (model.DiplomacyParty, string, model.Error) {
+	character := session.SelectedCharacter
+	if !asAlliance {
+		return model.CharacterParty(character.Name), model.CharacterTopic(character.ID), nil
+	}
+
+	if _, err := s.checkAlliancePermission(session, model.AlliancePermissionDiplomacy); err != nil {
+		return model.DiplomacyParty{}, "", err
+	}
+
+	return model.AllianceParty(character.AllianceTag), model.AllianceTopic(character.AllianceID), nil
+}
+
+// representDiplomacyParty - checks that the session character can make decisions for the party
+// and returns the topic of the party events
+func (s *SimpleLogic) representDiplomacyParty(session *PlayerSession, party model.DiplomacyParty) (string, model.Error) {
+	own, topic, err := s.sessionDiplomacyParty(session, party.Type == model.DiplomacyPartyAlliance)
+	if err != nil {
+		return "", err
+	}
+
+	if own != party {
+		return "", model.ErrForbidden
+	}
+
+	return topic, nil
+}
+
+// setDiplomacyRelation - saves the relation and notifies both parties
+func (s *SimpleLogic) setDiplomacyRelation(
+	relation model.DiplomacyRelation, firstTopic string, secondTopic string, tx db.DatabaseTransaction) model.Error {
+	if err := tx.SetDiplomacyRelation(relation); err != nil {
+		s.log.WithError(err).Error("Failed to set diplomacy relation")
+		return model.ErrInternalServerError
+	}
+
+	s.publishEvent(model.NewDiplomacyChangedEvent(firstTopic, relation))
+	s.publishEvent(model.NewDiplomacyChangedEvent(secondTopic, relation))
+
+	return nil
+}
+
+// getDiplomacyProposal - returns the proposal or ErrDiplomacyProposalNotFound
+func (s *SimpleLogic) getDiplomacyProposal(id int64, tx db.DatabaseTransaction) (model.DiplomacyProposal, model.Error) {
+	tx.SetAutoRollBack(false)
+	proposal, err := tx.GetDiplomacyProposal(id)
+	tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return proposal, model.ErrDiplomacyProposalNotFound
+	} else if err != nil {
+		s.log.WithError(err).Error("Failed to get diplomacy proposal")
+		return proposal, model.ErrInternalServerError
+	}
+
+	return proposal, nil
+}
@@ -0,0 +1,138 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewDiplomacyRelation_Order(t *testing.T) {
+	first := model.NewDiplomacyRelation(model.CharacterParty("b"), model.AllianceParty("A"), model.DiplomacyWar)
+	second := model.NewDiplomacyRelation(model.AllianceParty("A"), model.CharacterParty("b"), model.DiplomacyWar)
+
+	require.Equal(t, first.First(), second.First())
+	require.Equal(t, model.CharacterParty("b"), first.First())
+	require.Equal(t, model.AllianceParty("A"), first.Other(model.CharacterParty("b")))
+}
+
+func TestSimpleLogic_CharacterRelation(t *testing.T) {
+	logic, db, _ := NewLogicMock()
+
+	first := &model.Character{Name: "first", AllianceID: 1, AllianceTag: "ONE"}
+	second := &model.Character{Name: "second", AllianceID: 2, AllianceTag: "TWO"}
+	sameAlliance := &model.Character{Name: "third", AllianceID: 1, AllianceTag: "ONE"}
+
+	state, err := logic.characterRelation(first, sameAlliance, db)
+	require.NoError(t, err)
+	require.Equal(t, model.DiplomacyAllied, state)
+
+	db.On("GetDiplomacyRelation", model.CharacterParty("first"), model.CharacterParty("second")).
+		Return(model.DiplomacyRelation{}, sql.ErrNoRows)
+	db.On("GetDiplomacyRelation", model.CharacterParty("first"), model.AllianceParty("TWO")).
+		Return(model.DiplomacyRelation{}, sql.ErrNoRows)
+	db.On("GetDiplomacyRelation", model.AllianceParty("ONE"), model.CharacterParty("second")).
+		Return(model.DiplomacyRelation{}, sql.ErrNoRows)
+	db.On("GetDiplomacyRelation", model.AllianceParty("ONE"), model.AllianceParty("TWO")).
+		Return(model.DiplomacyRelation{State: model.DiplomacyWar}, nil)
+
+	state, err = logic.characterRelation(first, second, db)
+	require.NoError(t, err)
+	require.Equal(t, model.DiplomacyWar, state)
+	require.True(t, state.IsHostile())
+}
+
+func TestSimpleLogic_ProposeDiplomacy_War(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "first"}
+
+	db.On("GetCharacterByName", "second").Return(model.Character{ID: 2, Name: "second"}, nil)
+	db.On("GetDiplomacyRelation", model.CharacterParty("first"), model.CharacterParty("second")).
+		Return(model.DiplomacyRelation{}, sql.ErrNoRows)
+	db.On("SetDiplomacyRelation", mock.MatchedBy(func(relation model.DiplomacyRelation) bool {
+		return relation.State == model.DiplomacyWar
+	})).Return(nil)
+
+	resp, err := logic.ProposeDiplomacy(session, &rpc.ProposeDiplomacyRequest{
+		Target: &rpc.DiplomacyParty{Type: rpc.DiplomacyParty_CHARACTER, Name: "second"},
+		State:  rpc.DiplomacyState_WAR,
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp.Proposal)
+	require.Equal(t, rpc.DiplomacyState_WAR, resp.Relation.State)
+
+	require.Equal(t, 2, len(logic.EventsChan))
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), (<-logic.EventsChan).Topic)
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(2)), (<-logic.EventsChan).Topic)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_ProposeDiplomacy_Peace(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "first"}
+
+	db.On("GetAllianceByTag", "TWO").Return(model.Alliance{ID: 2, Tag: "TWO"}, nil)
+	db.On("GetDiplomacyRelation", model.CharacterParty("first"), model.AllianceParty("TWO")).
+		Return(model.DiplomacyRelation{State: model.DiplomacyWar}, nil)
+	db.On("AddDiplomacyProposal", mock.MatchedBy(func(proposal model.DiplomacyProposal) bool {
+		return proposal.State == model.DiplomacyPeace && proposal.To() == model.AllianceParty("TWO")
+	})).Return(int64(7), nil)
+
+	target := &rpc.DiplomacyParty{Type: rpc.DiplomacyParty_ALLIANCE, Name: "TWO"}
+
+	// War can't be finished without the agreement
+	resp, err := logic.ProposeDiplomacy(session, &rpc.ProposeDiplomacyRequest{Target: target, State: rpc.DiplomacyState_NEUTRAL})
+	require.EqualError(t, err, model.ErrForbidden.Error())
+	require.Nil(t, resp)
+
+	resp, err = logic.ProposeDiplomacy(session, &rpc.ProposeDiplomacyRequest{Target: target, State: rpc.DiplomacyState_PEACE})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), resp.Proposal.Id)
+
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.AllianceTopic(2)), event.Topic)
+	require.Equal(t, int64(7), event.Event.GetDiplomacyProposalEvent().Proposal.Id)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_AcceptDiplomacyProposal(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 2, Name: "leader", AllianceID: 2, AllianceTag: "TWO"}
+
+	proposal := model.NewDiplomacyProposal(
+		model.CharacterParty("first"), model.AllianceParty("TWO"), model.DiplomacyPeace, "first")
+	proposal.ID = 7
+
+	db.On("GetDiplomacyProposal", int64(7)).Return(proposal, nil)
+	db.On("GetAllianceMember", "leader").
+		Return(model.AllianceMember{AllianceID: 2, CharacterName: "leader", Rank: model.AllianceRankLeader}, nil)
+	db.On("GetCharacterByName", "first").Return(model.Character{ID: 1, Name: "first"}, nil)
+	db.On("RemoveDiplomacyProposal", int64(7)).Return(nil)
+	db.On("SetDiplomacyRelation", mock.MatchedBy(func(relation model.DiplomacyRelation) bool {
+		return relation.State == model.DiplomacyPeace
+	})).Return(nil)
+
+	resp, err := logic.AcceptDiplomacyProposal(session, &rpc.AcceptDiplomacyProposalRequest{ProposalID: 7})
+	require.NoError(t, err)
+	require.Equal(t, rpc.DiplomacyState_PEACE, resp.Relation.State)
+	require.Equal(t, 2, len(logic.EventsChan))
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_AcceptDiplomacyProposal_NotTarget(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 3, Name: "other"}
+
+	proposal := model.NewDiplomacyProposal(
+		model.CharacterParty("first"), model.CharacterParty("second"), model.DiplomacyPeace, "first")
+	db.On("GetDiplomacyProposal", int64(7)).Return(proposal, nil)
+
+	resp, err := logic.AcceptDiplomacyProposal(session, &rpc.AcceptDiplomacyProposalRequest{ProposalID: 7})
+	require.EqualError(t, err, model.ErrForbidden.Error())
+	require.Nil(t, resp)
+}
@@ -0,0 +1,53 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) DisbandAlliance(session *PlayerSession, request *rpc.DisbandAllianceRequest) (*rpc.DisbandAllianceResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("DisbandAlliance")
+
+	member, modelErr := s.checkAlliancePermission(session, model.AlliancePermissionDisband)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	tx := session.Tx
+
+	alliance, err := tx.GetAlliance(member.AllianceID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance")
+		return nil, model.ErrInternalServerError
+	}
+
+	members, err := tx.GetAllianceMembers(alliance.ID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance members")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := tx.DeleteAlliance(alliance.ID); err != nil {
+		s.log.WithError(err).Error("Failed to delete alliance")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := tx.RemoveDiplomacyParty(model.AllianceParty(alliance.Tag)); err != nil {
+		s.log.WithError(err).Error("Failed to remove alliance diplomacy")
+		return nil, model.ErrInternalServerError
+	}
+
+	// Nobody can read the alliance channel history anymore, so the notice isn't saved
+	s.publishEvent(model.NewPrivateSystemChatMessageEvent(
+		model.AllianceChatChannel(alliance.ID).Topic(), consts.MessageAllianceDisbanded(alliance.Name)))
+
+	for _, member := range members {
+		s.setCharacterAlliance(session, member.CharacterName, nil)
+	}
+
+	return &rpc.DisbandAllianceResponse{}, nil
+}
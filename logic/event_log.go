@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"container/list"
+	"github.com/golang/protobuf/proto"
+	"sync"
+)
+
+// EventLog - numbers events of every topic and keeps the latest of them,
+// so reconnected clients can receive events they missed
+type EventLog struct {
+	mutex     sync.Mutex
+	capacity  int
+	events    *list.List        // Latest events of all topics, the oldest are at the front
+	sequences map[string]uint64 // Sequence number of the last event of the topic
+	evicted   map[string]uint64 // Sequence number of the last event of the topic removed from the log
+}
+
+func NewEventLog(capacity int) *EventLog {
+	return &EventLog{
+		capacity:  capacity,
+		events:    list.New(),
+		sequences: make(map[string]uint64),
+		evicted:   make(map[string]uint64),
+	}
+}
+
+// Append - assigns the next sequence number of the topic to the event and stores it.
+// Callback is called under the log lock, so events are processed in the order of their sequence numbers
+func (l *EventLog) Append(event model.EventWrapper, callback func(event model.EventWrapper)) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.sequences[event.Topic]++
+	event.Event.Topic = event.Topic
+	event.Event.Sequence = l.sequences[event.Topic]
+
+	if l.capacity > 0 {
+		l.events.PushBack(event)
+
+		for l.events.Len() > l.capacity {
+			oldest := l.events.Remove(l.events.Front()).(model.EventWrapper)
+			l.evicted[oldest.Topic] = oldest.Event.Sequence
+		}
+	} else {
+		l.evicted[event.Topic] = event.Event.Sequence
+	}
+
+	callback(event)
+}
+
+// Since - returns copies of the topic events which sequence number is greater than the provided one.
+// Complete is false if some of the requested events were already removed from the log
+func (l *EventLog) Since(topic string, sequence uint64) (events []*rpc.Event, lastSequence uint64, complete bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for element := l.events.Front(); element != nil; element = element.Next() {
+		event := element.Value.(model.EventWrapper)
+		if event.Topic == topic && event.Event.Sequence > sequence {
+			events = append(events, proto.Clone(event.Event).(*rpc.Event))
+		}
+	}
+
+	return events, l.sequences[topic], sequence >= l.evicted[topic]
+}
+
+// Forget - removes the topic counters, should be called when the topic won't be used anymore
+func (l *EventLog) Forget(topic string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.sequences, topic)
+	delete(l.evicted, topic)
+}
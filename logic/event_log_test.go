@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func appendEvents(log *EventLog, topic string, count int) {
+	for i := 0; i < count; i++ {
+		log.Append(model.NewEvent(topic, &rpc.Event{}), func(model.EventWrapper) {})
+	}
+}
+
+func sequences(events []*rpc.Event) (result []uint64) {
+	for _, event := range events {
+		result = append(result, event.Sequence)
+	}
+
+	return result
+}
+
+func TestEventLog_SequencesPerTopic(t *testing.T) {
+	log := NewEventLog(10)
+
+	var published []model.EventWrapper
+	collect := func(event model.EventWrapper) { published = append(published, event) }
+
+	log.Append(model.NewCharacterStatusEvent("a", true), collect)
+	log.Append(model.NewCharacterEvent(1, &rpc.Event{}), collect)
+	log.Append(model.NewCharacterStatusEvent("b", true), collect)
+
+	require.Len(t, published, 3)
+	require.Equal(t, uint64(1), published[0].Event.Sequence)
+	require.Equal(t, uint64(1), published[1].Event.Sequence)
+	require.Equal(t, uint64(2), published[2].Event.Sequence)
+	require.Equal(t, model.CharacterTopic(1), published[1].Event.Topic)
+}
+
+func TestEventLog_Since(t *testing.T) {
+	log := NewEventLog(10)
+	appendEvents(log, "A", 3)
+	appendEvents(log, "B", 2)
+
+	events, last, complete := log.Since("A", 1)
+	require.Equal(t, []uint64{2, 3}, sequences(events))
+	require.Equal(t, uint64(3), last)
+	require.True(t, complete)
+
+	events, last, complete = log.Since("B", 2)
+	require.Empty(t, events)
+	require.Equal(t, uint64(2), last)
+	require.True(t, complete)
+}
+
+func TestEventLog_Eviction(t *testing.T) {
+	log := NewEventLog(3)
+	appendEvents(log, "A", 5)
+
+	events, last, complete := log.Since("A", 0)
+	require.Equal(t, []uint64{3, 4, 5}, sequences(events))
+	require.Equal(t, uint64(5), last)
+	require.False(t, complete, "events 1 and 2 were evicted")
+
+	events, _, complete = log.Since("A", 2)
+	require.Equal(t, []uint64{3, 4, 5}, sequences(events))
+	require.True(t, complete)
+}
+
+func TestSimpleLogic_GetEventsSince_Forbidden(t *testing.T) {
+	logic, _, session := NewLogicMock()
+
+	resp, err := logic.GetEventsSince(session, &rpc.GetEventsSinceRequest{Topic: model.CharacterTopic(5)})
+	require.EqualError(t, err, model.ErrForbidden.Error())
+	require.Nil(t, resp)
+}
+
+func TestSimpleLogic_GetEventsSince(t *testing.T) {
+	logic, _, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 5, Name: "test"}
+
+	logic.publishEvent(model.NewPopulationGrownEvent(*session.SelectedCharacter))
+	logic.publishEvent(model.NewPopulationGrownEvent(*session.SelectedCharacter))
+
+	topic := logic.topicTokens.Private(model.CharacterTopic(5))
+	resp, err := logic.GetEventsSince(session, &rpc.GetEventsSinceRequest{Topic: topic, Sequence: 1})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, sequences(resp.Events))
+	require.Equal(t, uint64(2), resp.LastSequence)
+	require.True(t, resp.Complete)
+}
+
+func TestSimpleLogic_PublishEvent_QueueIsFull(t *testing.T) {
+	logic, _, _ := NewLogicMock()
+	logic.EventsChan = make(chan model.EventWrapper, 1)
+
+	logic.publishEvent(model.NewCharacterStatusEvent("a", true))
+	logic.publishEvent(model.NewCharacterStatusEvent("b", true))
+
+	require.Equal(t, 1, len(logic.EventsChan))
+
+	events, _, _ := logic.eventLog.Since(model.NewCharacterStatusEvent("a", true).Topic, 0)
+	require.Len(t, events, 2)
+}
@@ -2,6 +2,7 @@ package logic
 
 import (
 	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
 	"time"
 )
 
@@ -10,10 +11,11 @@ const (
 )
 
 func (s *SimpleLogic) updateSessions() {
-	sessionsCount := len(s.sessions)
+	sessions := s.onlineSessions()
+	sessionsCount := len(sessions)
 	finishChan := make(chan bool, sessionsCount)
 
-	for _, session := range s.sessions {
+	for _, session := range sessions {
 		session := session
 
 		go func() {
@@ -65,6 +67,24 @@ func (s *SimpleLogic) startGameLoop() {
 			s.resourceManager.Update()
 		}
 	}()
+
+	go func() {
+		for _ = range time.Tick(caravanUpdateFreq) {
+			s.caravanManager.Update()
+		}
+	}()
+
+	go func() {
+		for _ = range time.Tick(researchUpdateFreq) {
+			s.researchManager.Update()
+		}
+	}()
+
+	go func() {
+		for _ = range time.Tick(armyUpdateFreq) {
+			s.armyManager.Update()
+		}
+	}()
 }
 
 func (s *SimpleLogic) characterPopulationGrownEvent(session *PlayerSession) {
@@ -78,26 +98,45 @@ func (s *SimpleLogic) characterPopulationGrownEvent(session *PlayerSession) {
 
 	if err := session.Tx.UpdateCharacter(*session.SelectedCharacter); err != nil {
 		s.log.WithError(err).Error("Failed to update character")
+		return
 	}
+
+	s.publishEvent(model.NewPopulationGrownEvent(*session.SelectedCharacter))
 }
 
 func (s *SimpleLogic) updateSessionResources(session *PlayerSession) {
 	character := session.SelectedCharacter
-
-	if !character.Resources.IsLimitReached() {
-		character.Resources.Add(model.Resources{
-			Wood:    1,
-			Food:    1,
-			Stone:   1,
-			Leather: 1,
+	before := character.Resources.Clone()
+
+	if !character.Resources.IsFull(character.StorageCapacity) {
+		// Every resource is produced until its own storage is full
+		character.AddResources(model.Resources{
+			model.ResourceWood:    1,
+			model.ResourceFood:    1,
+			model.ResourceStone:   1,
+			model.ResourceLeather: 1,
 		})
 
-		character.Resources.Add(character.ProductionRate)
+		character.AddResources(character.ProductionRate)
+		character.AddResources(character.ProductionRate.Percent(s.techTree.ProductionBonus(character.Techs)))
+		character.AddResources(model.Resources{model.ResourceGold: character.TaxIncome()})
+		character.RunProductionChains()
+	}
 
-		if err := session.Tx.AddOrUpdateResources(character.Resources); err != nil {
-			s.log.WithError(err).Error("Failed to update resources")
-		}
+	// The army eats the food left after the production, the units stay hungry when there is no food
+	upkeep := model.NewResources(model.ResourceFood, character.Army.FoodUpkeep())
+	character.Resources.Subtract(character.Resources.Min(upkeep))
+
+	if before.Equal(character.Resources) {
+		return
 	}
+
+	if err := session.Tx.AddOrUpdateResources(character.ID, character.Resources); err != nil {
+		s.log.WithError(err).Error("Failed to update resources")
+		return
+	}
+
+	s.publishEvent(model.NewResourcesChangedEvent(character.ID, before, character.Resources))
 }
 
 func (s *SimpleLogic) updateSession(session *PlayerSession) {
@@ -105,13 +144,16 @@ func (s *SimpleLogic) updateSession(session *PlayerSession) {
 		s.log.WithField("sessionID", session.SessionID).
 			WithField("timeout", s.config.AFKTimeout).
 			Info("Session AFK timeout, delete session")
-		delete(s.sessions, session.SessionID)
+		s.removeSession(session)
+		s.publishEvent(model.NewCharacterStatusEvent(session.SelectedCharacter.Name, false))
 		return
 	}
 
 	character := session.SelectedCharacter
 
-	populationGrownEvent := CheckRandomEventHappened(PopulationGrownEventChance)
+	// Unhappy population grows slower, nobody settles in the towns with the max taxes
+	growthChance := PopulationGrownEventChance * float32(character.Happiness()) / consts.MaxHappiness
+	populationGrownEvent := CheckRandomEventHappened(growthChance)
 	if populationGrownEvent && character.MaxPopulation != character.CurrentPopulation {
 		s.characterPopulationGrownEvent(session)
 	}
@@ -0,0 +1,129 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSimpleLogic_UpdateSessionResources_ResourcesChangedEvent(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:              1,
+		Name:            "test",
+		Resources:       model.Resources{model.ResourceWood: 10},
+		ProductionRate:  model.Resources{model.ResourceWood: 2},
+		StorageCapacity: model.StorageCapacity(nil),
+	}
+
+	db.On("AddOrUpdateResources", mock.Anything, mock.Anything).Return(nil)
+
+	logic.updateSessionResources(session)
+
+	require.Equal(t, 1, len(logic.EventsChan))
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), event.Topic)
+
+	changed := event.Event.GetResourcesChangedEvent()
+	require.NotNil(t, changed)
+	require.Equal(t, uint64(13), changed.Resources.Amounts["wood"])
+	require.Equal(t, int64(3), changed.Delta.Amounts["wood"])
+	require.Equal(t, int64(1), changed.Delta.Amounts["food"])
+}
+
+func TestSimpleLogic_UpdateSessionResources_LimitReached(t *testing.T) {
+	logic, _, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:              1,
+		Name:            "test",
+		Resources:       model.StorageCapacity(nil),
+		StorageCapacity: model.StorageCapacity(nil),
+	}
+
+	logic.updateSessionResources(session)
+
+	require.Equal(t, 0, len(logic.EventsChan))
+}
+
+func TestSimpleLogic_UpdateSessionResources_PerResourceCapacity(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:              1,
+		Name:            "test",
+		Resources:       model.Resources{model.ResourceWood: 3000, model.ResourceFood: 1999, model.ResourceStone: 10},
+		ProductionRate:  model.Resources{model.ResourceFood: 5},
+		StorageCapacity: model.Resources{model.ResourceWood: 3000, model.ResourceFood: 2000, model.ResourceStone: 3000, model.ResourceLeather: 2000},
+	}
+
+	db.On("AddOrUpdateResources", mock.Anything, mock.Anything).Return(nil)
+
+	logic.updateSessionResources(session)
+
+	resources := session.SelectedCharacter.Resources
+	require.Equal(t, uint64(3000), resources[model.ResourceWood])
+	require.Equal(t, uint64(2000), resources[model.ResourceFood])
+	require.Equal(t, uint64(11), resources[model.ResourceStone])
+	require.Equal(t, uint64(1), resources[model.ResourceLeather])
+	require.Equal(t, 1, len(logic.EventsChan))
+}
+
+func TestSimpleLogic_UpdateSessionResources_TaxIncome(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:                1,
+		Name:              "test",
+		CurrentPopulation: 50,
+		TaxRate:           20,
+		StorageCapacity:   model.StorageCapacity(nil),
+	}
+
+	db.On("AddOrUpdateResources", mock.Anything, mock.Anything).Return(nil)
+
+	logic.updateSessionResources(session)
+
+	require.Equal(t, uint64(10), session.SelectedCharacter.Resources[model.ResourceGold])
+}
+
+func TestCharacter_Happiness(t *testing.T) {
+	require.Equal(t, uint32(consts.MaxHappiness), model.Character{}.Happiness())
+	require.Equal(t, uint32(75), model.Character{TaxRate: 25}.Happiness())
+	require.Equal(t, uint32(0), model.Character{TaxRate: consts.MaxTaxRate}.Happiness())
+}
+
+func TestSimpleLogic_CharacterPopulationGrownEvent(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:                1,
+		Name:              "test",
+		MaxPopulation:     10,
+		CurrentPopulation: 5,
+	}
+
+	db.On("UpdateCharacter", mock.Anything).Return(nil)
+
+	logic.characterPopulationGrownEvent(session)
+
+	require.Equal(t, 1, len(logic.EventsChan))
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), event.Topic)
+	require.Equal(t, uint64(6), event.Event.GetPopulationGrownEvent().CurrentPopulation)
+	require.Equal(t, uint64(10), event.Event.GetPopulationGrownEvent().MaxPopulation)
+}
+
+func TestSimpleLogic_UpdateSession_AFKTimeout(t *testing.T) {
+	logic, _, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+	logic.config.AFKTimeout = 0
+
+	logic.updateSession(session)
+
+	require.NotContains(t, logic.sessions, session.SessionID)
+	require.Equal(t, 1, len(logic.EventsChan))
+
+	event := <-logic.EventsChan
+	require.Equal(t, consts.GlobalTopic, event.Topic)
+	require.Equal(t, "test", event.Event.GetCharacterStatusEvent().Name)
+	require.False(t, event.Event.GetCharacterStatusEvent().Online)
+}
@@ -0,0 +1,50 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetAllianceMembers(
+	session *PlayerSession, request *rpc.GetAllianceMembersRequest) (*rpc.GetAllianceMembersResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":  request.SessionID,
+		"allianceID": request.AllianceID,
+	}).Info("GetAllianceMembers")
+
+	allianceID := request.AllianceID
+	if allianceID == 0 {
+		allianceID = session.SelectedCharacter.AllianceID
+		if allianceID == 0 {
+			return nil, model.ErrNotInAlliance
+		}
+	}
+
+	tx := session.Tx
+
+	alliance, err := s.getAlliance(allianceID, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance")
+		return nil, model.ErrInternalServerError
+	}
+
+	if alliance == nil {
+		return nil, model.ErrAllianceNotFound
+	}
+
+	members, err := tx.GetAllianceMembers(alliance.ID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance members")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetAllianceMembersResponse{
+		Alliance: alliance.ToRPC(),
+	}
+	for _, member := range members {
+		response.Members = append(response.Members, member.ToRPC())
+	}
+
+	return response, nil
+}
@@ -0,0 +1,47 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"sort"
+)
+
+func (s *SimpleLogic) GetArmy(session *PlayerSession, request *rpc.GetArmyRequest) (*rpc.GetArmyResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("GetArmy")
+
+	character := session.SelectedCharacter
+
+	towns, err := session.Tx.GetCharacterArmy(character.Name)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get character army")
+		return nil, model.ErrInternalServerError
+	}
+
+	queue, err := session.Tx.GetCharacterUnitTraining(character.ID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get training queue")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetArmyResponse{
+		FoodUpkeep: character.Army.FoodUpkeep(),
+	}
+
+	for townID, army := range towns {
+		response.Towns = append(response.Towns, &rpc.TownArmy{
+			TownID: townID,
+			Units:  army.ToRPC(),
+		})
+	}
+
+	sort.Slice(response.Towns, func(i, j int) bool { return response.Towns[i].TownID < response.Towns[j].TownID })
+
+	for _, training := range queue {
+		response.Training = append(response.Training, training.ToRPC())
+	}
+
+	return response, nil
+}
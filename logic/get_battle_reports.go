@@ -0,0 +1,37 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetBattleReports(session *PlayerSession, request *rpc.GetBattleReportsRequest) (*rpc.GetBattleReportsResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"offset":    request.Offset,
+		"count":     request.Count,
+	}).Info("GetBattleReports")
+
+	offset := 0
+	limit := 10
+	if request.Offset != 0 {
+		offset = int(request.Offset)
+	}
+	if request.Count != 0 {
+		limit = int(request.Count)
+	}
+
+	reports, err := session.Tx.GetBattleReports(session.SelectedCharacter.ID, offset, limit)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get battle reports")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetBattleReportsResponse{}
+	for _, report := range reports {
+		response.Reports = append(response.Reports, report.ToRPC())
+	}
+
+	return response, nil
+}
@@ -0,0 +1,26 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetCaravans(session *PlayerSession, request *rpc.GetCaravansRequest) (*rpc.GetCaravansResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("GetCaravans")
+
+	caravans, err := session.Tx.GetCharacterCaravans(session.SelectedCharacter.ID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get caravans")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetCaravansResponse{}
+	for _, caravan := range caravans {
+		response.Caravans = append(response.Caravans, caravan.ToRPC())
+	}
+
+	return response, nil
+}
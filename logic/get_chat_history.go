@@ -9,20 +9,30 @@ import (
 func (s *SimpleLogic) GetChatHistory(session *PlayerSession, request *rpc.GetChatHistoryRequest) (*rpc.GetChatHistoryResponse, model.Error) {
 	s.log.WithFields(logrus.Fields{
 		"sessionID": request.SessionID,
-		"offset":    request.Offset,
 		"count":     request.Count,
+		"channel":   request.Channel,
+		"beforeID":  request.BeforeID,
+		"afterID":   request.AfterID,
 	}).Info("GetChatHistory")
 
-	offset := 0
-	limit := 10
-	if request.Offset != 0 {
-		offset = int(request.Offset)
+	channel, err := s.getChatChannel(session, request.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.BeforeID < 0 || request.AfterID < 0 {
+		return nil, model.ErrBadRequest
 	}
+
+	limit := 10
 	if request.Count != 0 {
 		limit = int(request.Count)
 	}
+	if limit > s.config.ChatHistoryMaxPageSize {
+		limit = s.config.ChatHistoryMaxPageSize
+	}
 
-	messages, dbErr := session.Tx.GetChatMessages(offset, limit)
+	messages, dbErr := session.Tx.GetChatMessages(channel.Name(), request.BeforeID, request.AfterID, limit)
 	if dbErr != nil {
 		s.log.WithError(dbErr).Error("Failed to GetChatMessages")
 		return nil, model.ErrInternalServerError
@@ -0,0 +1,26 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetConversations(session *PlayerSession, request *rpc.GetConversationsRequest) (*rpc.GetConversationsResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("GetConversations")
+
+	conversations, err := session.Tx.GetConversations(session.SelectedCharacter.Name)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get conversations")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetConversationsResponse{}
+	for _, conversation := range conversations {
+		response.Conversations = append(response.Conversations, conversation.ToRPC())
+	}
+
+	return response, nil
+}
@@ -0,0 +1,44 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetDiplomacy(session *PlayerSession, request *rpc.GetDiplomacyRequest) (*rpc.GetDiplomacyResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("GetDiplomacy")
+
+	character := session.SelectedCharacter
+	parties := []model.DiplomacyParty{model.CharacterParty(character.Name)}
+	if character.AllianceTag != "" {
+		parties = append(parties, model.AllianceParty(character.AllianceTag))
+	}
+
+	response := &rpc.GetDiplomacyResponse{}
+	for _, party := range parties {
+		relations, err := session.Tx.GetDiplomacyRelations(party)
+		if err != nil {
+			s.log.WithError(err).Error("Failed to get diplomacy relations")
+			return nil, model.ErrInternalServerError
+		}
+
+		for _, relation := range relations {
+			response.Relations = append(response.Relations, relation.ToRPC())
+		}
+
+		proposals, err := session.Tx.GetDiplomacyProposals(party)
+		if err != nil {
+			s.log.WithError(err).Error("Failed to get diplomacy proposals")
+			return nil, model.ErrInternalServerError
+		}
+
+		for _, proposal := range proposals {
+			response.Proposals = append(response.Proposals, proposal.ToRPC())
+		}
+	}
+
+	return response, nil
+}
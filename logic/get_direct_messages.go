@@ -0,0 +1,48 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetDirectMessages(session *PlayerSession, request *rpc.GetDirectMessagesRequest) (*rpc.GetDirectMessagesResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"character": request.Character,
+		"offset":    request.Offset,
+		"count":     request.Count,
+	}).Info("GetDirectMessages")
+
+	if request.Character == "" || request.Offset < 0 || request.Count < 0 {
+		return nil, model.ErrBadRequest
+	}
+
+	offset := int(request.Offset)
+	limit := 10
+	if request.Count != 0 {
+		limit = int(request.Count)
+	}
+	if limit > s.config.DirectMessagesMaxPageSize {
+		limit = s.config.DirectMessagesMaxPageSize
+	}
+
+	name := session.SelectedCharacter.Name
+	messages, err := session.Tx.GetDirectMessages(name, request.Character, offset, limit)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get direct messages")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := session.Tx.MarkDirectMessagesRead(name, request.Character); err != nil {
+		s.log.WithError(err).Error("Failed to mark direct messages as read")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetDirectMessagesResponse{}
+	for _, message := range messages {
+		response.Messages = append(response.Messages, message.ToRPC())
+	}
+
+	return response, nil
+}
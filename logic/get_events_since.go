@@ -0,0 +1,38 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+// isSubscribedTo - checks if the session is allowed to receive events of the topic
+func (s *SimpleLogic) isSubscribedTo(session *PlayerSession, topic string) bool {
+	for _, sessionTopic := range s.eventTopics(session) {
+		if sessionTopic == topic {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *SimpleLogic) GetEventsSince(session *PlayerSession, request *rpc.GetEventsSinceRequest) (*rpc.GetEventsSinceResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"topic":     request.Topic,
+		"sequence":  request.Sequence,
+	}).Info("GetEventsSince")
+
+	if !s.isSubscribedTo(session, request.Topic) {
+		return nil, model.ErrForbidden
+	}
+
+	events, lastSequence, complete := s.eventLog.Since(request.Topic, request.Sequence)
+
+	return &rpc.GetEventsSinceResponse{
+		Events:       events,
+		LastSequence: lastSequence,
+		Complete:     complete,
+	}, nil
+}
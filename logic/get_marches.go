@@ -0,0 +1,28 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+func (s *SimpleLogic) GetMarches(session *PlayerSession, request *rpc.GetMarchesRequest) (*rpc.GetMarchesResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("GetMarches")
+
+	marches, err := session.Tx.GetCharacterMarches(session.SelectedCharacter.ID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get marches")
+		return nil, model.ErrInternalServerError
+	}
+
+	now := time.Now()
+	response := &rpc.GetMarchesResponse{}
+	for _, march := range marches {
+		response.Marches = append(response.Marches, march.ToRPC(now))
+	}
+
+	return response, nil
+}
@@ -0,0 +1,37 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetMarketOrders(session *PlayerSession, request *rpc.GetMarketOrdersRequest) (*rpc.GetMarketOrdersResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"offset":    request.Offset,
+		"count":     request.Count,
+	}).Info("GetMarketOrders")
+
+	offset := 0
+	limit := 10
+	if request.Offset != 0 {
+		offset = int(request.Offset)
+	}
+	if request.Count != 0 {
+		limit = int(request.Count)
+	}
+
+	orders, err := session.Tx.GetCharacterMarketOrders(session.SelectedCharacter.ID, offset, limit)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get market orders")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetMarketOrdersResponse{}
+	for _, order := range orders {
+		response.Orders = append(response.Orders, order.ToRPC())
+	}
+
+	return response, nil
+}
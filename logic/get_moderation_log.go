@@ -0,0 +1,41 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetModerationLog(session *PlayerSession, request *rpc.GetModerationLogRequest) (*rpc.GetModerationLogResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"offset":    request.Offset,
+		"count":     request.Count,
+	}).Info("GetModerationLog")
+
+	if !session.IsModerator {
+		return nil, model.ErrForbidden
+	}
+
+	offset := 0
+	limit := 10
+	if request.Offset != 0 {
+		offset = int(request.Offset)
+	}
+	if request.Count != 0 {
+		limit = int(request.Count)
+	}
+
+	entries, err := session.Tx.GetModerationLog(offset, limit)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get moderation log")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetModerationLogResponse{}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, entry.ToRPC())
+	}
+
+	return response, nil
+}
@@ -0,0 +1,53 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetOrderBook(session *PlayerSession, request *rpc.GetOrderBookRequest) (*rpc.GetOrderBookResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":     request.SessionID,
+		"resource":      request.Resource,
+		"priceResource": request.PriceResource,
+		"depth":         request.Depth,
+	}).Info("GetOrderBook")
+
+	resource := model.ResourceType(request.Resource)
+	priceResource := model.ResourceType(request.PriceResource)
+	if !resource.IsValid() || !priceResource.IsValid() || resource == priceResource || request.Depth < 0 {
+		return nil, model.ErrBadRequest
+	}
+
+	depth := 10
+	if request.Depth != 0 {
+		depth = int(request.Depth)
+	}
+	if depth > consts.MarketOrderBookMaxDepth {
+		depth = consts.MarketOrderBookMaxDepth
+	}
+
+	bids, err := session.Tx.GetOrderBook(resource, priceResource, model.MarketOrderBuy, depth)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get buy orders")
+		return nil, model.ErrInternalServerError
+	}
+
+	asks, err := session.Tx.GetOrderBook(resource, priceResource, model.MarketOrderSell, depth)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get sell orders")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetOrderBookResponse{}
+	for _, order := range bids {
+		response.Bids = append(response.Bids, order.ToRPC())
+	}
+	for _, order := range asks {
+		response.Asks = append(response.Asks, order.ToRPC())
+	}
+
+	return response, nil
+}
@@ -0,0 +1,20 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetResourceTypes(session *PlayerSession, request *rpc.GetResourceTypesRequest) (*rpc.GetResourceTypesResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("GetResourceTypes")
+
+	response := &rpc.GetResourceTypesResponse{}
+	for _, resourceType := range model.ResourceTypes() {
+		response.Resources = append(response.Resources, model.ResourceRegistry[resourceType].ToRPC())
+	}
+
+	return response, nil
+}
@@ -22,7 +22,15 @@ func (s *SimpleLogic) GetResources(
 		return nil, model.ErrInternalServerError
 	}
 
+	buildings, err := session.Tx.GetCharacterBuildings(session.SelectedCharacter.Name)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get buildings")
+		return nil, model.ErrInternalServerError
+	}
+
 	return &rpc.GetResourcesResponse{
 		Resources: resources.ToRPC(),
+		Capacity:  model.StorageCapacity(buildings).ToRPC(),
+		Gold:      resources[model.ResourceGold],
 	}, nil
 }
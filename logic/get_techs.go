@@ -0,0 +1,30 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) GetTechs(session *PlayerSession, request *rpc.GetTechsRequest) (*rpc.GetTechsResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("GetTechs")
+
+	response := &rpc.GetTechsResponse{
+		Researched: session.SelectedCharacter.Techs.IDs(),
+	}
+
+	for _, id := range s.techTree.IDs() {
+		response.Techs = append(response.Techs, s.techTree[id].ToRPC())
+	}
+
+	research, err := s.getActiveResearch(session)
+	if err == nil {
+		response.Research = research.ToRPC()
+	} else if err != model.ErrResearchNotFound {
+		return nil, err
+	}
+
+	return response, nil
+}
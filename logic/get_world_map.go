@@ -1,6 +1,9 @@
 package logic
 
 import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/generation"
+	"abbysoft/gardarike-online/geometry"
 	"abbysoft/gardarike-online/model"
 	rpc "abbysoft/gardarike-online/rpc/generated"
 	"database/sql"
@@ -10,62 +13,103 @@ import (
 	"time"
 )
 
-func (s *SimpleLogic) saveChunk(chunk rpc.WorldMapChunk, session *PlayerSession) error {
+// saveMapChunk - saves chunk to the db and drops its outdated copy from the cache
+func saveMapChunk(chunk rpc.WorldMapChunk, tx db.DatabaseTransaction, cache *ChunkCache) error {
 	modelChunk, err := model.NewWorldMapChunkFromRPC(chunk)
 	if err != nil {
 		return err
 	}
 
-	return session.Tx.SaveMapChunkOrUpdate(modelChunk)
-}
+	if err := tx.SaveMapChunkOrUpdate(modelChunk); err != nil {
+		return err
+	}
 
-func (s *SimpleLogic) generateAndSaveMapChunk(x, y int, session *PlayerSession) (*rpc.WorldMapChunk, error) {
-	s.log.WithFields(log.Fields{
-		"x": x,
-		"y": y,
-	}).Info("Generating map chunk")
+	cache.Invalidate(modelChunk.X, modelChunk.Y)
+	return nil
+}
 
-	terrain := s.generator.GenerateTerrain(
-		s.config.ChunkSize,
-		s.config.ChunkSize,
-		float64(s.config.ChunkSize*x),
-		float64(s.config.ChunkSize*y))
+// generateMapChunk - generates terrain of the chunk located at x, y (in chunks)
+func generateMapChunk(generator generation.TerrainGenerator, config Config, x, y int) rpc.WorldMapChunk {
+	terrain := generator.GenerateTerrain(
+		config.ChunkSize,
+		config.ChunkSize,
+		float64(config.ChunkSize*x),
+		float64(config.ChunkSize*y))
 
-	chunk := rpc.WorldMapChunk{
+	return rpc.WorldMapChunk{
 		X:          int32(x),
 		Y:          int32(y),
-		Width:      int32(s.config.ChunkSize),
-		Height:     int32(s.config.ChunkSize),
+		Width:      int32(config.ChunkSize),
+		Height:     int32(config.ChunkSize),
 		Data:       terrain,
 		Towns:      []*rpc.Town{},
 		Trees:      0,
 		Stones:     0,
 		Animals:    0,
 		Plants:     0,
-		WaterLevel: s.config.WaterLevel,
+		WaterLevel: config.WaterLevel,
 	}
+}
+
+func (s *SimpleLogic) generateAndSaveMapChunk(x, y int, session *PlayerSession) (*rpc.WorldMapChunk, error) {
+	s.log.WithFields(log.Fields{
+		"x": x,
+		"y": y,
+	}).Info("Generating map chunk")
+
+	chunk := generateMapChunk(s.generator, s.config, x, y)
 
-	if err := s.saveChunk(chunk, session); err != nil {
+	if err := saveMapChunk(chunk, session.Tx, s.chunkCache); err != nil {
 		return nil, fmt.Errorf("failed to save map chunk: %w", err)
 	}
 
 	return &chunk, nil
 }
 
-func (s *SimpleLogic) GetWorldMap(session *PlayerSession, request *rpc.GetWorldMapRequest) (*rpc.GetWorldMapResponse, model.Error) {
-	s.log.WithField("location", request.GetLocation()).
-		WithField("sessionID", request.GetSessionID()).
-		Infof("GetMap request")
+// getMapChunk - returns the decoded chunk from the cache or from the db, resources are always loaded from the db.
+// Returns nil if the chunk isn't generated yet
+func (s *SimpleLogic) getMapChunk(x, y int64, tx db.DatabaseTransaction) (*rpc.WorldMapChunk, error) {
+	if chunk := s.chunkCache.Get(x, y); chunk != nil {
+		resources, err := tx.GetMapChunkResources(x, y)
+		if err != nil {
+			return nil, err
+		}
+
+		chunk.Trees, chunk.Stones, chunk.Animals, chunk.Plants =
+			resources.Trees, resources.Stones, resources.Animals, resources.Plants
+		return chunk, nil
+	}
+
+	chunk, err := tx.GetMapChunk(x, y)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if len(chunk.Data) == 0 {
+		return nil, nil
+	}
 
-	newChunk := func() (*rpc.GetWorldMapResponse, model.Error) {
+	rpcChunk, err := chunk.ToRPC()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert map chunk to the rpc chunk: %w", err)
+	}
+
+	s.chunkCache.Put(rpcChunk)
+	return rpcChunk, nil
+}
+
+// getOrGenerateMapChunk - loads chunk from the db or generates a new one if it doesn't exist yet
+func (s *SimpleLogic) getOrGenerateMapChunk(chunk geometry.Point, session *PlayerSession) (*rpc.WorldMapChunk, model.Error) {
+	newChunk := func() (*rpc.WorldMapChunk, model.Error) {
 		s.log.WithField("alwaysGenerate", s.config.AlwaysRegenerateMap).
-			WithField("location", request.GetLocation()).Info("Generating chunk")
+			WithField("chunk", chunk).
+			Info("Generating chunk")
 
-		if newChunk, err := s.generateAndSaveMapChunk(int(request.Location.X), int(request.Location.Y), session); err != nil {
+		if newChunk, err := s.generateAndSaveMapChunk(int(chunk.X), int(chunk.Y), session); err != nil {
 			s.log.WithError(err).Error("Failed to regenerate game map")
 			return nil, model.ErrInternalServerError
 		} else {
-			return &rpc.GetWorldMapResponse{Map: newChunk}, nil
+			return newChunk, nil
 		}
 	}
 
@@ -77,37 +121,61 @@ func (s *SimpleLogic) GetWorldMap(session *PlayerSession, request *rpc.GetWorldM
 	tx := session.Tx
 	tx.SetAutoRollBack(false)
 
-	chunk, err := tx.GetMapChunk(int64(request.Location.X), int64(request.Location.Y))
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	rpcChunk, err := s.getMapChunk(chunk.X, chunk.Y, tx)
+	if err != nil {
 		s.log.WithError(err).Error("Failed to get map chunk")
 		return nil, model.ErrInternalServerError
 	}
 
 	tx.SetAutoRollBack(true)
 
-	if len(chunk.Data) == 0 {
+	if rpcChunk == nil {
 		return newChunk()
 	}
 
-	rpcChunk, err := chunk.ToRPC()
-	if err != nil {
-		s.log.WithError(err).Error("Failed to convert map chunk to the rpc chunk")
-		return nil, model.ErrInternalServerError
+	return rpcChunk, nil
+}
+
+// getWorldMapChunk - returns the chunk with all towns located on it and territories covering it
+func (s *SimpleLogic) getWorldMapChunk(chunk geometry.Point, session *PlayerSession) (*rpc.WorldMapChunk, model.Error) {
+	rpcChunk, modelErr := s.getOrGenerateMapChunk(chunk, session)
+	if modelErr != nil {
+		return nil, modelErr
 	}
 
-	xStart := int(request.Location.X) * mapChunkSize
-	xEnd := xStart + mapChunkSize
-	yStart := int(request.Location.Y) * mapChunkSize
-	yEnd := yStart + mapChunkSize
+	chunkRect := s.grid().ChunkRect(chunk)
 
-	towns, err := tx.GetTownsForRect(xStart, xEnd, yStart, yEnd)
+	territories, err := s.getTerritoriesForRect(chunkRect, session.Tx)
 	if err != nil {
 		s.log.WithError(err).Error("Failed to get chunk towns")
 		return nil, model.ErrInternalServerError
 	}
 
-	for _, town := range towns {
-		rpcChunk.Towns = append(rpcChunk.Towns, town.ToRPC())
+	for _, town := range territories {
+		if chunkRect.Contains(town.Location()) {
+			rpcChunk.Towns = append(rpcChunk.Towns, town.ToRPC())
+		}
+
+		rpcChunk.Territories = append(rpcChunk.Territories, town.TerritoryToRPC())
+	}
+
+	return rpcChunk, nil
+}
+
+func (s *SimpleLogic) GetWorldMap(session *PlayerSession, request *rpc.GetWorldMapRequest) (*rpc.GetWorldMapResponse, model.Error) {
+	s.log.WithField("location", request.GetLocation()).
+		WithField("sessionID", request.GetSessionID()).
+		Infof("GetMap request")
+
+	if request.Location == nil {
+		return nil, model.ErrBadRequest
+	}
+
+	chunk := geometry.Point{X: int64(request.Location.X), Y: int64(request.Location.Y)}
+
+	rpcChunk, err := s.getWorldMapChunk(chunk, session)
+	if err != nil {
+		return nil, err
 	}
 
 	return &rpc.GetWorldMapResponse{Map: rpcChunk}, nil
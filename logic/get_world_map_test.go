@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"abbysoft/gardarike-online/geometry"
 	"abbysoft/gardarike-online/model"
 	rpc "abbysoft/gardarike-online/rpc/generated"
 	"database/sql"
@@ -25,6 +26,11 @@ func TestSimpleLogic_GetWorldMap_GeneratedWhenNoMapIsFound(t *testing.T) {
 
 	db.On("GetMapChunk", int64(0), int64(0)).Return(model.WorldMapChunk{}, sql.ErrNoRows)
 	db.On("SaveMapChunkOrUpdate", mock.Anything, mock.Anything).Return(nil)
+	db.On("GetTownsForRect", geometry.Rect{MinX: -60, MinY: -60, MaxX: 69, MaxY: 69}).Return([]model.Town{
+		{ID: 1, X: 5, Y: 5, Name: "inside"},
+		{ID: 2, X: -5, Y: 5, Name: "neighbour"},
+		{ID: 3, X: -50, Y: 5, Name: "far away"},
+	}, nil)
 
 	generator.On("GenerateTerrain", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return([]float32{10.0, 10.0})
@@ -36,4 +42,31 @@ func TestSimpleLogic_GetWorldMap_GeneratedWhenNoMapIsFound(t *testing.T) {
 	assert.NotEmpty(t, response.Map.Data)
 	assert.Equal(t, int32(0), response.Map.X)
 	assert.Equal(t, int32(0), response.Map.Y)
+
+	// Only the town inside of the chunk is returned but the neighbour's territory covers the chunk too
+	require.Len(t, response.Map.Towns, 1)
+	assert.Equal(t, int64(1), response.Map.Towns[0].Id)
+	require.Len(t, response.Map.Territories, 2)
+	assert.Equal(t, int64(2), response.Map.Territories[1].TownID)
+}
+
+func TestSimpleLogic_GetMapChunk_CachedChunkHasFreshResources(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.chunkCache = NewChunkCache(1)
+
+	chunk, _ := model.NewWorldMapChunkFromRPC(rpc.WorldMapChunk{X: 1, Y: 2, Data: []float32{1}, Trees: 10})
+	db.On("GetMapChunk", int64(1), int64(2)).Return(chunk, nil).Once()
+	db.On("GetMapChunkResources", int64(1), int64(2)).Return(model.ChunkResources{Trees: 15, Stones: 3}, nil)
+
+	first, err := logic.getMapChunk(1, 2, session.Tx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), first.Trees)
+
+	// Terrain comes from the cache but the resources are changed by the resource manager meanwhile
+	second, err := logic.getMapChunk(1, 2, session.Tx)
+	require.NoError(t, err)
+	assert.Equal(t, first.Data, second.Data)
+	assert.Equal(t, uint64(15), second.Trees)
+	assert.Equal(t, uint64(3), second.Stones)
+	db.AssertNumberOfCalls(t, "GetMapChunk", 1)
 }
@@ -0,0 +1,71 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/generation"
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+// downsampleChunk - reduces chunk heightmap resolution by the levelOfDetail factor
+func downsampleChunk(chunk *rpc.WorldMapChunk, levelOfDetail int) {
+	if levelOfDetail <= 1 {
+		chunk.LevelOfDetail = 1
+		return
+	}
+
+	data, width, height := generation.Downsample(
+		chunk.Data, int(chunk.Width), int(chunk.Height), levelOfDetail)
+
+	chunk.Data = data
+	chunk.Width = int32(width)
+	chunk.Height = int32(height)
+	chunk.LevelOfDetail = int32(levelOfDetail)
+}
+
+func (s *SimpleLogic) GetWorldViewport(
+	session *PlayerSession, request *rpc.GetWorldViewportRequest) ([]*rpc.GetWorldViewportResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":     request.SessionID,
+		"from":          request.From,
+		"to":            request.To,
+		"levelOfDetail": request.LevelOfDetail,
+	}).Info("GetWorldViewport")
+
+	if request.From == nil || request.To == nil || request.LevelOfDetail < 0 {
+		return nil, model.ErrBadRequest
+	}
+
+	xStart, xEnd := int(request.From.X), int(request.To.X)
+	if xStart > xEnd {
+		xStart, xEnd = xEnd, xStart
+	}
+
+	yStart, yEnd := int(request.From.Y), int(request.To.Y)
+	if yStart > yEnd {
+		yStart, yEnd = yEnd, yStart
+	}
+
+	// Spans are checked one by one first, the product of two huge spans could overflow
+	width, height := xEnd-xStart+1, yEnd-yStart+1
+	if width > s.config.MaxViewportChunks || height > s.config.MaxViewportChunks ||
+		width*height > s.config.MaxViewportChunks {
+		return nil, model.ErrViewportTooLarge
+	}
+
+	var responses []*rpc.GetWorldViewportResponse
+	for x := xStart; x <= xEnd; x++ {
+		for y := yStart; y <= yEnd; y++ {
+			chunk, err := s.getWorldMapChunk(geometry.Point{X: int64(x), Y: int64(y)}, session)
+			if err != nil {
+				return nil, err
+			}
+
+			downsampleChunk(chunk, int(request.LevelOfDetail))
+			responses = append(responses, &rpc.GetWorldViewportResponse{Map: chunk})
+		}
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestSimpleLogic_GetWorldViewport(t *testing.T) {
+	logic, db, session, generator := NewLogicMockWithTerrainGenerator()
+	request := &rpc.GetWorldViewportRequest{
+		SessionID:     "sessionID",
+		From:          &rpc.IntVector2D{X: 1, Y: 0},
+		To:            &rpc.IntVector2D{X: 0, Y: 0},
+		LevelOfDetail: 2,
+	}
+
+	logic.config.ChunkSize = 4
+	logic.config.MaxViewportChunks = 2
+
+	db.On("GetMapChunk", mock.Anything, int64(0)).Return(model.WorldMapChunk{}, sql.ErrNoRows)
+	db.On("SaveMapChunkOrUpdate", mock.Anything).Return(nil)
+	db.On("GetTownsForRect", mock.Anything).Return([]model.Town{}, nil)
+
+	generator.On("GenerateTerrain", 4, 4, mock.Anything, mock.Anything).
+		Return(make([]float32, 16))
+
+	responses, err := logic.GetWorldViewport(session, request)
+	require.NoError(t, err)
+	require.Len(t, responses, 2)
+
+	for i, response := range responses {
+		assert.Equal(t, int32(i), response.Map.X)
+		assert.Equal(t, int32(0), response.Map.Y)
+		assert.Equal(t, int32(2), response.Map.Width)
+		assert.Equal(t, int32(2), response.Map.Height)
+		assert.Equal(t, int32(2), response.Map.LevelOfDetail)
+		assert.Len(t, response.Map.Data, 4)
+	}
+
+	// Viewport is bigger than allowed
+	request.To = &rpc.IntVector2D{X: 0, Y: 1}
+
+	responses, err = logic.GetWorldViewport(session, request)
+	require.EqualError(t, err, model.ErrViewportTooLarge.Error())
+	require.Nil(t, responses)
+}
+
+func TestSimpleLogic_GetWorldViewport_ExtremeCoordinates(t *testing.T) {
+	logic, _, session := NewLogicMock()
+	logic.config.MaxViewportChunks = 16
+
+	// 2^32 chunks along each axis, the area overflows to zero
+	responses, err := logic.GetWorldViewport(session, &rpc.GetWorldViewportRequest{
+		From: &rpc.IntVector2D{X: math.MinInt32, Y: math.MinInt32},
+		To:   &rpc.IntVector2D{X: math.MaxInt32, Y: math.MaxInt32},
+	})
+	require.EqualError(t, err, model.ErrViewportTooLarge.Error())
+	require.Nil(t, responses)
+
+	responses, err = logic.GetWorldViewport(session, &rpc.GetWorldViewportRequest{
+		From: &rpc.IntVector2D{X: math.MinInt32, Y: 0},
+		To:   &rpc.IntVector2D{X: math.MaxInt32, Y: 0},
+	})
+	require.EqualError(t, err, model.ErrViewportTooLarge.Error())
+	require.Nil(t, responses)
+}
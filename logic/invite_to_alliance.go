@@ -0,0 +1,56 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+func (s *SimpleLogic) InviteToAlliance(session *PlayerSession, request *rpc.InviteToAllianceRequest) (*rpc.InviteToAllianceResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"character": request.Character,
+	}).Info("InviteToAlliance")
+
+	member, modelErr := s.checkAlliancePermission(session, model.AlliancePermissionInvite)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	tx := session.Tx
+
+	target, err := s.findCharacter(request.Character, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get character")
+		return nil, model.ErrInternalServerError
+	}
+
+	if target == nil {
+		return nil, model.ErrCharacterNotFound
+	}
+
+	if target.AllianceID != 0 {
+		return nil, model.ErrAlreadyInAlliance
+	}
+
+	alliance, err := tx.GetAlliance(member.AllianceID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := tx.AddAllianceInvite(model.AllianceInvite{
+		AllianceID:    alliance.ID,
+		CharacterName: target.Name,
+		InviterName:   session.SelectedCharacter.Name,
+		CreatedAt:     time.Now(),
+	}); err != nil {
+		s.log.WithError(err).Error("Failed to add alliance invite")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.publishEvent(model.NewAllianceInviteEvent(target.ID, alliance, session.SelectedCharacter.Name))
+
+	return &rpc.InviteToAllianceResponse{}, nil
+}
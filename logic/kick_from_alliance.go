@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) KickFromAlliance(session *PlayerSession, request *rpc.KickFromAllianceRequest) (*rpc.KickFromAllianceResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"character": request.Character,
+	}).Info("KickFromAlliance")
+
+	officer, modelErr := s.checkAlliancePermission(session, model.AlliancePermissionKick)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	tx := session.Tx
+
+	target, err := s.findCharacter(request.Character, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get character")
+		return nil, model.ErrInternalServerError
+	}
+
+	if target == nil {
+		return nil, model.ErrCharacterNotFound
+	}
+
+	if target.AllianceID != officer.AllianceID {
+		return nil, model.ErrNotInAlliance
+	}
+
+	member, err := tx.GetAllianceMember(target.Name)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	if member.Rank >= officer.Rank {
+		return nil, model.ErrForbidden
+	}
+
+	alliance, err := tx.GetAlliance(officer.AllianceID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := tx.RemoveAllianceMember(target.Name); err != nil {
+		s.log.WithError(err).Error("Failed to remove alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.setCharacterAlliance(session, target.Name, nil)
+	s.rotateAllianceTopics(session, alliance.ID)
+
+	if err := s.sendAllianceSystemMessage(alliance.ID,
+		consts.MessageAllianceMemberKicked(target.Name, session.SelectedCharacter.Name), tx); err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(model.NewPrivateSystemChatMessageEvent(
+		model.CharacterTopic(target.ID), consts.MessageKickedFromAlliance(alliance.Name)))
+
+	return &rpc.KickFromAllianceResponse{}, nil
+}
@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) LeaveAlliance(session *PlayerSession, request *rpc.LeaveAllianceRequest) (*rpc.LeaveAllianceResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+	}).Info("LeaveAlliance")
+
+	tx := session.Tx
+	character := session.SelectedCharacter
+
+	member, err := s.getAllianceMember(character.Name, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	if member == nil {
+		return nil, model.ErrNotInAlliance
+	}
+
+	// Alliance can't stay without the leader, the leader has to disband it
+	if member.Rank == model.AllianceRankLeader {
+		return nil, model.ErrForbidden
+	}
+
+	if err := tx.RemoveAllianceMember(character.Name); err != nil {
+		s.log.WithError(err).Error("Failed to remove alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.setCharacterAlliance(session, character.Name, nil)
+	s.rotateAllianceTopics(session, member.AllianceID)
+
+	if err := s.sendAllianceSystemMessage(member.AllianceID, consts.MessageAllianceLeft(character.Name), tx); err != nil {
+		return nil, err
+	}
+
+	return &rpc.LeaveAllianceResponse{}, nil
+}
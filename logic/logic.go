@@ -4,17 +4,22 @@ import (
 	db2 "abbysoft/gardarike-online/db"
 	"abbysoft/gardarike-online/db/postgres"
 	"abbysoft/gardarike-online/generation"
+	"abbysoft/gardarike-online/geometry"
 	"abbysoft/gardarike-online/model"
 	"abbysoft/gardarike-online/model/consts"
 	rpc "abbysoft/gardarike-online/rpc/generated"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"sync"
 	"time"
 )
 
 const (
-	mapChunkSize = 100
+	// Chunk around which the first towns are placed
+	spawnChunkX = 0
+	spawnChunkY = 0
 )
 
 type Logic interface {
@@ -29,24 +34,95 @@ type Logic interface {
 	GetResources(session *PlayerSession, request *rpc.GetResourcesRequest) (*rpc.GetResourcesResponse, model.Error)
 	PlaceTown(session *PlayerSession, request *rpc.PlaceTownRequest) (*rpc.PlaceTownResponse, model.Error)
 	PlaceBuilding(session *PlayerSession, request *rpc.PlaceBuildingRequest) (*rpc.PlaceBuildingResponse, model.Error)
+	GetTileOwner(session *PlayerSession, request *rpc.GetTileOwnerRequest) (*rpc.GetTileOwnerResponse, model.Error)
+	GetWorldViewport(session *PlayerSession, request *rpc.GetWorldViewportRequest) ([]*rpc.GetWorldViewportResponse, model.Error)
+	GetEventsSince(session *PlayerSession, request *rpc.GetEventsSinceRequest) (*rpc.GetEventsSinceResponse, model.Error)
+	SendDirectMessage(session *PlayerSession, request *rpc.SendDirectMessageRequest) (*rpc.SendDirectMessageResponse, model.Error)
+	GetConversations(session *PlayerSession, request *rpc.GetConversationsRequest) (*rpc.GetConversationsResponse, model.Error)
+	GetDirectMessages(session *PlayerSession, request *rpc.GetDirectMessagesRequest) (*rpc.GetDirectMessagesResponse, model.Error)
+	MuteCharacter(session *PlayerSession, request *rpc.MuteCharacterRequest) (*rpc.MuteCharacterResponse, model.Error)
+	UnmuteCharacter(session *PlayerSession, request *rpc.UnmuteCharacterRequest) (*rpc.UnmuteCharacterResponse, model.Error)
+	GetModerationLog(session *PlayerSession, request *rpc.GetModerationLogRequest) (*rpc.GetModerationLogResponse, model.Error)
+	CreateAlliance(session *PlayerSession, request *rpc.CreateAllianceRequest) (*rpc.CreateAllianceResponse, model.Error)
+	DisbandAlliance(session *PlayerSession, request *rpc.DisbandAllianceRequest) (*rpc.DisbandAllianceResponse, model.Error)
+	InviteToAlliance(session *PlayerSession, request *rpc.InviteToAllianceRequest) (*rpc.InviteToAllianceResponse, model.Error)
+	AcceptAllianceInvite(session *PlayerSession, request *rpc.AcceptAllianceInviteRequest) (*rpc.AcceptAllianceInviteResponse, model.Error)
+	LeaveAlliance(session *PlayerSession, request *rpc.LeaveAllianceRequest) (*rpc.LeaveAllianceResponse, model.Error)
+	KickFromAlliance(session *PlayerSession, request *rpc.KickFromAllianceRequest) (*rpc.KickFromAllianceResponse, model.Error)
+	SetAllianceRank(session *PlayerSession, request *rpc.SetAllianceRankRequest) (*rpc.SetAllianceRankResponse, model.Error)
+	GetAllianceMembers(session *PlayerSession, request *rpc.GetAllianceMembersRequest) (*rpc.GetAllianceMembersResponse, model.Error)
+	ProposeDiplomacy(session *PlayerSession, request *rpc.ProposeDiplomacyRequest) (*rpc.ProposeDiplomacyResponse, model.Error)
+	AcceptDiplomacyProposal(session *PlayerSession, request *rpc.AcceptDiplomacyProposalRequest) (*rpc.AcceptDiplomacyProposalResponse, model.Error)
+	RejectDiplomacyProposal(session *PlayerSession, request *rpc.RejectDiplomacyProposalRequest) (*rpc.RejectDiplomacyProposalResponse, model.Error)
+	GetDiplomacy(session *PlayerSession, request *rpc.GetDiplomacyRequest) (*rpc.GetDiplomacyResponse, model.Error)
+	PlaceMarketOrder(session *PlayerSession, request *rpc.PlaceMarketOrderRequest) (*rpc.PlaceMarketOrderResponse, model.Error)
+	CancelMarketOrder(session *PlayerSession, request *rpc.CancelMarketOrderRequest) (*rpc.CancelMarketOrderResponse, model.Error)
+	GetOrderBook(session *PlayerSession, request *rpc.GetOrderBookRequest) (*rpc.GetOrderBookResponse, model.Error)
+	GetMarketOrders(session *PlayerSession, request *rpc.GetMarketOrdersRequest) (*rpc.GetMarketOrdersResponse, model.Error)
+	SendCaravan(session *PlayerSession, request *rpc.SendCaravanRequest) (*rpc.SendCaravanResponse, model.Error)
+	GetCaravans(session *PlayerSession, request *rpc.GetCaravansRequest) (*rpc.GetCaravansResponse, model.Error)
+	GetResourceTypes(session *PlayerSession, request *rpc.GetResourceTypesRequest) (*rpc.GetResourceTypesResponse, model.Error)
+	SetTaxRate(session *PlayerSession, request *rpc.SetTaxRateRequest) (*rpc.SetTaxRateResponse, model.Error)
+	GetTechs(session *PlayerSession, request *rpc.GetTechsRequest) (*rpc.GetTechsResponse, model.Error)
+	StartResearch(session *PlayerSession, request *rpc.StartResearchRequest) (*rpc.StartResearchResponse, model.Error)
+	CancelResearch(session *PlayerSession, request *rpc.CancelResearchRequest) (*rpc.CancelResearchResponse, model.Error)
+	TrainUnits(session *PlayerSession, request *rpc.TrainUnitsRequest) (*rpc.TrainUnitsResponse, model.Error)
+	GetArmy(session *PlayerSession, request *rpc.GetArmyRequest) (*rpc.GetArmyResponse, model.Error)
+	SendArmy(session *PlayerSession, request *rpc.SendArmyRequest) (*rpc.SendArmyResponse, model.Error)
+	RecallArmy(session *PlayerSession, request *rpc.RecallArmyRequest) (*rpc.RecallArmyResponse, model.Error)
+	RedirectArmy(session *PlayerSession, request *rpc.RedirectArmyRequest) (*rpc.RedirectArmyResponse, model.Error)
+	GetMarches(session *PlayerSession, request *rpc.GetMarchesRequest) (*rpc.GetMarchesResponse, model.Error)
+	GetBattleReports(session *PlayerSession, request *rpc.GetBattleReportsRequest) (*rpc.GetBattleReportsResponse, model.Error)
+	BuyPeaceShield(session *PlayerSession, request *rpc.BuyPeaceShieldRequest) (*rpc.BuyPeaceShieldResponse, model.Error)
 }
 
 type SimpleLogic struct {
 	db              db2.Database
 	log             *logrus.Entry
 	sessions        map[string]*PlayerSession
+	sessionsMutex   sync.RWMutex // Guards the sessions map, not the sessions themselves
 	EventsChan      chan model.EventWrapper
 	config          Config
 	resourceManager ResourceManager
+	caravanManager  *CaravanManager
+	researchManager *ResearchManager
+	armyManager     *ArmyManager
+	techTree        model.TechTree
 	generator       generation.TerrainGenerator
+	chunkCache      *ChunkCache
+	eventLog        *EventLog
+	topicTokens     *TopicTokens
+	chatLimiter     *ChatRateLimiter
+	chatFilter      *ChatFilter
+	chatCommands    *ChatCommandRegistry
 }
 
 type Config struct {
-	AFKTimeout           time.Duration
-	ChatMessageMaxLength int
-	WaterLevel           float32
-	ChunkSize            int
-	AlwaysRegenerateMap  bool
+	AFKTimeout                time.Duration
+	ChatMessageMaxLength      int
+	WaterLevel                float32
+	ChunkSize                 int
+	AlwaysRegenerateMap       bool
+	MaxViewportChunks         int
+	ChunkCacheSize            int // Max number of decoded chunks kept in memory
+	PregenRadius              int // Radius (in chunks) around the spawn generated on start, 0 disables pregeneration
+	PregenWorkers             int
+	MinTownDistance           int     // Min distance (in tiles) between two towns
+	MaxTownSlope              float32 // Max height difference between the town tile and its neighbours
+	SpawnSearchAttempts       int     // Max number of locations checked while searching a place for the town
+	EventReplayBufferSize     int     // Number of the latest events (of all topics) available for replay
+	ChatRegionSize            int     // Size (in chunks) of the square region which has its own chat channel
+	ChatRateLimit             int     // Max number of chat messages of the character during ChatRateWindow, 0 disables the limit
+	ChatRateWindow            time.Duration
+	BannedWords               []string      // Words masked in the chat messages
+	ChatHistoryMaxPageSize    int           // Max number of messages returned by the GetChatHistory
+	DirectMessagesMaxPageSize int           // Max number of messages returned by the GetDirectMessages
+	CaravanTileTravelTime     time.Duration // Time needed for the caravan to pass one tile
+	TechTreeFile              string        // Path to the data file with the techs
+	MarchMaxDistance          int           // Max distance (in tiles) between the army and its destination
+	NewbieProtection          time.Duration // Protection of the new character starting with the first town
+	PeaceShieldDuration       time.Duration
+	PeaceShieldGoldCost       uint64
 }
 
 func NewLogic(generator generation.TerrainGenerator, eventsChan chan model.EventWrapper, dbConfig postgres.Config, config Config) (*SimpleLogic, error) {
@@ -55,16 +131,41 @@ func NewLogic(generator generation.TerrainGenerator, eventsChan chan model.Event
 		return nil, fmt.Errorf("failed to init db: %w", err)
 	}
 
+	techTree, err := LoadTechTree(config.TechTreeFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tech tree: %w", err)
+	}
+
 	logic := &SimpleLogic{
-		db:         database,
-		log:        logrus.WithField("module", "logic"),
-		sessions:   make(map[string]*PlayerSession),
-		EventsChan: eventsChan,
-		config:     config,
-		generator:  generator,
+		db:          database,
+		log:         logrus.WithField("module", "logic"),
+		sessions:    make(map[string]*PlayerSession),
+		EventsChan:  eventsChan,
+		config:      config,
+		generator:   generator,
+		chunkCache:  NewChunkCache(config.ChunkCacheSize),
+		eventLog:    NewEventLog(config.EventReplayBufferSize),
+		topicTokens: NewTopicTokens(),
+		chatLimiter: NewChatRateLimiter(config.ChatRateLimit, config.ChatRateWindow),
+		chatFilter:  NewChatFilter(config.BannedWords),
+		techTree:    techTree,
 	}
 
 	logic.resourceManager = NewResourceManager(logic)
+	logic.caravanManager = NewCaravanManager(logic)
+	logic.researchManager = NewResearchManager(logic)
+	logic.armyManager = NewArmyManager(logic)
+	logic.registerDefaultChatCommands()
+
+	if config.PregenRadius > 0 {
+		pregenerator := NewChunkPregenerator(database, generator, logic.chunkCache, config)
+
+		go func() {
+			if err := pregenerator.Pregenerate(config.PregenRadius); err != nil {
+				logic.log.WithError(err).Error("Chunk pregeneration failed")
+			}
+		}()
+	}
 
 	logic.log.Info("Running game loop")
 	logic.startGameLoop()
@@ -72,6 +173,268 @@ func NewLogic(generator generation.TerrainGenerator, eventsChan chan model.Event
 	return logic, nil
 }
 
+// AddCaravanRisk - registers the hook deciding which part of the caravan cargo is lost on the way
+func (s *SimpleLogic) AddCaravanRisk(risk CaravanRisk) {
+	s.caravanManager.AddRisk(risk)
+}
+
+// addSession - registers the session of the logged in account
+func (s *SimpleLogic) addSession(session *PlayerSession) {
+	s.sessionsMutex.Lock()
+	defer s.sessionsMutex.Unlock()
+
+	s.sessions[session.SessionID] = session
+}
+
+// getSession - returns the session by its id, false if there is no such session
+func (s *SimpleLogic) getSession(sessionID string) (*PlayerSession, bool) {
+	s.sessionsMutex.RLock()
+	defer s.sessionsMutex.RUnlock()
+
+	session, found := s.sessions[sessionID]
+	return session, found
+}
+
+// removeSession - forgets the session and its events
+func (s *SimpleLogic) removeSession(session *PlayerSession) {
+	s.sessionsMutex.Lock()
+	delete(s.sessions, session.SessionID)
+	s.sessionsMutex.Unlock()
+
+	s.eventLog.Forget(session.EventTopic)
+}
+
+// onlineSessions - returns the copy of the current sessions, so they can be iterated (and locked)
+// while other sessions log in and out
+func (s *SimpleLogic) onlineSessions() []*PlayerSession {
+	s.sessionsMutex.RLock()
+	defer s.sessionsMutex.RUnlock()
+
+	sessions := make([]*PlayerSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+
+	return sessions
+}
+
+// grid - returns the world coordinate system of the current chunk size
+func (s *SimpleLogic) grid() geometry.Grid {
+	return geometry.NewGrid(s.config.ChunkSize)
+}
+
+// findCharacter - returns the character with the name or nil if it doesn't exist
+func (s *SimpleLogic) findCharacter(name string, tx db2.DatabaseTransaction) (*model.Character, error) {
+	tx.SetAutoRollBack(false)
+	character, err := tx.GetCharacterByName(name)
+	tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &character, nil
+}
+
+// closeCharacterSessions - removes all sessions of the character.
+// Returns the character or nil if it wasn't online
+func (s *SimpleLogic) closeCharacterSessions(name string) *model.Character {
+	var closed *model.Character
+
+	for _, session := range s.onlineSessions() {
+		if session.SelectedCharacter != nil && session.SelectedCharacter.Name == name {
+			closed = session.SelectedCharacter
+			s.removeSession(session)
+		}
+	}
+
+	return closed
+}
+
+// updateOnlineCharacter - applies the update to the selected character of the session if the character is online,
+// returns false if the character is offline. The current session is already locked by the caller
+func (s *SimpleLogic) updateOnlineCharacter(current *PlayerSession, characterID int64, update func(*model.Character)) bool {
+	return s.updateSelectedCharacter(current, func(character *model.Character) bool {
+		return character.ID == characterID
+	}, update)
+}
+
+// updateOnlineCharacterByName - same as updateOnlineCharacter, but the character is found by its name
+func (s *SimpleLogic) updateOnlineCharacterByName(current *PlayerSession, name string, update func(*model.Character)) bool {
+	return s.updateSelectedCharacter(current, func(character *model.Character) bool {
+		return character.Name == name
+	}, update)
+}
+
+func (s *SimpleLogic) updateSelectedCharacter(
+	current *PlayerSession, match func(*model.Character) bool, update func(*model.Character)) bool {
+	for _, session := range s.onlineSessions() {
+		if session != current {
+			session.Mutex.Lock()
+		}
+
+		character := session.SelectedCharacter
+		found := character != nil && match(character)
+		if found {
+			update(character)
+		}
+
+		if session != current {
+			session.Mutex.Unlock()
+		}
+
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateCharacter - applies the update to the character loaded from the db and saves it,
+// the online character gets the same update once the transaction is committed
+func (s *SimpleLogic) updateCharacter(
+	characterID int64, tx db2.DatabaseTransaction, after *afterCommit, update func(*model.Character)) error {
+	character, err := tx.GetCharacter(characterID)
+	if err != nil {
+		return fmt.Errorf("failed to get character: %w", err)
+	}
+
+	update(&character)
+	if err := tx.UpdateCharacter(character); err != nil {
+		return fmt.Errorf("failed to update character: %w", err)
+	}
+
+	after.add(func() {
+		s.updateOnlineCharacter(nil, characterID, update)
+	})
+	return nil
+}
+
+// giveCharacterResources - adds resources (limited by the storage capacity) to the character in the db,
+// the online character receives them in memory once the transaction is committed
+func (s *SimpleLogic) giveCharacterResources(
+	name string, resources model.Resources, tx db2.DatabaseTransaction, after *afterCommit) error {
+	buildings, err := tx.GetCharacterBuildings(name)
+	if err != nil {
+		return fmt.Errorf("failed to get buildings: %w", err)
+	}
+
+	if err := tx.AddCharacterResources(name, resources, model.StorageCapacity(buildings)); err != nil {
+		return fmt.Errorf("failed to add resources: %w", err)
+	}
+
+	after.add(func() {
+		var event model.EventWrapper
+		online := s.updateOnlineCharacterByName(nil, name, func(character *model.Character) {
+			before := character.Resources.Clone()
+			character.AddResources(resources)
+			event = model.NewResourcesChangedEvent(character.ID, before, character.Resources)
+		})
+
+		if online {
+			s.publishEvent(event)
+		}
+	})
+	return nil
+}
+
+// returnCharacterResources - same as giveCharacterResources, but the resources aren't limited by the storage capacity,
+// the character has already paid them (market escrow and its trades, caravan cargo).
+// Current is the session of the handled request (it's locked already), nil outside of the requests
+func (s *SimpleLogic) returnCharacterResources(current *PlayerSession,
+	name string, resources model.Resources, tx db2.DatabaseTransaction, after *afterCommit) error {
+	if err := tx.AddCharacterResources(name, resources, nil); err != nil {
+		return fmt.Errorf("failed to add resources: %w", err)
+	}
+
+	after.add(func() {
+		var event model.EventWrapper
+		online := s.updateOnlineCharacterByName(current, name, func(character *model.Character) {
+			before := character.Resources.Clone()
+			character.Resources.Add(resources)
+			event = model.NewResourcesChangedEvent(character.ID, before, character.Resources)
+		})
+
+		if online {
+			s.publishEvent(event)
+		}
+	})
+	return nil
+}
+
+// setSessionResources - replaces resources of the session character once the request is committed,
+// the new resources are already saved by the request transaction
+func (s *SimpleLogic) setSessionResources(session *PlayerSession, resources model.Resources) {
+	session.AfterCommit.add(func() {
+		character := session.SelectedCharacter
+		before := character.Resources
+		character.Resources = resources
+		s.publishEvent(model.NewResourcesChangedEvent(character.ID, before, resources))
+	})
+}
+
+// afterCommit - in-memory changes of the online characters (and the events about them) made by the background
+// managers and the request handlers. They are applied only once the transaction is committed, so the sessions
+// never see the rolled back changes. The sessions are never locked while the transaction holds the row locks,
+// the request handlers lock them in the reverse order
+type afterCommit []func()
+
+// add - postpones the action until the transaction is committed
+func (a *afterCommit) add(action func()) {
+	*a = append(*a, action)
+}
+
+// run - applies the postponed actions in the order they were added
+func (a afterCommit) run() {
+	for _, action := range a {
+		action()
+	}
+}
+
+// rollBack - discards the transaction of the failed background update
+func rollBack(tx db2.DatabaseTransaction, logger *logrus.Entry) {
+	if err := tx.RollBack(); err != nil {
+		logger.WithError(err).Error("Failed to roll back transaction")
+	}
+}
+
+// publishEvent - numbers the event and passes it to the publisher, events of the characters, alliances
+// and chat channels go to their private topics. Handlers are never blocked by the slow publisher: if the events queue is full
+// the event is dropped and clients receive it through the replay
+func (s *SimpleLogic) publishEvent(event model.EventWrapper) {
+	event.Topic = s.topicTokens.Private(event.Topic)
+	s.eventLog.Append(event, func(event model.EventWrapper) {
+		select {
+		case s.EventsChan <- event:
+		default:
+			s.log.WithField("topic", event.Topic).
+				WithField("sequence", event.Event.Sequence).
+				Warn("Events queue is full, event is available only through the replay")
+		}
+	})
+}
+
+// eventTopics - returns all topics which events should be delivered to the session
+func (s *SimpleLogic) eventTopics(session *PlayerSession) []string {
+	topics := []string{consts.GlobalTopic, session.EventTopic}
+	if session.SelectedCharacter != nil {
+		topics = append(topics, model.CharacterTopic(session.SelectedCharacter.ID))
+		if session.SelectedCharacter.AllianceID != 0 {
+			topics = append(topics, model.AllianceTopic(session.SelectedCharacter.AllianceID))
+		}
+		topics = append(topics, s.chatChannelTopics(session)...)
+	}
+
+	for i, topic := range topics {
+		topics[i] = s.topicTokens.Private(topic)
+	}
+
+	return topics
+}
+
 func (s *SimpleLogic) SelectCharacter(session *PlayerSession, request *rpc.SelectCharacterRequest) (*rpc.SelectCharacterResponse, model.Error) {
 	s.log.WithField("characterID", request.GetCharacterID()).
 		WithField("sessionID", request.GetSessionID()).
@@ -100,14 +463,30 @@ func (s *SimpleLogic) SelectCharacter(session *PlayerSession, request *rpc.Selec
 	}
 
 	session.SelectedCharacter = &char
+	if err := s.updateChatChannels(session); err != nil {
+		s.log.WithError(err).Error("Failed to get character's chat channels")
+		return nil, model.ErrInternalServerError
+	}
+
 	s.log.WithFields(logrus.Fields{
 		"sessionID": request.GetSessionID(),
 		"character": char,
 	}).Info("User selected character")
 
-	s.EventsChan <- model.NewSystemChatMessageEvent(consts.MessageCharacterAuthorized(char.Name))
+	if err := s.sendSystemChatMessage(consts.MessageCharacterAuthorized(char.Name), tx); err != nil {
+		s.log.WithError(err).Error("Failed to send system chat message")
+		return nil, model.ErrInternalServerError
+	}
 
-	response := &rpc.SelectCharacterResponse{Resources: char.Resources.ToRPC()}
+	s.publishEvent(model.NewCharacterStatusEvent(char.Name, true))
+
+	response := &rpc.SelectCharacterResponse{
+		Resources:   char.Resources.ToRPC(),
+		EventTopics: s.eventTopics(session),
+		Gold:        char.Resources[model.ResourceGold],
+		TaxRate:     char.TaxRate,
+		Happiness:   char.Happiness(),
+	}
 	for _, town := range char.Towns {
 		response.Towns = append(response.Towns, town.ToRPC())
 	}
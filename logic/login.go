@@ -52,8 +52,9 @@ func (s *SimpleLogic) Login(request *rpc.LoginRequest) (*rpc.LoginResponse, mode
 	}
 
 	session := NewPlayerSession(acc.ID)
+	session.IsModerator = acc.IsModerator
 
-	s.sessions[session.SessionID] = session
+	s.addSession(session)
 
 	s.log.WithFields(log.Fields{
 		"accID":     acc.ID,
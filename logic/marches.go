@@ -0,0 +1,185 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+)
+
+// marchTravelTime - time needed for the army to reach the destination along the straight line.
+// Every tile of the way takes the army's tile travel time, multiplied for the slopes and the water tiles
+func (s *SimpleLogic) marchTravelTime(
+	from, to geometry.Point, army model.Army, session *PlayerSession) (time.Duration, model.Error) {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	steps := int64(math.Max(math.Abs(float64(dx)), math.Abs(float64(dy))))
+
+	heights := s.newHeightMap(session)
+	previous := from
+	previousHeight, err := heights.heightAt(from)
+	if err != nil {
+		return 0, err
+	}
+
+	tiles := 0.0
+	for i := int64(1); i <= steps; i++ {
+		tile := geometry.Point{
+			X: from.X + int64(math.Round(float64(dx*i)/float64(steps))),
+			Y: from.Y + int64(math.Round(float64(dy*i)/float64(steps))),
+		}
+
+		height, err := heights.heightAt(tile)
+		if err != nil {
+			return 0, err
+		}
+
+		factor := 1 + math.Abs(float64(height-previousHeight))*consts.MarchSlopeFactor
+		if height < s.config.WaterLevel {
+			factor *= consts.MarchWaterFactor
+		}
+
+		tiles += geometry.Distance(previous, tile) * factor
+		previous, previousHeight = tile, height
+	}
+
+	return time.Duration(tiles * float64(army.TileTravelTime())), nil
+}
+
+// marchDestination - returns the location of the destination and the target town (empty for the world tile)
+func (s *SimpleLogic) marchDestination(
+	destination *rpc.MarchDestination, session *PlayerSession) (geometry.Point, model.Town, model.Error) {
+	if destination == nil {
+		return geometry.Point{}, model.Town{}, model.ErrBadRequest
+	}
+
+	if destination.TownID == 0 {
+		return geometry.Point{X: destination.X, Y: destination.Y}, model.Town{}, nil
+	}
+
+	session.Tx.SetAutoRollBack(false)
+	town, err := session.Tx.GetTown(destination.TownID)
+	session.Tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return geometry.Point{}, town, model.ErrTownNotFound
+	} else if err != nil {
+		s.log.WithError(err).Error("Failed to get town")
+		return geometry.Point{}, town, model.ErrInternalServerError
+	}
+
+	return town.Location(), town, nil
+}
+
+// checkAttack - towns of the protected characters and of the characters bound by the treaty can't be attacked.
+// Returns true if the army is sent to the hostile town
+func (s *SimpleLogic) checkAttack(town model.Town, session *PlayerSession) (bool, model.Error) {
+	character := session.SelectedCharacter
+	if town.ID == 0 || !town.IsHostileTo(character.Name, character.AllianceTag) {
+		return false, nil
+	}
+
+	defender, err := session.Tx.GetCharacterByName(town.OwnerName)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get town owner")
+		return false, model.ErrInternalServerError
+	}
+
+	state, err := s.characterRelation(character, &defender, session.Tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get diplomacy relation")
+		return false, model.ErrInternalServerError
+	}
+
+	if !state.CanAttack() {
+		return false, model.ErrTreatyViolation
+	}
+
+	if err := defender.ProtectionError(time.Now()); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// dropAttackerProtection - the attacker loses its own protection once its army is on the way to the hostile town
+func (s *SimpleLogic) dropAttackerProtection(session *PlayerSession) model.Error {
+	character := session.SelectedCharacter
+	if !character.IsProtected(time.Now()) {
+		return nil
+	}
+
+	updated := *character
+	updated.DropProtection()
+
+	if err := session.Tx.UpdateCharacter(updated); err != nil {
+		s.log.WithError(err).Error("Failed to update character")
+		return model.ErrInternalServerError
+	}
+
+	character.DropProtection()
+	return nil
+}
+
+// departMarch - starts the new leg of the march from the location to the destination.
+// Returns true if the army attacks, the attacker's protection should be dropped once the march is saved
+func (s *SimpleLogic) departMarch(march *model.March, from geometry.Point,
+	destination *rpc.MarchDestination, session *PlayerSession) (bool, model.Error) {
+	to, town, err := s.marchDestination(destination, session)
+	if err != nil {
+		return false, err
+	}
+
+	if geometry.Distance(from, to) > float64(s.config.MarchMaxDistance) {
+		return false, model.ErrDestinationTooFar
+	}
+
+	travelTime, err := s.marchTravelTime(from, to, march.Units, session)
+	if err != nil {
+		return false, err
+	}
+
+	attack, err := s.checkAttack(town, session)
+	if err != nil {
+		return false, err
+	}
+
+	march.Depart(from, to, town.ID, time.Now(), travelTime)
+	return attack, nil
+}
+
+func (s *SimpleLogic) getCharacterMarch(session *PlayerSession, id int64) (model.March, model.Error) {
+	session.Tx.SetAutoRollBack(false)
+	march, err := session.Tx.GetMarch(id)
+	session.Tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return march, model.ErrMarchNotFound
+	} else if err != nil {
+		s.log.WithError(err).Error("Failed to get march")
+		return march, model.ErrInternalServerError
+	}
+
+	if march.CharacterID != session.SelectedCharacter.ID {
+		return march, model.ErrMarchNotFound
+	}
+
+	return march, nil
+}
+
+// marchTopics - the owner and the players with towns in the region of the army observe the march
+func (s *SimpleLogic) marchTopics(march model.March, now time.Time) []string {
+	return []string{
+		model.CharacterTopic(march.CharacterID),
+		model.RegionChatChannel(s.regionOf(march.Position(now))).Topic(),
+	}
+}
+
+func (s *SimpleLogic) publishMarchPosition(march model.March, now time.Time) {
+	for _, topic := range s.marchTopics(march, now) {
+		s.publishEvent(model.NewArmyPositionEvent(topic, march, now))
+	}
+}
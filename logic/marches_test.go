@@ -0,0 +1,215 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// mockMarchTerrain - every chunk is filled with the same height
+func mockMarchTerrain(logic *SimpleLogic, db *DatabaseTransactionMock, height float32) {
+	logic.config.ChunkSize = 2
+	logic.config.WaterLevel = 0.1
+
+	chunk, _ := model.NewWorldMapChunkFromRPC(rpc.WorldMapChunk{Data: []float32{height, height, height, height}})
+	db.On("GetMapChunk", mock.Anything, mock.Anything).Return(chunk, nil)
+}
+
+func TestMarch_Position(t *testing.T) {
+	departed := time.Now()
+	march := model.March{}
+	march.Depart(geometry.Point{X: 0, Y: 0}, geometry.Point{X: 10, Y: -4}, 0, departed, 10*time.Second)
+
+	require.Equal(t, geometry.Point{X: 0, Y: 0}, march.Position(departed.Add(-time.Second)))
+	require.Equal(t, geometry.Point{X: 5, Y: -2}, march.Position(departed.Add(5*time.Second)))
+	require.Equal(t, geometry.Point{X: 10, Y: -4}, march.Position(departed.Add(time.Minute)))
+
+	march.Status = model.MarchCamped
+	require.Equal(t, geometry.Point{X: 10, Y: -4}, march.Position(departed))
+}
+
+func TestSimpleLogic_MarchTravelTime(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockMarchTerrain(logic, db, 1)
+
+	army := model.Army{rpc.UnitType_MILITIA: 1, rpc.UnitType_CAVALRY: 1}
+	travelTime, err := logic.marchTravelTime(geometry.Point{}, geometry.Point{X: 0, Y: 5}, army, session)
+	require.NoError(t, err)
+	require.Equal(t, 5*model.Units[rpc.UnitType_MILITIA].TileTravelTime, travelTime)
+
+	// 6 tiles of the way lie on 3 chunks, every chunk is loaded once
+	db.AssertNumberOfCalls(t, "GetMapChunk", 3)
+
+	// cavalry alone moves faster, water slows it down
+	logic, db, session = NewLogicMock()
+	mockMarchTerrain(logic, db, 0)
+
+	army = model.Army{rpc.UnitType_CAVALRY: 1}
+	travelTime, err = logic.marchTravelTime(geometry.Point{}, geometry.Point{X: 3, Y: 0}, army, session)
+	require.NoError(t, err)
+	require.Equal(t, 9*model.Units[rpc.UnitType_CAVALRY].TileTravelTime, travelTime)
+}
+
+func TestSimpleLogic_SendArmy(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockMarchTerrain(logic, db, 1)
+	session.SelectedCharacter = &model.Character{
+		ID:    1,
+		Name:  "test",
+		Towns: []model.Town{{ID: 1, X: 0, Y: 0}},
+		Army:  model.Army{rpc.UnitType_CAVALRY: 3},
+	}
+
+	units := model.Army{rpc.UnitType_CAVALRY: 2}
+
+	db.On("GetCharacterArmy", "test").Return(map[int64]model.Army{1: {rpc.UnitType_CAVALRY: 3}}, nil)
+	db.On("RemoveTownUnits", int64(1), units).Return(nil)
+	db.On("AddMarch", mock.MatchedBy(func(march model.March) bool {
+		return march.HomeTownID == 1 && march.TargetTownID == 0 &&
+			march.To() == geometry.Point{X: 4, Y: 0} &&
+			march.ArrivesAt.Sub(march.DepartedAt) == 4*model.Units[rpc.UnitType_CAVALRY].TileTravelTime
+	})).Return(int64(7), nil)
+
+	resp, err := logic.SendArmy(session, &rpc.SendArmyRequest{
+		TownID:      1,
+		Units:       units.ToRPC(),
+		Destination: &rpc.MarchDestination{X: 4, Y: 0},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), resp.March.Id)
+	require.Equal(t, rpc.MarchStatus_MOVING, resp.March.Status)
+	require.Equal(t, model.Army{rpc.UnitType_CAVALRY: 3}, session.SelectedCharacter.Army)
+
+	require.Equal(t, 2, len(logic.EventsChan))
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), event.Topic)
+	require.Equal(t, int64(7), event.Event.GetArmyPositionEvent().March.Id)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_SendArmy_Errors(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockMarchTerrain(logic, db, 1)
+	session.SelectedCharacter = &model.Character{
+		ID:    1,
+		Name:  "test",
+		Towns: []model.Town{{ID: 1}},
+	}
+
+	db.On("GetCharacterArmy", "test").Return(map[int64]model.Army{1: {rpc.UnitType_MILITIA: 1}}, nil)
+	db.On("GetTown", int64(5)).Return(model.Town{}, sql.ErrNoRows)
+
+	militia := model.Army{rpc.UnitType_MILITIA: 1}.ToRPC()
+
+	_, err := logic.SendArmy(session, &rpc.SendArmyRequest{TownID: 1, Destination: &rpc.MarchDestination{X: 1}})
+	require.Equal(t, model.ErrBadRequest, err)
+
+	_, err = logic.SendArmy(session, &rpc.SendArmyRequest{TownID: 2, Units: militia, Destination: &rpc.MarchDestination{X: 1}})
+	require.Equal(t, model.ErrTownNotFound, err)
+
+	_, err = logic.SendArmy(session, &rpc.SendArmyRequest{
+		TownID:      1,
+		Units:       model.Army{rpc.UnitType_MILITIA: 2}.ToRPC(),
+		Destination: &rpc.MarchDestination{X: 1},
+	})
+	require.Equal(t, model.ErrNotEnoughUnits, err)
+
+	_, err = logic.SendArmy(session, &rpc.SendArmyRequest{TownID: 1, Units: militia})
+	require.Equal(t, model.ErrBadRequest, err)
+
+	// only towns can be captured
+	_, err = logic.SendArmy(session, &rpc.SendArmyRequest{
+		TownID: 1, Units: militia, Destination: &rpc.MarchDestination{X: 1}, Capture: true,
+	})
+	require.Equal(t, model.ErrBadRequest, err)
+
+	_, err = logic.SendArmy(session, &rpc.SendArmyRequest{TownID: 1, Units: militia, Destination: &rpc.MarchDestination{TownID: 5}})
+	require.Equal(t, model.ErrTownNotFound, err)
+
+	_, err = logic.SendArmy(session, &rpc.SendArmyRequest{TownID: 1, Units: militia, Destination: &rpc.MarchDestination{X: 501}})
+	require.Equal(t, model.ErrDestinationTooFar, err)
+}
+
+func TestSimpleLogic_RecallArmy(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockMarchTerrain(logic, db, 1)
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	march := model.March{ID: 3, CharacterID: 1, CharacterName: "test", HomeTownID: 1, Units: model.Army{rpc.UnitType_CAVALRY: 1}}
+	march.Depart(geometry.Point{X: 0, Y: 0}, geometry.Point{X: 6, Y: 0}, 0, time.Now().Add(-time.Hour), time.Minute)
+	march.Status = model.MarchCamped
+
+	db.On("GetMarch", int64(3)).Return(march, nil)
+	db.On("GetMarch", int64(4)).Return(model.March{ID: 4, CharacterID: 2}, nil)
+	db.On("GetTown", int64(1)).Return(model.Town{ID: 1, X: 0, Y: 0, OwnerName: "test"}, nil)
+	db.On("UpdateMarch", mock.MatchedBy(func(march model.March) bool {
+		return march.Status == model.MarchMoving && march.TargetTownID == 1 &&
+			march.From() == geometry.Point{X: 6, Y: 0} && march.To() == geometry.Point{X: 0, Y: 0}
+	})).Return(nil)
+
+	resp, err := logic.RecallArmy(session, &rpc.RecallArmyRequest{MarchID: 3})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.March.TargetTownID)
+
+	_, err = logic.RecallArmy(session, &rpc.RecallArmyRequest{MarchID: 4})
+	require.Equal(t, model.ErrMarchNotFound, err)
+
+	db.AssertExpectations(t)
+}
+
+func TestArmyManager_ArriveMarches(t *testing.T) {
+	logic, db, _ := NewLogicMock()
+	logic.config.ChunkSize = 2
+
+	now := time.Now()
+	units := model.Army{rpc.UnitType_ARCHER: 4}
+	home := model.March{ID: 1, CharacterID: 1, CharacterName: "test", TargetTownID: 1, Units: units}
+	foreign := model.March{ID: 2, CharacterID: 1, CharacterName: "test", TargetTownID: 2, Units: units}
+	tile := model.March{ID: 3, CharacterID: 1, CharacterName: "test", Units: units}
+
+	db.On("GetArrivedMarches", now, marchArrivalBatchSize).Return([]model.March{home, foreign, tile}, nil)
+	db.On("GetTown", int64(1)).Return(model.Town{ID: 1, OwnerName: "test"}, nil)
+	// armies don't fight for the towns of the alliance members
+	db.On("GetTown", int64(2)).Return(model.Town{ID: 2, OwnerName: "ally", AllianceTag: "NRD"}, nil)
+	db.On("GetCharacterByName", "test").Return(model.Character{ID: 1, Name: "test", AllianceID: 3, AllianceTag: "NRD"}, nil)
+	db.On("GetCharacterByName", "ally").Return(model.Character{ID: 2, Name: "ally", AllianceID: 3, AllianceTag: "NRD"}, nil)
+	db.On("AddTownUnits", int64(1), units).Return(nil)
+	db.On("DeleteMarch", int64(1)).Return(nil)
+	db.On("UpdateMarch", mock.MatchedBy(func(march model.March) bool {
+		return march.Status == model.MarchCamped
+	})).Return(nil).Twice()
+
+	var after afterCommit
+	require.NoError(t, NewArmyManager(logic).arriveMarches(now, db, &after))
+	db.AssertExpectations(t)
+
+	after.run()
+
+	require.Equal(t, 6, len(logic.EventsChan))
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), event.Topic)
+	require.True(t, event.Event.GetArmyArrivedEvent().Stationed)
+
+	<-logic.EventsChan
+	event = <-logic.EventsChan
+	require.False(t, event.Event.GetArmyArrivedEvent().Stationed)
+	require.Equal(t, rpc.MarchStatus_CAMPED, event.Event.GetArmyArrivedEvent().March.Status)
+}
+
+func TestSimpleLogic_GetMarches(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	db.On("GetCharacterMarches", int64(1)).Return([]model.March{{ID: 1}, {ID: 2}}, nil)
+
+	resp, err := logic.GetMarches(session, &rpc.GetMarchesRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Marches))
+	require.Equal(t, int64(2), resp.Marches[1].Id)
+}
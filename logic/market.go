@@ -0,0 +1,107 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Number of the opposite orders loaded at once while matching the order
+	marketMatchBatchSize = 20
+)
+
+// matchMarketOrder - fills the order by the opposite orders of other characters,
+// the best prices and then the oldest orders go first. Trade price is the price of the resting order
+func (s *SimpleLogic) matchMarketOrder(session *PlayerSession, order *model.MarketOrder, resources model.Resources) error {
+	for order.Remaining > 0 {
+		matches, err := session.Tx.GetMatchingMarketOrders(*order, marketMatchBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get matching orders: %w", err)
+		}
+
+		for i := range matches {
+			if order.Remaining == 0 {
+				break
+			}
+
+			if err := s.fillMarketOrders(session, order, &matches[i], resources); err != nil {
+				return err
+			}
+		}
+
+		if len(matches) < marketMatchBatchSize {
+			break
+		}
+	}
+
+	return nil
+}
+
+// fillMarketOrders - trades the max possible amount between the session character's order and the resting order.
+// Buyer receives the resource and the difference between its escrow and the trade price,
+// seller receives the price resource. The taker's share is added to the session character resources,
+// the events are published once the request is committed
+func (s *SimpleLogic) fillMarketOrders(
+	session *PlayerSession, taker *model.MarketOrder, maker *model.MarketOrder, resources model.Resources) error {
+	amount := taker.Remaining
+	if maker.Remaining < amount {
+		amount = maker.Remaining
+	}
+
+	price := maker.Price
+	taker.Fill(amount)
+	maker.Fill(amount)
+
+	if err := session.Tx.UpdateMarketOrder(*maker); err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
+	buyer, seller := taker, maker
+	if taker.Side == model.MarketOrderSell {
+		buyer, seller = maker, taker
+	}
+
+	buyerGets := model.NewResources(buyer.Resource, amount)
+	buyerGets.Add(model.NewResources(buyer.PriceResource, amount*(buyer.Price-price)))
+	sellerGets := model.NewResources(seller.PriceResource, amount*price)
+
+	takerGets, makerGets := buyerGets, sellerGets
+	if taker == seller {
+		takerGets, makerGets = sellerGets, buyerGets
+	}
+
+	resources.Add(takerGets)
+	if err := s.returnCharacterResources(session, maker.CharacterName, makerGets, session.Tx, &session.AfterCommit); err != nil {
+		return fmt.Errorf("failed to give resources to %s: %w", maker.CharacterName, err)
+	}
+
+	takerFilled, makerFilled := *taker, *maker
+	session.AfterCommit.add(func() {
+		s.publishEvent(model.NewMarketOrderFilledEvent(takerFilled, amount, price))
+		s.publishEvent(model.NewMarketOrderFilledEvent(makerFilled, amount, price))
+	})
+
+	return nil
+}
+
+// getCharacterMarketOrder - returns the order of the session character
+func (s *SimpleLogic) getCharacterMarketOrder(session *PlayerSession, id int64) (model.MarketOrder, model.Error) {
+	session.Tx.SetAutoRollBack(false)
+	order, err := session.Tx.GetMarketOrder(id)
+	session.Tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return order, model.ErrMarketOrderNotFound
+	} else if err != nil {
+		s.log.WithError(err).Error("Failed to get market order")
+		return order, model.ErrInternalServerError
+	}
+
+	if order.CharacterID != session.SelectedCharacter.ID {
+		return order, model.ErrForbidden
+	}
+
+	return order, nil
+}
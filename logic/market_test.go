@@ -0,0 +1,175 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMarketOrder_Escrow(t *testing.T) {
+	sell := model.MarketOrder{Side: model.MarketOrderSell, Resource: model.ResourceWood,
+		PriceResource: model.ResourceStone, Price: 3}
+	buy := model.MarketOrder{Side: model.MarketOrderBuy, Resource: model.ResourceWood,
+		PriceResource: model.ResourceStone, Price: 3}
+
+	require.Equal(t, model.Resources{model.ResourceWood: 10}, sell.Escrow(10))
+	require.Equal(t, model.Resources{model.ResourceStone: 30}, buy.Escrow(10))
+}
+
+func TestSimpleLogic_PlaceMarketOrder_NotEnoughResources(t *testing.T) {
+	logic, _, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "buyer", Resources: model.Resources{model.ResourceStone: 10}}
+
+	_, err := logic.PlaceMarketOrder(session, &rpc.PlaceMarketOrderRequest{
+		Side:          rpc.MarketOrderSide_BUY,
+		Resource:      "wood",
+		PriceResource: "stone",
+		Price:         2,
+		Amount:        10,
+	})
+	require.Equal(t, model.ErrNotEnoughResources, err)
+	require.Equal(t, uint64(10), session.SelectedCharacter.Resources[model.ResourceStone])
+}
+
+func TestSimpleLogic_PlaceMarketOrder_BadRequest(t *testing.T) {
+	logic, _, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "buyer"}
+
+	_, err := logic.PlaceMarketOrder(session, &rpc.PlaceMarketOrderRequest{
+		Side:          rpc.MarketOrderSide_BUY,
+		Resource:      "wood",
+		PriceResource: "wood",
+		Price:         1,
+		Amount:        1,
+	})
+	require.Equal(t, model.ErrBadRequest, err)
+}
+
+func TestSimpleLogic_PlaceMarketOrder_PartialFill(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "buyer", Resources: model.Resources{model.ResourceStone: 50},
+		StorageCapacity: model.StorageCapacity(nil)}
+
+	maker := model.MarketOrder{ID: 7, CharacterID: 2, CharacterName: "seller", Side: model.MarketOrderSell,
+		Resource: model.ResourceWood, PriceResource: model.ResourceStone, Price: 3, Amount: 4, Remaining: 4,
+		Status: model.MarketOrderOpen}
+
+	db.On("AddMarketOrder", mock.Anything).Return(int64(8), nil)
+	db.On("GetMatchingMarketOrders", mock.Anything, marketMatchBatchSize).Return([]model.MarketOrder{maker}, nil)
+	db.On("UpdateMarketOrder", mock.MatchedBy(func(order model.MarketOrder) bool {
+		return order.ID == 7 && order.Remaining == 0 && order.Status == model.MarketOrderFilled
+	})).Return(nil)
+	db.On("UpdateMarketOrder", mock.MatchedBy(func(order model.MarketOrder) bool {
+		return order.ID == 8 && order.Remaining == 6 && order.Status == model.MarketOrderOpen
+	})).Return(nil)
+	db.On("AddCharacterResources", "seller", model.Resources{model.ResourceStone: 12}, model.Resources(nil)).Return(nil)
+	db.On("AddOrUpdateResources", mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := logic.PlaceMarketOrder(session, &rpc.PlaceMarketOrderRequest{
+		Side:          rpc.MarketOrderSide_BUY,
+		Resource:      "wood",
+		PriceResource: "stone",
+		Price:         5,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), resp.Order.Remaining)
+	require.Equal(t, rpc.MarketOrderStatus_OPEN, resp.Order.Status)
+
+	// the session character gets its share only after the commit
+	require.Equal(t, uint64(50), session.SelectedCharacter.Resources[model.ResourceStone])
+	require.Equal(t, 0, len(logic.EventsChan))
+	session.AfterCommit.run()
+
+	// 50 - 10 * 5 escrowed + (5 - 3) * 4 refunded
+	require.Equal(t, uint64(8), session.SelectedCharacter.Resources[model.ResourceStone])
+	require.Equal(t, uint64(4), session.SelectedCharacter.Resources[model.ResourceWood])
+
+	require.Equal(t, 3, len(logic.EventsChan))
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), (<-logic.EventsChan).Topic)
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(2)), (<-logic.EventsChan).Topic)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_PlaceMarketOrder_FillFailed(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "buyer", Resources: model.Resources{model.ResourceStone: 50}}
+
+	seller := NewPlayerSession(2)
+	seller.SelectedCharacter = &model.Character{ID: 2, Name: "seller", Resources: model.Resources{}}
+	logic.addSession(seller)
+
+	first := model.MarketOrder{ID: 7, CharacterID: 2, CharacterName: "seller", Side: model.MarketOrderSell,
+		Resource: model.ResourceWood, PriceResource: model.ResourceStone, Price: 3, Amount: 4, Remaining: 4,
+		Status: model.MarketOrderOpen}
+	second := first
+	second.ID = 9
+
+	db.On("AddMarketOrder", mock.Anything).Return(int64(8), nil)
+	db.On("GetMatchingMarketOrders", mock.Anything, marketMatchBatchSize).Return(
+		[]model.MarketOrder{first, second}, nil)
+	db.On("UpdateMarketOrder", mock.MatchedBy(func(order model.MarketOrder) bool {
+		return order.ID == 7
+	})).Return(nil)
+	db.On("UpdateMarketOrder", mock.MatchedBy(func(order model.MarketOrder) bool {
+		return order.ID == 9
+	})).Return(errors.New("db error"))
+	db.On("AddCharacterResources", "seller", model.Resources{model.ResourceStone: 12}, model.Resources(nil)).Return(nil)
+
+	_, err := logic.PlaceMarketOrder(session, &rpc.PlaceMarketOrderRequest{
+		Side:          rpc.MarketOrderSide_BUY,
+		Resource:      "wood",
+		PriceResource: "stone",
+		Price:         5,
+		Amount:        10,
+	})
+	require.Equal(t, model.ErrInternalServerError, err)
+
+	// the transaction is rolled back, so neither the buyer nor the seller of the first fill get anything
+	require.Equal(t, model.Resources{model.ResourceStone: 50}, session.SelectedCharacter.Resources)
+	require.Equal(t, model.Resources{}, seller.SelectedCharacter.Resources)
+	require.Equal(t, 0, len(logic.EventsChan))
+}
+
+func TestSimpleLogic_CancelMarketOrder(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "seller",
+		Resources:       model.Resources{model.ResourceFood: model.BaseStorageCapacity},
+		StorageCapacity: model.StorageCapacity(nil)}
+
+	db.On("GetMarketOrder", int64(3)).Return(model.MarketOrder{ID: 3, CharacterID: 1, Side: model.MarketOrderSell,
+		Resource: model.ResourceFood, PriceResource: model.ResourceStone, Price: 2, Amount: 10, Remaining: 6,
+		Status: model.MarketOrderOpen}, nil)
+	db.On("UpdateMarketOrder", mock.MatchedBy(func(order model.MarketOrder) bool {
+		return order.Status == model.MarketOrderCancelled
+	})).Return(nil)
+	db.On("AddOrUpdateResources", mock.Anything, model.Resources{model.ResourceFood: model.BaseStorageCapacity + 6}).Return(nil)
+
+	resp, err := logic.CancelMarketOrder(session, &rpc.CancelMarketOrderRequest{OrderID: 3})
+	require.NoError(t, err)
+	require.Equal(t, rpc.MarketOrderStatus_CANCELLED, resp.Order.Status)
+
+	session.AfterCommit.run()
+	require.Equal(t, uint64(model.BaseStorageCapacity+6), session.SelectedCharacter.Resources[model.ResourceFood])
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_CancelMarketOrder_Errors(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "seller"}
+
+	db.On("GetMarketOrder", int64(1)).Return(model.MarketOrder{}, sql.ErrNoRows)
+	db.On("GetMarketOrder", int64(2)).Return(model.MarketOrder{ID: 2, CharacterID: 5}, nil)
+
+	_, err := logic.CancelMarketOrder(session, &rpc.CancelMarketOrderRequest{OrderID: 1})
+	require.Equal(t, model.ErrMarketOrderNotFound, err)
+
+	_, err = logic.CancelMarketOrder(session, &rpc.CancelMarketOrderRequest{OrderID: 2})
+	require.Equal(t, model.ErrForbidden, err)
+}
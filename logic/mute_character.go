@@ -0,0 +1,64 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+func (s *SimpleLogic) MuteCharacter(session *PlayerSession, request *rpc.MuteCharacterRequest) (*rpc.MuteCharacterResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"character": request.Character,
+		"duration":  request.Duration,
+		"reason":    request.Reason,
+	}).Info("MuteCharacter")
+
+	if !session.IsModerator {
+		return nil, model.ErrForbidden
+	}
+
+	if request.Duration <= 0 || request.Duration > consts.ChatMuteMaxDuration {
+		return nil, model.ErrBadRequest
+	}
+
+	target, err := s.findCharacter(request.Character, session.Tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get character")
+		return nil, model.ErrInternalServerError
+	}
+
+	if target == nil {
+		return nil, model.ErrCharacterNotFound
+	}
+
+	mute := model.ChatMute{
+		CharacterName: target.Name,
+		ModeratorName: session.SelectedCharacter.Name,
+		Reason:        request.Reason,
+		ExpiresAt:     time.Now().Add(time.Duration(request.Duration) * time.Second),
+	}
+
+	if err := session.Tx.AddChatMute(mute); err != nil {
+		s.log.WithError(err).Error("Failed to add chat mute")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := s.logModeration(session, model.ModerationLogEntry{
+		ModeratorName: mute.ModeratorName,
+		Action:        model.ModerationActionMute,
+		TargetName:    mute.CharacterName,
+		Reason:        mute.Reason,
+	}); err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(model.NewPrivateSystemChatMessageEvent(
+		model.CharacterTopic(target.ID), consts.MessageMuted(mute.ExpiresAt, mute.Reason)))
+
+	return &rpc.MuteCharacterResponse{
+		ExpiresAt: mute.ExpiresAt.Unix(),
+	}, nil
+}
@@ -25,8 +25,12 @@ func NewPacketHandler(logic *SimpleLogic) PacketHandler {
 
 type handleFunc func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error)
 
+// multipartHandleFunc - handles requests which answer consists of several responses
+type multipartHandleFunc func(s *PlayerSession, r rpc.Request) ([]rpc.Response, model.Error)
+
 type requestHandler struct {
 	handleFunc            handleFunc
+	multipartHandleFunc   multipartHandleFunc
 	authorizationRequired bool
 	characterRequired     bool
 }
@@ -147,6 +151,378 @@ func (p *PacketHandler) getHandleFunc(request rpc.Request) *requestHandler {
 				},
 			}, err
 		}
+	} else if request.GetGetTileOwnerRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetTileOwner(s, r.GetGetTileOwnerRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetTileOwnerResponse{
+					GetTileOwnerResponse: response,
+				},
+			}, err
+		}
+
+		handler.characterRequired = false
+	} else if request.GetGetEventsSinceRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetEventsSince(s, r.GetGetEventsSinceRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetEventsSinceResponse{
+					GetEventsSinceResponse: response,
+				},
+			}, err
+		}
+
+		handler.characterRequired = false
+	} else if request.GetSendDirectMessageRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.SendDirectMessage(s, r.GetSendDirectMessageRequest())
+			return rpc.Response{
+				Data: &rpc.Response_SendDirectMessageResponse{
+					SendDirectMessageResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetConversationsRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetConversations(s, r.GetGetConversationsRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetConversationsResponse{
+					GetConversationsResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetDirectMessagesRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetDirectMessages(s, r.GetGetDirectMessagesRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetDirectMessagesResponse{
+					GetDirectMessagesResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetMuteCharacterRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.MuteCharacter(s, r.GetMuteCharacterRequest())
+			return rpc.Response{
+				Data: &rpc.Response_MuteCharacterResponse{
+					MuteCharacterResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetUnmuteCharacterRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.UnmuteCharacter(s, r.GetUnmuteCharacterRequest())
+			return rpc.Response{
+				Data: &rpc.Response_UnmuteCharacterResponse{
+					UnmuteCharacterResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetModerationLogRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetModerationLog(s, r.GetGetModerationLogRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetModerationLogResponse{
+					GetModerationLogResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetCreateAllianceRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.CreateAlliance(s, r.GetCreateAllianceRequest())
+			return rpc.Response{
+				Data: &rpc.Response_CreateAllianceResponse{
+					CreateAllianceResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetDisbandAllianceRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.DisbandAlliance(s, r.GetDisbandAllianceRequest())
+			return rpc.Response{
+				Data: &rpc.Response_DisbandAllianceResponse{
+					DisbandAllianceResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetInviteToAllianceRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.InviteToAlliance(s, r.GetInviteToAllianceRequest())
+			return rpc.Response{
+				Data: &rpc.Response_InviteToAllianceResponse{
+					InviteToAllianceResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetAcceptAllianceInviteRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.AcceptAllianceInvite(s, r.GetAcceptAllianceInviteRequest())
+			return rpc.Response{
+				Data: &rpc.Response_AcceptAllianceInviteResponse{
+					AcceptAllianceInviteResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetLeaveAllianceRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.LeaveAlliance(s, r.GetLeaveAllianceRequest())
+			return rpc.Response{
+				Data: &rpc.Response_LeaveAllianceResponse{
+					LeaveAllianceResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetKickFromAllianceRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.KickFromAlliance(s, r.GetKickFromAllianceRequest())
+			return rpc.Response{
+				Data: &rpc.Response_KickFromAllianceResponse{
+					KickFromAllianceResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetSetAllianceRankRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.SetAllianceRank(s, r.GetSetAllianceRankRequest())
+			return rpc.Response{
+				Data: &rpc.Response_SetAllianceRankResponse{
+					SetAllianceRankResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetAllianceMembersRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetAllianceMembers(s, r.GetGetAllianceMembersRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetAllianceMembersResponse{
+					GetAllianceMembersResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetProposeDiplomacyRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.ProposeDiplomacy(s, r.GetProposeDiplomacyRequest())
+			return rpc.Response{
+				Data: &rpc.Response_ProposeDiplomacyResponse{
+					ProposeDiplomacyResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetAcceptDiplomacyProposalRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.AcceptDiplomacyProposal(s, r.GetAcceptDiplomacyProposalRequest())
+			return rpc.Response{
+				Data: &rpc.Response_AcceptDiplomacyProposalResponse{
+					AcceptDiplomacyProposalResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetRejectDiplomacyProposalRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.RejectDiplomacyProposal(s, r.GetRejectDiplomacyProposalRequest())
+			return rpc.Response{
+				Data: &rpc.Response_RejectDiplomacyProposalResponse{
+					RejectDiplomacyProposalResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetDiplomacyRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetDiplomacy(s, r.GetGetDiplomacyRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetDiplomacyResponse{
+					GetDiplomacyResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetPlaceMarketOrderRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.PlaceMarketOrder(s, r.GetPlaceMarketOrderRequest())
+			return rpc.Response{
+				Data: &rpc.Response_PlaceMarketOrderResponse{
+					PlaceMarketOrderResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetCancelMarketOrderRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.CancelMarketOrder(s, r.GetCancelMarketOrderRequest())
+			return rpc.Response{
+				Data: &rpc.Response_CancelMarketOrderResponse{
+					CancelMarketOrderResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetOrderBookRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetOrderBook(s, r.GetGetOrderBookRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetOrderBookResponse{
+					GetOrderBookResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetMarketOrdersRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetMarketOrders(s, r.GetGetMarketOrdersRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetMarketOrdersResponse{
+					GetMarketOrdersResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetSendCaravanRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.SendCaravan(s, r.GetSendCaravanRequest())
+			return rpc.Response{
+				Data: &rpc.Response_SendCaravanResponse{
+					SendCaravanResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetCaravansRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetCaravans(s, r.GetGetCaravansRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetCaravansResponse{
+					GetCaravansResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetResourceTypesRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetResourceTypes(s, r.GetGetResourceTypesRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetResourceTypesResponse{
+					GetResourceTypesResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetSetTaxRateRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.SetTaxRate(s, r.GetSetTaxRateRequest())
+			return rpc.Response{
+				Data: &rpc.Response_SetTaxRateResponse{
+					SetTaxRateResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetTechsRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetTechs(s, r.GetGetTechsRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetTechsResponse{
+					GetTechsResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetStartResearchRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.StartResearch(s, r.GetStartResearchRequest())
+			return rpc.Response{
+				Data: &rpc.Response_StartResearchResponse{
+					StartResearchResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetCancelResearchRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.CancelResearch(s, r.GetCancelResearchRequest())
+			return rpc.Response{
+				Data: &rpc.Response_CancelResearchResponse{
+					CancelResearchResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetTrainUnitsRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.TrainUnits(s, r.GetTrainUnitsRequest())
+			return rpc.Response{
+				Data: &rpc.Response_TrainUnitsResponse{
+					TrainUnitsResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetArmyRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetArmy(s, r.GetGetArmyRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetArmyResponse{
+					GetArmyResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetSendArmyRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.SendArmy(s, r.GetSendArmyRequest())
+			return rpc.Response{
+				Data: &rpc.Response_SendArmyResponse{
+					SendArmyResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetRecallArmyRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.RecallArmy(s, r.GetRecallArmyRequest())
+			return rpc.Response{
+				Data: &rpc.Response_RecallArmyResponse{
+					RecallArmyResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetRedirectArmyRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.RedirectArmy(s, r.GetRedirectArmyRequest())
+			return rpc.Response{
+				Data: &rpc.Response_RedirectArmyResponse{
+					RedirectArmyResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetMarchesRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetMarches(s, r.GetGetMarchesRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetMarchesResponse{
+					GetMarchesResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetBattleReportsRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.GetBattleReports(s, r.GetGetBattleReportsRequest())
+			return rpc.Response{
+				Data: &rpc.Response_GetBattleReportsResponse{
+					GetBattleReportsResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetBuyPeaceShieldRequest() != nil {
+		handler.handleFunc = func(s *PlayerSession, r rpc.Request) (rpc.Response, model.Error) {
+			response, err := p.logic.BuyPeaceShield(s, r.GetBuyPeaceShieldRequest())
+			return rpc.Response{
+				Data: &rpc.Response_BuyPeaceShieldResponse{
+					BuyPeaceShieldResponse: response,
+				},
+			}, err
+		}
+	} else if request.GetGetWorldViewportRequest() != nil {
+		handler.multipartHandleFunc = func(s *PlayerSession, r rpc.Request) ([]rpc.Response, model.Error) {
+			parts, err := p.logic.GetWorldViewport(s, r.GetGetWorldViewportRequest())
+
+			var responses []rpc.Response
+			for _, part := range parts {
+				responses = append(responses, rpc.Response{
+					Data: &rpc.Response_GetWorldViewportResponse{
+						GetWorldViewportResponse: part,
+					},
+				})
+			}
+
+			return responses, err
+		}
+
+		handler.characterRequired = false
 	} else {
 		return nil
 	}
@@ -154,11 +530,31 @@ func (p *PacketHandler) getHandleFunc(request rpc.Request) *requestHandler {
 	return &handler
 }
 
+// newMultipartResponse - prepends the MultipartResponse header to the response parts
+func newMultipartResponse(parts []rpc.Response) []*rpc.Response {
+	responses := []*rpc.Response{{
+		Data: &rpc.Response_MultipartResponse{
+			MultipartResponse: &rpc.MultipartResponse{
+				Parts: int64(len(parts)),
+			},
+		},
+	}}
+
+	for i := range parts {
+		responses = append(responses, &parts[i])
+	}
+
+	return responses
+}
+
+// HandleClientPacket - handles the client request and returns the responses which should be sent back.
+// Returns more than one response only for the multipart requests (MultipartResponse followed by parts)
 // TODO: refactor this method (too complex)
-func (p *PacketHandler) HandleClientPacket(data []byte) *rpc.Response {
+func (p *PacketHandler) HandleClientPacket(data []byte) []*rpc.Response {
 	var request rpc.Request
 	var requestErr model.Error
 	var response rpc.Response
+	var multipartResponse []rpc.Response
 
 	if err := proto.Unmarshal(data, &request); err != nil || len(data) == 0 {
 		p.log.WithError(err).Error("Failed to serialize client request")
@@ -170,7 +566,7 @@ func (p *PacketHandler) HandleClientPacket(data []byte) *rpc.Response {
 			},
 		}
 
-		return &response
+		return []*rpc.Response{&response}
 	}
 
 	requestName := strings.Split(fmt.Sprintf("%T", request.Data), "_")[1]
@@ -184,7 +580,7 @@ func (p *PacketHandler) HandleClientPacket(data []byte) *rpc.Response {
 	sessionSubmatch := sessionRegexp.FindStringSubmatch(request.String())
 	if len(sessionSubmatch) == 2 {
 		sessionID = sessionSubmatch[1]
-		session, authorized = p.logic.sessions[sessionID]
+		session, authorized = p.logic.getSession(sessionID)
 
 		if session != nil {
 			session.LastRequestTime = time.Now()
@@ -206,7 +602,7 @@ func (p *PacketHandler) HandleClientPacket(data []byte) *rpc.Response {
 		requestErr = model.ErrCharacterNotSelected
 	}
 
-	if handler != nil && handler.handleFunc != nil && requestErr == nil {
+	if handler != nil && (handler.handleFunc != nil || handler.multipartHandleFunc != nil) && requestErr == nil {
 		if session != nil {
 			session.Mutex.Lock()
 
@@ -219,7 +615,9 @@ func (p *PacketHandler) HandleClientPacket(data []byte) *rpc.Response {
 			session.Tx = tx
 		}
 
-		if requestErr == nil {
+		if requestErr == nil && handler.multipartHandleFunc != nil {
+			multipartResponse, requestErr = handler.multipartHandleFunc(session, request)
+		} else if requestErr == nil {
 			response, requestErr = handler.handleFunc(session, request)
 		}
 		if session != nil {
@@ -231,6 +629,12 @@ func (p *PacketHandler) HandleClientPacket(data []byte) *rpc.Response {
 				}
 			}
 
+			// In-memory changes of the rolled back request are discarded
+			if session.Tx != nil && session.Tx.IsSucceed() {
+				session.AfterCommit.run()
+			}
+			session.AfterCommit = nil
+
 			session.Mutex.Unlock()
 		}
 	}
@@ -246,7 +650,13 @@ func (p *PacketHandler) HandleClientPacket(data []byte) *rpc.Response {
 				},
 			},
 		}
+
+		return []*rpc.Response{&response}
+	}
+
+	if handler != nil && handler.multipartHandleFunc != nil {
+		return newMultipartResponse(multipartResponse)
 	}
 
-	return &response
+	return []*rpc.Response{&response}
 }
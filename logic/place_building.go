@@ -8,10 +8,11 @@ import (
 
 func (s *SimpleLogic) PlaceBuilding(session *PlayerSession, request *rpc.PlaceBuildingRequest) (*rpc.PlaceBuildingResponse, model.Error) {
 	s.log.WithFields(log.Fields{
-		"sessionID":  session.SessionID,
-		"buildingID": request.BuildingID,
-		"townID":     request.TownID,
-		"location":   request.Location,
+		"sessionID":   session.SessionID,
+		"buildingID":  request.BuildingID,
+		"townID":      request.TownID,
+		"location":    request.Location,
+		"payWithGold": request.PayWithGold,
 	}).Info("PlaceBuilding")
 
 	building, found := model.Buildings[request.BuildingID]
@@ -24,19 +25,39 @@ func (s *SimpleLogic) PlaceBuilding(session *PlayerSession, request *rpc.PlaceBu
 		return nil, model.ErrTownNotFound
 	}
 
+	if !s.techTree.IsBuildingAvailable(building.ID, session.SelectedCharacter.Techs) {
+		return nil, model.ErrTechNotResearched
+	}
+
+	if request.PayWithGold && building.GoldCost == 0 {
+		return nil, model.ErrBadRequest
+	}
+
+	char := session.SelectedCharacter
+
+	before := char.Resources.Clone()
+	if !char.Resources.Subtract(building.Price(request.PayWithGold)) {
+		return nil, model.ErrNotEnoughResources
+	}
+
 	building.Location = model.LocationFromRPC(request.Location)
 	if err := session.Tx.AddTownBuilding(request.TownID, building); err != nil {
 		s.log.WithError(err).Error("Failed to add town building")
+		char.Resources = before
 		return nil, model.ErrInternalServerError
 	}
 
-	char := session.SelectedCharacter
+	// Production of the chains depends on the inputs, so it's calculated every tick
+	if !building.IsProductionChain() {
+		char.ProductionRate.Add(building.Production)
+	}
 
-	if !char.Resources.Subtract(building.Cost) {
-		return nil, model.ErrNotEnoughResources
+	if char.Buildings == nil {
+		char.Buildings = make(model.CharacterBuildings)
 	}
 
-	char.ProductionRate.Add(building.Production)
+	char.Buildings[building.ID]++
+	char.StorageCapacity.Add(building.Storage)
 	char.MaxPopulation += building.PopulationBonus
 
 	if err := session.Tx.UpdateCharacter(*char); err != nil {
@@ -44,5 +65,10 @@ func (s *SimpleLogic) PlaceBuilding(session *PlayerSession, request *rpc.PlaceBu
 		return nil, model.ErrInternalServerError
 	}
 
+	// Buildings are constructed instantly, so the building is completed right after placing
+	s.publishEvent(model.NewBuildingPlacedEvent(char.ID, request.TownID, building))
+	s.publishEvent(model.NewBuildingCompletedEvent(char.ID, request.TownID, building))
+	s.publishEvent(model.NewResourcesChangedEvent(char.ID, before, char.Resources))
+
 	return &rpc.PlaceBuildingResponse{}, nil
 }
@@ -0,0 +1,102 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSimpleLogic_PlaceBuilding_StorageCapacity(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:              1,
+		Name:            "test",
+		Towns:           []model.Town{{ID: 1}},
+		Resources:       model.StorageCapacity(nil),
+		StorageCapacity: model.StorageCapacity(nil),
+	}
+
+	db.On("AddTownBuilding", int64(1), mock.Anything).Return(nil)
+	db.On("UpdateCharacter", mock.Anything).Return(nil)
+
+	_, err := logic.PlaceBuilding(session, &rpc.PlaceBuildingRequest{
+		BuildingID: rpc.BuildingType_GRANARY,
+		TownID:     1,
+		Location:   &rpc.Vector2D{},
+	})
+	require.NoError(t, err)
+
+	capacity := session.SelectedCharacter.StorageCapacity
+	require.Equal(t, model.BaseStorageCapacity+model.Buildings[rpc.BuildingType_GRANARY].Storage[model.ResourceFood], capacity[model.ResourceFood])
+	require.Equal(t, uint64(model.BaseStorageCapacity), capacity[model.ResourceWood])
+}
+
+func TestSimpleLogic_PlaceBuilding_PayWithGold(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{
+		ID:              1,
+		Name:            "test",
+		Towns:           []model.Town{{ID: 1}},
+		Resources:       model.Resources{model.ResourceGold: 100},
+		StorageCapacity: model.StorageCapacity(nil),
+	}
+
+	db.On("AddTownBuilding", int64(1), mock.Anything).Return(nil)
+	db.On("UpdateCharacter", mock.Anything).Return(nil)
+
+	_, err := logic.PlaceBuilding(session, &rpc.PlaceBuildingRequest{
+		BuildingID:  rpc.BuildingType_HOUSE,
+		TownID:      1,
+		Location:    &rpc.Vector2D{},
+		PayWithGold: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, model.Resources{model.ResourceGold: 100 - model.Buildings[rpc.BuildingType_HOUSE].GoldCost},
+		session.SelectedCharacter.Resources)
+
+	_, err = logic.PlaceBuilding(session, &rpc.PlaceBuildingRequest{
+		BuildingID:  rpc.BuildingType_QUARRY,
+		TownID:      1,
+		Location:    &rpc.Vector2D{},
+		PayWithGold: true,
+	})
+	require.Equal(t, model.ErrNotEnoughResources, err)
+	db.AssertNumberOfCalls(t, "AddTownBuilding", 1)
+}
+
+func TestCharacter_RunProductionChains(t *testing.T) {
+	character := model.Character{
+		Buildings:       model.CharacterBuildings{rpc.BuildingType_SMELTER: 2},
+		Resources:       model.Resources{model.ResourceIronOre: 3, model.ResourceWood: 5},
+		StorageCapacity: model.StorageCapacity(nil),
+	}
+
+	// second smelter lacks the ore to run
+	character.RunProductionChains()
+	require.Equal(t, model.Resources{
+		model.ResourceIronOre: 1,
+		model.ResourceWood:    4,
+		model.ResourceIron:    1,
+	}, character.Resources)
+
+	character.RunProductionChains()
+	require.Equal(t, model.Resources{
+		model.ResourceIronOre: 1,
+		model.ResourceWood:    4,
+		model.ResourceIron:    1,
+	}, character.Resources)
+}
+
+func TestSimpleLogic_GetResourceTypes(t *testing.T) {
+	logic, _, session := NewLogicMock()
+
+	resp, err := logic.GetResourceTypes(session, &rpc.GetResourceTypesRequest{})
+	require.NoError(t, err)
+	require.Equal(t, len(model.ResourceRegistry), len(resp.Resources))
+
+	for _, info := range resp.Resources {
+		require.True(t, model.ResourceType(info.Type).IsValid())
+	}
+}
@@ -0,0 +1,81 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"math"
+	"time"
+)
+
+func (s *SimpleLogic) PlaceMarketOrder(session *PlayerSession, request *rpc.PlaceMarketOrderRequest) (*rpc.PlaceMarketOrderResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":     request.SessionID,
+		"side":          request.Side,
+		"resource":      request.Resource,
+		"priceResource": request.PriceResource,
+		"price":         request.Price,
+		"amount":        request.Amount,
+	}).Info("PlaceMarketOrder")
+
+	character := session.SelectedCharacter
+	now := time.Now()
+	order := model.MarketOrder{
+		CharacterID:   character.ID,
+		CharacterName: character.Name,
+		Side:          model.MarketOrderSide(request.Side),
+		Resource:      model.ResourceType(request.Resource),
+		PriceResource: model.ResourceType(request.PriceResource),
+		Price:         request.Price,
+		Amount:        request.Amount,
+		Remaining:     request.Amount,
+		Status:        model.MarketOrderOpen,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if order.Side != model.MarketOrderBuy && order.Side != model.MarketOrderSell ||
+		!order.Resource.IsValid() || !order.PriceResource.IsValid() || order.Resource == order.PriceResource ||
+		order.Amount == 0 || order.Price == 0 || order.Price > math.MaxUint64/order.Amount {
+		return nil, model.ErrBadRequest
+	}
+
+	// Character resources are changed in memory only once the request is committed
+	tx := session.Tx
+	resources := character.Resources.Clone()
+
+	if !resources.Subtract(order.Escrow(order.Amount)) {
+		return nil, model.ErrNotEnoughResources
+	}
+
+	id, err := tx.AddMarketOrder(order)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to add market order")
+		return nil, model.ErrInternalServerError
+	}
+
+	order.ID = id
+
+	if err := s.matchMarketOrder(session, &order, resources); err != nil {
+		s.log.WithError(err).Error("Failed to match market order")
+		return nil, model.ErrInternalServerError
+	}
+
+	if order.Remaining != order.Amount {
+		if err := tx.UpdateMarketOrder(order); err != nil {
+			s.log.WithError(err).Error("Failed to update market order")
+			return nil, model.ErrInternalServerError
+		}
+	}
+
+	if err := tx.AddOrUpdateResources(character.ID, resources); err != nil {
+		s.log.WithError(err).Error("Failed to update character resources")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.setSessionResources(session, resources)
+
+	return &rpc.PlaceMarketOrderResponse{
+		Order: order.ToRPC(),
+	}, nil
+}
@@ -2,12 +2,13 @@ package logic
 
 import (
 	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/geometry"
 	"abbysoft/gardarike-online/model"
 	"abbysoft/gardarike-online/model/consts"
 	rpc "abbysoft/gardarike-online/rpc/generated"
-	"fmt"
+	"errors"
 	log "github.com/sirupsen/logrus"
-	"math/rand"
+	"time"
 )
 
 // canPlaceTown - checks if the character can place one more town.
@@ -18,25 +19,50 @@ func canPlaceTown(character model.Character) bool {
 		character.CurrentPopulation >= uint64(townCount*500)
 }
 
-func (s *SimpleLogic) getMapChunkHeightAt(chunk *rpc.WorldMapChunk, x, y int) float32 {
-	return chunk.Data[y+x*s.config.ChunkSize]
+// heightMap - terrain heights read by the single request, every chunk is loaded (and copied from the cache) once
+type heightMap struct {
+	logic   *SimpleLogic
+	session *PlayerSession
+	chunks  map[geometry.Point]*rpc.WorldMapChunk
 }
 
-func (s *SimpleLogic) getMapChunkAt(x, y int, tx db.DatabaseTransaction) (*rpc.WorldMapChunk, error) {
-	i := x / mapChunkSize
-	j := y / mapChunkSize
+func (s *SimpleLogic) newHeightMap(session *PlayerSession) *heightMap {
+	return &heightMap{
+		logic:   s,
+		session: session,
+		chunks:  make(map[geometry.Point]*rpc.WorldMapChunk),
+	}
+}
+
+// heightAt - returns terrain height of the world tile, the chunk is generated if it doesn't exist yet
+func (h *heightMap) heightAt(world geometry.Point) (float32, model.Error) {
+	location := h.logic.grid().WorldToChunk(world)
 
-	chunk, err := tx.GetMapChunk(int64(i), int64(j))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get chunk from the db: %w", err)
+	chunk, found := h.chunks[location]
+	if !found {
+		var err model.Error
+		if chunk, err = h.logic.getOrGenerateMapChunk(location, h.session); err != nil {
+			return 0, err
+		}
+
+		h.chunks[location] = chunk
 	}
 
-	rpcChunk, err := chunk.ToRPC()
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert map chunk to rpc: %w", err)
+	return h.logic.chunkHeightAt(chunk, world)
+}
+
+// chunkHeightAt - height of the world tile located on the chunk
+func (s *SimpleLogic) chunkHeightAt(chunk *rpc.WorldMapChunk, world geometry.Point) (float32, model.Error) {
+	grid := s.grid()
+	index := geometry.HeightmapIndex(grid.WorldToLocal(world), s.config.ChunkSize)
+	if index >= len(chunk.Data) {
+		s.log.WithField("chunk", grid.WorldToChunk(world)).
+			WithField("size", len(chunk.Data)).
+			Error("Chunk heightmap doesn't match the chunk size")
+		return 0, model.ErrInternalServerError
 	}
 
-	return rpcChunk, err
+	return chunk.Data[index], nil
 }
 
 func (s *SimpleLogic) PlaceTown(
@@ -59,28 +85,20 @@ func (s *SimpleLogic) PlaceTown(
 	tx := session.Tx
 
 	if request.Location == nil {
-		request.Location = &rpc.Vector2D{
-			X: rand.Float32() * float32(s.config.ChunkSize),
-			Y: rand.Float32() * float32(s.config.ChunkSize),
-		}
-	} else {
-		if request.Location.X > float32(s.config.ChunkSize) ||
-			request.Location.Y > float32(s.config.ChunkSize) ||
-			request.Location.X < 0 ||
-			request.Location.Y < 0 {
-			s.log.Error("PlaceTown: incorrect location")
-			return nil, model.ErrBadRequest
-		}
-
-		mapChunk, err := s.getMapChunkAt(int(request.Location.X), int(request.Location.Y), tx)
+		location, err := s.findSpawnLocation(session)
 		if err != nil {
-			s.log.WithError(err).Error("Failed to get map chunk")
-			return nil, model.ErrInternalServerError
+			return nil, err
 		}
 
-		if s.getMapChunkHeightAt(mapChunk, int(request.Location.X), int(request.Location.Y)) < s.config.WaterLevel {
-			s.log.Error("PlaceTown: trying to place town bellow the water level")
-			return nil, model.ErrBadRequest
+		request.Location = &rpc.Vector2D{
+			X: float32(location.X),
+			Y: float32(location.Y),
+		}
+	} else {
+		location := geometry.PointFromFloat(request.Location.X, request.Location.Y)
+		if err := s.checkTownLocation(location, s.newHeightMap(session), session); err != nil {
+			s.log.WithError(err).Error("PlaceTown: incorrect location")
+			return nil, err
 		}
 	}
 
@@ -90,36 +108,55 @@ func (s *SimpleLogic) PlaceTown(
 		return nil, err
 	}
 
+	location := geometry.PointFromFloat(request.Location.X, request.Location.Y)
 	town := model.Town{
-		X:          int64(request.Location.X),
-		Y:          int64(request.Location.Y),
+		X:          location.X,
+		Y:          location.Y,
 		OwnerName:  session.SelectedCharacter.Name,
 		Population: 0,
 		Name:       request.Name,
 	}
 
-	if err := tx.AddTown(town); err != nil {
+	if err := tx.AddTown(town); err != nil && errors.Is(err, db.ErrDuplicatedUniqueKey) {
+		return nil, model.ErrTownTooClose
+	} else if err != nil {
 		s.log.WithError(err).Error("Failed to add town")
 		return nil, model.ErrInternalServerError
 	}
 
 	session.SelectedCharacter.MaxPopulation += consts.TownPopulationBonus
 
+	// The free capital of the new character can't be attacked for a while
+	if isFirstTown {
+		session.SelectedCharacter.ProtectedUntil = time.Now().Add(s.config.NewbieProtection)
+	}
+
 	if err := tx.UpdateCharacter(*session.SelectedCharacter); err != nil {
 		s.log.WithError(err).Error("Failed to update character")
 		return nil, model.ErrInternalServerError
 	}
 
 	if !isFirstTown {
+		before := session.SelectedCharacter.Resources.Clone()
 		session.SelectedCharacter.Resources.Subtract(model.ResourcesPlaceTown)
 
-		if err := tx.AddOrUpdateResources(session.SelectedCharacter.Resources); err != nil {
+		if err := tx.AddOrUpdateResources(session.SelectedCharacter.ID, session.SelectedCharacter.Resources); err != nil {
 			s.log.WithError(err).Error("Failed to update character resources")
 			return nil, model.ErrInternalServerError
 		}
+
+		s.publishEvent(model.NewResourcesChangedEvent(
+			session.SelectedCharacter.ID, before, session.SelectedCharacter.Resources))
 	}
 
 	session.SelectedCharacter.Towns = append(session.SelectedCharacter.Towns, town)
+	if err := s.updateChatChannels(session); err != nil {
+		s.log.WithError(err).Error("Failed to update chat channels")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.publishEvent(model.NewTownFoundedEvent(town))
+
 	return &rpc.PlaceTownResponse{
 		Location: request.Location,
 	}, nil
@@ -4,11 +4,29 @@ import (
 	"abbysoft/gardarike-online/model"
 	"abbysoft/gardarike-online/model/consts"
 	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"math"
 	"testing"
+	"time"
 )
 
+// mockFlatWorld - all chunks which weren't mocked before are flat lands without towns
+func mockFlatWorld(logic *SimpleLogic, db *DatabaseTransactionMock) {
+	logic.config.ChunkSize = 2
+	logic.config.WaterLevel = 0.1
+	logic.config.MaxTownSlope = 0.5
+	logic.config.MinTownDistance = 5
+	logic.config.SpawnSearchAttempts = 10
+
+	chunk, _ := model.NewWorldMapChunkFromRPC(rpc.WorldMapChunk{Data: []float32{1, 1, 1, 1}})
+
+	db.On("GetMapChunk", mock.Anything, mock.Anything).Return(chunk, nil)
+	db.On("GetTownsForRect", mock.Anything).Return([]model.Town{}, nil)
+	db.On("GetNewestCapitals", spawnAnchorCandidates).Return([]model.Town{}, nil)
+}
+
 func TestSimpleLogic_PlaceTown_FirstTown(t *testing.T) {
 	logic, db, session := NewLogicMock()
 	request := &rpc.PlaceTownRequest{
@@ -22,19 +40,29 @@ func TestSimpleLogic_PlaceTown_FirstTown(t *testing.T) {
 		Name:      "test",
 	}
 
+	mockFlatWorld(logic, db)
+	logic.config.NewbieProtection = time.Hour
+
 	db.On("AddTown", mock.MatchedBy(func(town model.Town) bool {
 		return town.OwnerName == session.SelectedCharacter.Name &&
 			town.Name == request.Name
 	}), mock.Anything).Return(nil)
 
+	// the free capital is protected
 	db.On("UpdateCharacter", mock.MatchedBy(func(character model.Character) bool {
-		return character.MaxPopulation == consts.TownPopulationBonus
+		return character.MaxPopulation == consts.TownPopulationBonus &&
+			character.ProtectedUntil.After(time.Now())
 	}), mock.Anything).Return(nil)
 
 	resp, err := logic.PlaceTown(session, request)
 	require.NoError(t, err)
 	require.NotEmpty(t, resp)
 	require.NotNil(t, resp.Location)
+
+	require.Equal(t, 1, len(logic.EventsChan))
+	event := <-logic.EventsChan
+	require.Equal(t, consts.GlobalTopic, event.Topic)
+	require.Equal(t, request.Name, event.Event.GetTownFoundedEvent().Town.Name)
 }
 
 func TestSimpleLogic_PlaceTown_PlacingSecondTown(t *testing.T) {
@@ -58,16 +86,17 @@ func TestSimpleLogic_PlaceTown_PlacingSecondTown(t *testing.T) {
 			},
 		},
 		Resources: model.Resources{
-			CharacterID: 1,
-			Wood:        1000,
-			Food:        1000,
-			Stone:       1000,
-			Leather:     1000,
+			model.ResourceWood:    1000,
+			model.ResourceFood:    1000,
+			model.ResourceStone:   1000,
+			model.ResourceLeather: 1000,
 		},
 		MaxPopulation:     2000,
 		CurrentPopulation: 1500,
 	}
 
+	mockFlatWorld(logic, db)
+
 	db.On("AddTown", mock.MatchedBy(func(town model.Town) bool {
 		return town.OwnerName == session.SelectedCharacter.Name &&
 			town.Name == request.Name
@@ -78,15 +107,14 @@ func TestSimpleLogic_PlaceTown_PlacingSecondTown(t *testing.T) {
 	}), mock.Anything).Return(nil)
 
 	resourcesAfterPlacing := model.Resources{
-		CharacterID: 1,
-		Wood:        1000,
-		Food:        1000,
-		Stone:       1000,
-		Leather:     1000,
+		model.ResourceWood:    1000,
+		model.ResourceFood:    1000,
+		model.ResourceStone:   1000,
+		model.ResourceLeather: 1000,
 	}
 	resourcesAfterPlacing.Subtract(model.ResourcesPlaceTown)
 
-	db.On("AddOrUpdateResources", resourcesAfterPlacing, mock.Anything).Return(nil)
+	db.On("AddOrUpdateResources", int64(1), resourcesAfterPlacing).Return(nil)
 
 	resp, err := logic.PlaceTown(session, request)
 	require.NoError(t, err)
@@ -98,25 +126,183 @@ func TestSimpleLogic_PlaceTown_PlacingSecondTown(t *testing.T) {
 	require.EqualError(t, err, model.ErrNotEnoughResources.Error())
 	require.Nil(t, resp)
 
-	// Placing bellow the waterlevel
-	session.SelectedCharacter.Resources.Add(model.ResourcesPlaceTown)
-	logic.config.WaterLevel = 0.1
-	logic.config.ChunkSize = 2
+}
+
+func TestSimpleLogic_PlaceTown_UnderWater(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	request := &rpc.PlaceTownRequest{
+		SessionID: "sessionID",
+		Name:      "TestTown",
+		Location: &rpc.Vector2D{
+			X: 1,
+			Y: 1,
+		},
+	}
+
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		AccountID: 1,
+		Name:      "test",
+	}
 
 	chunk, convertErr := model.NewWorldMapChunkFromRPC(rpc.WorldMapChunk{
 		Data: []float32{0.05, 0.04, 0.08, 0.09},
 	})
+	require.NoError(t, convertErr)
+
+	db.On("GetMapChunk", int64(0), int64(0)).Return(chunk, nil)
+	mockFlatWorld(logic, db)
+
+	resp, err := logic.PlaceTown(session, request)
+	require.EqualError(t, err, model.ErrLocationUnderWater.Error())
+	require.Nil(t, resp)
+}
 
+func TestSimpleLogic_PlaceTown_TerrainTooSteep(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	request := &rpc.PlaceTownRequest{
+		SessionID: "sessionID",
+		Name:      "TestTown",
+		Location: &rpc.Vector2D{
+			X: 1,
+			Y: 1,
+		},
+	}
+
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		AccountID: 1,
+		Name:      "test",
+	}
+
+	chunk, convertErr := model.NewWorldMapChunkFromRPC(rpc.WorldMapChunk{
+		Data: []float32{1, 3, 1, 1},
+	})
 	require.NoError(t, convertErr)
 
 	db.On("GetMapChunk", int64(0), int64(0)).Return(chunk, nil)
+	mockFlatWorld(logic, db)
+
+	resp, err := logic.PlaceTown(session, request)
+	require.EqualError(t, err, model.ErrTerrainTooSteep.Error())
+	require.Nil(t, resp)
+}
+
+func TestSimpleLogic_PlaceTown_TooCloseToAnotherTown(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	request := &rpc.PlaceTownRequest{
+		SessionID: "sessionID",
+		Name:      "TestTown",
+		Location: &rpc.Vector2D{
+			X: 1,
+			Y: 1,
+		},
+	}
 
-	request.Location = &rpc.Vector2D{
-		X: 1,
-		Y: 1,
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		AccountID: 1,
+		Name:      "test",
 	}
 
-	resp, err = logic.PlaceTown(session, request)
-	require.EqualError(t, err, model.ErrBadRequest.Error())
+	db.On("GetTownsForRect", mock.Anything).Return([]model.Town{{X: 3, Y: 4, Name: "neighbour"}}, nil)
+	mockFlatWorld(logic, db)
+
+	resp, err := logic.PlaceTown(session, request)
+	require.EqualError(t, err, model.ErrTownTooClose.Error())
+	require.Nil(t, resp)
+}
+
+func TestSimpleLogic_PlaceTown_SpawnNearNewestCapital(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	request := &rpc.PlaceTownRequest{
+		SessionID: "sessionID",
+		Name:      "TestTown",
+	}
+
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		AccountID: 1,
+		Name:      "test",
+	}
+
+	capital := model.Town{X: 100, Y: -100, Name: "capital", OwnerName: "newbie"}
+
+	db.On("GetNewestCapitals", spawnAnchorCandidates).Return([]model.Town{capital}, nil)
+	mockFlatWorld(logic, db)
+
+	db.On("AddTown", mock.Anything).Return(nil)
+	db.On("UpdateCharacter", mock.Anything).Return(nil)
+
+	resp, err := logic.PlaceTown(session, request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Location)
+
+	// The town is placed on the first ring around the newest capital
+	dx, dy := float64(resp.Location.X)-100, float64(resp.Location.Y)+100
+	distance := math.Sqrt(dx*dx + dy*dy)
+	assert.InDelta(t, float64(logic.config.MinTownDistance), distance, 1)
+}
+
+func TestSimpleLogic_PlaceTown_SpawnNotFound(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	request := &rpc.PlaceTownRequest{
+		SessionID: "sessionID",
+		Name:      "TestTown",
+	}
+
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		AccountID: 1,
+		Name:      "test",
+	}
+
+	water, _ := model.NewWorldMapChunkFromRPC(rpc.WorldMapChunk{Data: []float32{0, 0, 0, 0}})
+	db.On("GetMapChunk", mock.Anything, mock.Anything).Return(water, nil)
+	mockFlatWorld(logic, db)
+
+	resp, err := logic.PlaceTown(session, request)
+	require.EqualError(t, err, model.ErrSpawnLocationNotFound.Error())
 	require.Nil(t, resp)
 }
+
+func TestSimpleLogic_PlaceTown_NegativeCoordinates(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	request := &rpc.PlaceTownRequest{
+		SessionID: "sessionID",
+		Name:      "TestTown",
+		Location: &rpc.Vector2D{
+			X: -3.5,
+			Y: 5,
+		},
+	}
+
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		AccountID: 1,
+		Name:      "test",
+	}
+
+	// World tile (-4, 5) is the local tile (0, 1) of the chunk (-2, 2)
+	chunk, convertErr := model.NewWorldMapChunkFromRPC(rpc.WorldMapChunk{
+		X:    -2,
+		Y:    2,
+		Data: []float32{0.05, 0.5, 0.08, 0.09},
+	})
+	require.NoError(t, convertErr)
+
+	db.On("GetMapChunk", int64(-2), int64(2)).Return(chunk, nil)
+	mockFlatWorld(logic, db)
+	logic.config.MaxTownSlope = 1
+
+	db.On("AddTown", mock.MatchedBy(func(town model.Town) bool {
+		return town.X == -4 && town.Y == 5
+	})).Return(nil)
+	db.On("UpdateCharacter", mock.Anything).Return(nil)
+
+	resp, err := logic.PlaceTown(session, request)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	db.AssertCalled(t, "AddTown", mock.Anything)
+}
@@ -17,12 +17,17 @@ type PlayerSession struct {
 	LastRequestTime   time.Time
 	WorkDistribution  rpc.GetWorkDistributionResponse
 	Tx                db.DatabaseTransaction
+	EventTopic        string // Topic of the events addressed only to this session
+	ChatChannels      []model.ChatChannel
+	IsModerator       bool
+	AfterCommit       afterCommit // Changes of the online characters applied once the request is committed
 }
 
 func NewPlayerSession(accountID int64) *PlayerSession {
 	return &PlayerSession{
 		AccountID:         accountID,
 		SessionID:         uuid.New().String(),
+		EventTopic:        model.SessionTopic(uuid.New().String()),
 		SelectedCharacter: nil,
 		LastRequestTime:   time.Now(),
 		WorkDistribution: rpc.GetWorkDistributionResponse{
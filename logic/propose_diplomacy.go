@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) ProposeDiplomacy(session *PlayerSession, request *rpc.ProposeDiplomacyRequest) (*rpc.ProposeDiplomacyResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":  request.SessionID,
+		"asAlliance": request.AsAlliance,
+		"target":     request.Target,
+		"state":      request.State,
+	}).Info("ProposeDiplomacy")
+
+	state := model.DiplomacyState(request.State)
+	if request.Target == nil || !state.IsValid() {
+		return nil, model.ErrBadRequest
+	}
+
+	tx := session.Tx
+
+	from, fromTopic, modelErr := s.sessionDiplomacyParty(session, request.AsAlliance)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	to, toTopic, modelErr := s.findDiplomacyParty(model.NewDiplomacyPartyFromRPC(request.Target), tx)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	if from == to {
+		return nil, model.ErrBadRequest
+	}
+
+	current, err := s.diplomacyState(from, to, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get diplomacy state")
+		return nil, model.ErrInternalServerError
+	}
+
+	if state == current {
+		return nil, model.ErrBadRequest
+	}
+
+	if !state.RequiresConsent() {
+		// War can be finished only by the peace treaty
+		if current == model.DiplomacyWar {
+			return nil, model.ErrForbidden
+		}
+
+		relation := model.NewDiplomacyRelation(from, to, state)
+		if err := s.setDiplomacyRelation(relation, fromTopic, toTopic, tx); err != nil {
+			return nil, err
+		}
+
+		return &rpc.ProposeDiplomacyResponse{
+			Relation: relation.ToRPC(),
+		}, nil
+	}
+
+	proposal := model.NewDiplomacyProposal(from, to, state, session.SelectedCharacter.Name)
+	proposal.ID, err = tx.AddDiplomacyProposal(proposal)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to add diplomacy proposal")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.publishEvent(model.NewDiplomacyProposalEvent(toTopic, proposal))
+
+	return &rpc.ProposeDiplomacyResponse{
+		Proposal: proposal.ToRPC(),
+	}, nil
+}
@@ -0,0 +1,190 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCharacter_ProtectionError(t *testing.T) {
+	now := time.Now()
+	character := model.Character{ProtectedUntil: now.Add(time.Hour), ShieldUntil: now.Add(2 * time.Hour)}
+
+	require.Equal(t, model.ErrTargetProtected, character.ProtectionError(now))
+	require.Equal(t, model.ErrTargetShielded, character.ProtectionError(now.Add(90*time.Minute)))
+	require.NoError(t, character.ProtectionError(now.Add(3*time.Hour)))
+	require.Equal(t, int(rpc.Error_FORBIDDEN), character.ProtectionError(now).GetCode())
+
+	character.DropProtection()
+	require.False(t, character.IsProtected(now))
+}
+
+// mockAttack - the character with the militia in the town 1 sends it to the town 5 of the defender
+func mockAttack(defender model.Character) (*SimpleLogic, *DatabaseTransactionMock, *PlayerSession) {
+	logic, db, session := NewLogicMock()
+	mockMarchTerrain(logic, db, 1)
+
+	session.SelectedCharacter = &model.Character{
+		ID:    1,
+		Name:  "test",
+		Towns: []model.Town{{ID: 1}},
+	}
+
+	db.On("GetCharacterArmy", "test").Return(map[int64]model.Army{1: {rpc.UnitType_MILITIA: 1}}, nil)
+	db.On("GetTown", int64(5)).Return(model.Town{ID: 5, X: 3, OwnerName: "defender"}, nil)
+	db.On("GetCharacterByName", "defender").Return(defender, nil)
+	db.On("GetDiplomacyRelation", mock.Anything, mock.Anything).Return(model.DiplomacyRelation{}, sql.ErrNoRows)
+
+	return logic, db, session
+}
+
+func TestSimpleLogic_SendArmy_TargetProtected(t *testing.T) {
+	logic, _, session := mockAttack(model.Character{ID: 2, Name: "defender", ShieldUntil: time.Now().Add(time.Hour)})
+
+	_, err := logic.SendArmy(session, &rpc.SendArmyRequest{
+		TownID:      1,
+		Units:       model.Army{rpc.UnitType_MILITIA: 1}.ToRPC(),
+		Destination: &rpc.MarchDestination{TownID: 5},
+	})
+	require.Equal(t, model.ErrTargetShielded, err)
+}
+
+func TestSimpleLogic_SendArmy_Treaty(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockMarchTerrain(logic, db, 1)
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test", Towns: []model.Town{{ID: 1}}}
+
+	db.On("GetCharacterArmy", "test").Return(map[int64]model.Army{1: {rpc.UnitType_MILITIA: 1}}, nil)
+	db.On("GetTown", int64(5)).Return(model.Town{ID: 5, X: 3, OwnerName: "defender"}, nil)
+	db.On("GetCharacterByName", "defender").Return(model.Character{ID: 2, Name: "defender"}, nil)
+	db.On("GetDiplomacyRelation", model.CharacterParty("test"), model.CharacterParty("defender")).Return(
+		model.NewDiplomacyRelation(model.CharacterParty("test"), model.CharacterParty("defender"), model.DiplomacyPeace), nil)
+
+	_, err := logic.SendArmy(session, &rpc.SendArmyRequest{
+		TownID:      1,
+		Units:       model.Army{rpc.UnitType_MILITIA: 1}.ToRPC(),
+		Destination: &rpc.MarchDestination{TownID: 5},
+	})
+	require.Equal(t, model.ErrTreatyViolation, err)
+	db.AssertNotCalled(t, "AddMarch", mock.Anything)
+}
+
+func TestSimpleLogic_SendArmy_DropsProtection(t *testing.T) {
+	logic, db, session := mockAttack(model.Character{ID: 2, Name: "defender"})
+	session.SelectedCharacter.ProtectedUntil = time.Now().Add(time.Hour)
+
+	db.On("UpdateCharacter", mock.MatchedBy(func(character model.Character) bool {
+		return character.ProtectedUntil.IsZero()
+	})).Return(nil)
+	db.On("RemoveTownUnits", int64(1), model.Army{rpc.UnitType_MILITIA: 1}).Return(nil)
+	db.On("AddMarch", mock.Anything).Return(int64(1), nil)
+
+	_, err := logic.SendArmy(session, &rpc.SendArmyRequest{
+		TownID:      1,
+		Units:       model.Army{rpc.UnitType_MILITIA: 1}.ToRPC(),
+		Destination: &rpc.MarchDestination{TownID: 5},
+	})
+	require.NoError(t, err)
+	require.False(t, session.SelectedCharacter.IsProtected(time.Now()))
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_SendArmy_KeepsProtectionOnFailure(t *testing.T) {
+	logic, db, session := mockAttack(model.Character{ID: 2, Name: "defender"})
+	session.SelectedCharacter.ProtectedUntil = time.Now().Add(time.Hour)
+
+	db.On("RemoveTownUnits", int64(1), model.Army{rpc.UnitType_MILITIA: 1}).Return(nil)
+	db.On("AddMarch", mock.Anything).Return(int64(0), errors.New("db error"))
+
+	_, err := logic.SendArmy(session, &rpc.SendArmyRequest{
+		TownID:      1,
+		Units:       model.Army{rpc.UnitType_MILITIA: 1}.ToRPC(),
+		Destination: &rpc.MarchDestination{TownID: 5},
+	})
+	require.Equal(t, model.ErrInternalServerError, err)
+	require.True(t, session.SelectedCharacter.IsProtected(time.Now()))
+	db.AssertNotCalled(t, "UpdateCharacter", mock.Anything)
+}
+
+func TestSimpleLogic_BuyPeaceShield(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.PeaceShieldDuration = 24 * time.Hour
+	logic.config.PeaceShieldGoldCost = 500
+
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		Name:      "test",
+		Resources: model.Resources{model.ResourceGold: 600},
+	}
+
+	db.On("UpdateCharacter", mock.Anything).Return(nil)
+
+	resp, err := logic.BuyPeaceShield(session, &rpc.BuyPeaceShieldRequest{})
+	require.NoError(t, err)
+
+	character := session.SelectedCharacter
+	require.Equal(t, character.ShieldUntil.Unix(), resp.ShieldUntil)
+	require.True(t, character.ShieldUntil.After(time.Now().Add(23*time.Hour)))
+	require.Equal(t, model.Resources{model.ResourceGold: 100}, character.Resources)
+
+	_, err = logic.BuyPeaceShield(session, &rpc.BuyPeaceShieldRequest{})
+	require.Equal(t, model.ErrAlreadyProtected, err)
+
+	character.ShieldUntil = time.Time{}
+	_, err = logic.BuyPeaceShield(session, &rpc.BuyPeaceShieldRequest{})
+	require.Equal(t, model.ErrNotEnoughResources, err)
+}
+
+func TestArmyManager_ArriveMarches_ProtectedTown(t *testing.T) {
+	logic, db, _ := NewLogicMock()
+	logic.config.ChunkSize = 2
+
+	now := time.Now()
+	march := model.March{ID: 1, CharacterID: 1, CharacterName: "test", TargetTownID: 5, Units: model.Army{rpc.UnitType_CAVALRY: 1}}
+
+	// the shield was bought while the army was marching
+	db.On("GetArrivedMarches", now, marchArrivalBatchSize).Return([]model.March{march}, nil)
+	db.On("GetTown", int64(5)).Return(model.Town{ID: 5, OwnerName: "defender"}, nil)
+	db.On("GetCharacterByName", "test").Return(model.Character{ID: 1, Name: "test"}, nil)
+	db.On("GetCharacterByName", "defender").Return(model.Character{ID: 2, Name: "defender", ShieldUntil: now.Add(time.Hour)}, nil)
+	db.On("GetDiplomacyRelation", mock.Anything, mock.Anything).Return(model.DiplomacyRelation{}, sql.ErrNoRows)
+	db.On("UpdateMarch", mock.MatchedBy(func(march model.March) bool {
+		return march.Status == model.MarchCamped
+	})).Return(nil)
+
+	var after afterCommit
+	require.NoError(t, NewArmyManager(logic).arriveMarches(now, db, &after))
+	db.AssertExpectations(t)
+
+	after.run()
+}
+
+func TestArmyManager_ArriveMarches_Treaty(t *testing.T) {
+	logic, db, _ := NewLogicMock()
+	logic.config.ChunkSize = 2
+
+	now := time.Now()
+	march := model.March{ID: 1, CharacterID: 1, CharacterName: "test", TargetTownID: 5, Units: model.Army{rpc.UnitType_CAVALRY: 1}}
+
+	// the peace was signed while the army was marching
+	db.On("GetArrivedMarches", now, marchArrivalBatchSize).Return([]model.March{march}, nil)
+	db.On("GetTown", int64(5)).Return(model.Town{ID: 5, OwnerName: "defender"}, nil)
+	db.On("GetCharacterByName", "test").Return(model.Character{ID: 1, Name: "test"}, nil)
+	db.On("GetCharacterByName", "defender").Return(model.Character{ID: 2, Name: "defender"}, nil)
+	db.On("GetDiplomacyRelation", model.CharacterParty("test"), model.CharacterParty("defender")).Return(
+		model.NewDiplomacyRelation(model.CharacterParty("test"), model.CharacterParty("defender"), model.DiplomacyNonAggression), nil)
+	db.On("UpdateMarch", mock.MatchedBy(func(march model.March) bool {
+		return march.Status == model.MarchCamped
+	})).Return(nil)
+
+	var after afterCommit
+	require.NoError(t, NewArmyManager(logic).arriveMarches(now, db, &after))
+	db.AssertExpectations(t)
+	db.AssertNotCalled(t, "AddBattleReport", mock.Anything)
+}
@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// RecallArmy - the army turns back to its town from the current position
+func (s *SimpleLogic) RecallArmy(session *PlayerSession, request *rpc.RecallArmyRequest) (*rpc.RecallArmyResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"marchID":   request.MarchID,
+	}).Info("RecallArmy")
+
+	march, err := s.getCharacterMarch(session, request.MarchID)
+	if err != nil {
+		return nil, err
+	}
+
+	home := &rpc.MarchDestination{TownID: march.HomeTownID}
+	attack, err := s.departMarch(&march, march.Position(time.Now()), home, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := session.Tx.UpdateMarch(march); err != nil {
+		s.log.WithError(err).Error("Failed to update march")
+		return nil, model.ErrInternalServerError
+	}
+
+	if attack {
+		if err := s.dropAttackerProtection(session); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	s.publishMarchPosition(march, now)
+
+	return &rpc.RecallArmyResponse{
+		March: march.ToRPC(now),
+	}, nil
+}
@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// RedirectArmy - the army heads to the new destination from the current position
+func (s *SimpleLogic) RedirectArmy(session *PlayerSession, request *rpc.RedirectArmyRequest) (*rpc.RedirectArmyResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":   request.SessionID,
+		"marchID":     request.MarchID,
+		"destination": request.Destination,
+	}).Info("RedirectArmy")
+
+	march, err := s.getCharacterMarch(session, request.MarchID)
+	if err != nil {
+		return nil, err
+	}
+
+	attack, err := s.departMarch(&march, march.Position(time.Now()), request.Destination, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := session.Tx.UpdateMarch(march); err != nil {
+		s.log.WithError(err).Error("Failed to update march")
+		return nil, model.ErrInternalServerError
+	}
+
+	if attack {
+		if err := s.dropAttackerProtection(session); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	s.publishMarchPosition(march, now)
+
+	return &rpc.RedirectArmyResponse{
+		March: march.ToRPC(now),
+	}, nil
+}
@@ -0,0 +1,40 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) RejectDiplomacyProposal(
+	session *PlayerSession, request *rpc.RejectDiplomacyProposalRequest) (*rpc.RejectDiplomacyProposalResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":  request.SessionID,
+		"proposalID": request.ProposalID,
+	}).Info("RejectDiplomacyProposal")
+
+	tx := session.Tx
+
+	proposal, modelErr := s.getDiplomacyProposal(request.ProposalID, tx)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	// Target rejects the proposal, otherwise the proposer withdraws it
+	character := session.SelectedCharacter
+	side := proposal.To()
+	if side != model.CharacterParty(character.Name) && side != model.AllianceParty(character.AllianceTag) {
+		side = proposal.From()
+	}
+
+	if _, err := s.representDiplomacyParty(session, side); err != nil {
+		return nil, err
+	}
+
+	if err := tx.RemoveDiplomacyProposal(proposal.ID); err != nil {
+		s.log.WithError(err).Error("Failed to remove diplomacy proposal")
+		return nil, model.ErrInternalServerError
+	}
+
+	return &rpc.RejectDiplomacyProposalResponse{}, nil
+}
@@ -0,0 +1,23 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"database/sql"
+	"errors"
+)
+
+// getActiveResearch - returns the research of the character in progress, ErrResearchNotFound if there is none
+func (s *SimpleLogic) getActiveResearch(session *PlayerSession) (model.Research, model.Error) {
+	session.Tx.SetAutoRollBack(false)
+	research, err := session.Tx.GetActiveResearch(session.SelectedCharacter.ID)
+	session.Tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return research, model.ErrResearchNotFound
+	} else if err != nil {
+		s.log.WithError(err).Error("Failed to get active research")
+		return research, model.ErrInternalServerError
+	}
+
+	return research, nil
+}
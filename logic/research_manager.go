@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/model"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+var (
+	researchUpdateFreq = 5 * time.Second
+)
+
+const (
+	// Max number of researches completed in one transaction
+	researchCompletionBatchSize = 100
+)
+
+type ResearchManager struct {
+	logic  *SimpleLogic
+	logger *log.Entry
+}
+
+func NewResearchManager(l *SimpleLogic) *ResearchManager {
+	return &ResearchManager{
+		logic:  l,
+		logger: log.WithField("module", "research_manager"),
+	}
+}
+
+// finish - marks the research completed, the online character gets the tech once the transaction is committed
+func (r *ResearchManager) finish(research model.Research, tx db.DatabaseTransaction, after *afterCommit) error {
+	if err := tx.CompleteResearch(research); err != nil {
+		return fmt.Errorf("failed to update research: %w", err)
+	}
+
+	after.add(func() {
+		r.logic.updateOnlineCharacter(nil, research.CharacterID, func(character *model.Character) {
+			if character.Techs == nil {
+				character.Techs = make(model.CharacterTechs)
+			}
+
+			character.Techs[research.Tech] = true
+		})
+
+		r.logic.publishEvent(model.NewResearchCompletedEvent(research))
+	})
+	return nil
+}
+
+// complete - completes all researches which finish time has come
+func (r *ResearchManager) complete(now time.Time, tx db.DatabaseTransaction, after *afterCommit) error {
+	for {
+		researches, err := tx.GetFinishedResearch(now, researchCompletionBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get finished researches: %w", err)
+		}
+
+		for _, research := range researches {
+			if err := r.finish(research, tx, after); err != nil {
+				return fmt.Errorf("failed to complete research %s of %d: %w",
+					research.Tech, research.CharacterID, err)
+			}
+		}
+
+		if len(researches) < researchCompletionBatchSize {
+			return nil
+		}
+	}
+}
+
+func (r *ResearchManager) Update() {
+	tx, err := r.logic.db.BeginTransaction(false, true)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to begin transaction")
+		return
+	}
+
+	var after afterCommit
+	if err := r.complete(time.Now(), tx, &after); err != nil {
+		r.logger.WithError(err).Error("Failed to complete researches")
+		rollBack(tx, r.logger)
+		return
+	}
+
+	if err := tx.EndTransaction(); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return
+	}
+
+	after.run()
+}
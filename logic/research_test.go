@@ -0,0 +1,174 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testTechTree = model.TechTree{
+	"mining": {
+		ID:              "mining",
+		Cost:            model.Resources{model.ResourceWood: 100},
+		ResearchTime:    time.Minute,
+		Buildings:       []rpc.BuildingType{rpc.BuildingType_MINE},
+		ProductionBonus: model.Resources{model.ResourceStone: 50},
+	},
+	"smelting": {
+		ID:        "smelting",
+		Requires:  []string{"mining"},
+		Buildings: []rpc.BuildingType{rpc.BuildingType_SMELTER},
+	},
+}
+
+func TestLoadTechTree(t *testing.T) {
+	tree, err := LoadTechTree("../configs/techs.toml")
+	require.NoError(t, err)
+	require.NotEmpty(t, tree)
+
+	mining := tree["mining"]
+	require.Equal(t, []rpc.BuildingType{rpc.BuildingType_MINE}, mining.Buildings)
+	require.Equal(t, []string{"masonry"}, mining.Requires)
+	require.Equal(t, 5*time.Minute, mining.ResearchTime)
+	require.Equal(t, uint64(200), mining.Cost[model.ResourceStone])
+}
+
+func TestNewTechTree_Invalid(t *testing.T) {
+	_, err := model.NewTechTree([]model.Tech{{ID: "a", Requires: []string{"unknown"}}})
+	require.Error(t, err)
+
+	_, err = model.NewTechTree([]model.Tech{
+		{ID: "a", Requires: []string{"b"}},
+		{ID: "b", Requires: []string{"a"}},
+	})
+	require.Error(t, err)
+}
+
+func TestTechTree_IsBuildingAvailable(t *testing.T) {
+	require.True(t, testTechTree.IsBuildingAvailable(rpc.BuildingType_HOUSE, nil))
+	require.False(t, testTechTree.IsBuildingAvailable(rpc.BuildingType_MINE, nil))
+	require.True(t, testTechTree.IsBuildingAvailable(rpc.BuildingType_MINE, model.CharacterTechs{"mining": true}))
+}
+
+func TestSimpleLogic_PlaceBuilding_TechNotResearched(t *testing.T) {
+	logic, _, session := NewLogicMock()
+	logic.techTree = testTechTree
+	session.SelectedCharacter = &model.Character{
+		ID:        1,
+		Towns:     []model.Town{{ID: 1}},
+		Resources: model.StorageCapacity(nil),
+	}
+
+	_, err := logic.PlaceBuilding(session, &rpc.PlaceBuildingRequest{
+		BuildingID: rpc.BuildingType_MINE,
+		TownID:     1,
+		Location:   &rpc.Vector2D{},
+	})
+	require.Equal(t, model.ErrTechNotResearched, err)
+}
+
+func TestSimpleLogic_StartResearch(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.techTree = testTechTree
+	session.SelectedCharacter = &model.Character{ID: 1, Resources: model.Resources{model.ResourceWood: 150}}
+
+	db.On("GetActiveResearch", int64(1)).Return(model.Research{}, sql.ErrNoRows)
+	db.On("AddResearch", mock.MatchedBy(func(research model.Research) bool {
+		return research.Tech == "mining" && research.FinishesAt.Sub(research.StartedAt) == time.Minute
+	})).Return(nil)
+	db.On("AddOrUpdateResources", int64(1), model.Resources{model.ResourceWood: 50}).Return(nil)
+
+	resp, err := logic.StartResearch(session, &rpc.StartResearchRequest{Tech: "mining"})
+	require.NoError(t, err)
+	require.Equal(t, "mining", resp.Research.Tech)
+
+	_, err = logic.StartResearch(session, &rpc.StartResearchRequest{Tech: "smelting"})
+	require.Equal(t, model.ErrTechNotResearched, err)
+
+	_, err = logic.StartResearch(session, &rpc.StartResearchRequest{Tech: "unknown"})
+	require.Equal(t, model.ErrBadRequest, err)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_StartResearch_InProgress(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.techTree = testTechTree
+	session.SelectedCharacter = &model.Character{ID: 1, Resources: model.Resources{model.ResourceWood: 150}}
+
+	db.On("GetActiveResearch", int64(1)).Return(model.Research{CharacterID: 1, Tech: "other"}, nil)
+
+	_, err := logic.StartResearch(session, &rpc.StartResearchRequest{Tech: "mining"})
+	require.Equal(t, model.ErrResearchInProgress, err)
+	require.Equal(t, uint64(150), session.SelectedCharacter.Resources[model.ResourceWood])
+}
+
+func TestSimpleLogic_CancelResearch(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.techTree = testTechTree
+	session.SelectedCharacter = &model.Character{
+		ID:              1,
+		Resources:       model.Resources{model.ResourceWood: model.BaseStorageCapacity - 50},
+		StorageCapacity: model.StorageCapacity(nil),
+	}
+
+	db.On("GetActiveResearch", int64(1)).Return(model.Research{CharacterID: 1, Tech: "mining"}, nil).Once()
+	db.On("DeleteResearch", int64(1), "mining").Return(nil)
+	db.On("AddOrUpdateResources", int64(1), model.Resources{model.ResourceWood: model.BaseStorageCapacity + 50}).Return(nil)
+
+	// The cost is returned in full even above the storage capacity
+	_, err := logic.CancelResearch(session, &rpc.CancelResearchRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(model.BaseStorageCapacity+50), session.SelectedCharacter.Resources[model.ResourceWood])
+
+	db.On("GetActiveResearch", int64(1)).Return(model.Research{}, sql.ErrNoRows)
+
+	_, err = logic.CancelResearch(session, &rpc.CancelResearchRequest{})
+	require.Equal(t, model.ErrResearchNotFound, err)
+}
+
+func TestResearchManager_Complete(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.techTree = testTechTree
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test"}
+
+	now := time.Now()
+	research := model.Research{CharacterID: 1, Tech: "mining", FinishesAt: now}
+
+	db.On("GetFinishedResearch", now, researchCompletionBatchSize).Return([]model.Research{research}, nil)
+	db.On("CompleteResearch", research).Return(nil)
+
+	var after afterCommit
+	require.NoError(t, NewResearchManager(logic).complete(now, db, &after))
+	require.False(t, session.SelectedCharacter.Techs["mining"])
+
+	after.run()
+	require.True(t, session.SelectedCharacter.Techs["mining"])
+
+	require.Equal(t, 1, len(logic.EventsChan))
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), event.Topic)
+	require.Equal(t, "mining", event.Event.GetResearchCompletedEvent().Tech)
+}
+
+func TestSimpleLogic_UpdateSessionResources_ProductionBonus(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.techTree = testTechTree
+	session.SelectedCharacter = &model.Character{
+		ID:              1,
+		ProductionRate:  model.Resources{model.ResourceStone: 4},
+		StorageCapacity: model.StorageCapacity(nil),
+		Techs:           model.CharacterTechs{"mining": true},
+	}
+
+	db.On("AddOrUpdateResources", mock.Anything, mock.Anything).Return(nil)
+
+	logic.updateSessionResources(session)
+
+	// base income, production rate and the bonus of the researched tech
+	require.Equal(t, uint64(1+4+2), session.SelectedCharacter.Resources[model.ResourceStone])
+}
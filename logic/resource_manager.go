@@ -1,7 +1,10 @@
 package logic
 
 import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/geometry"
 	"abbysoft/gardarike-online/model"
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"time"
 )
@@ -10,6 +13,11 @@ var (
 	resourceUpdateFreq = 1 * time.Minute
 )
 
+const (
+	// Part of the chunk resources harvested by the territory owners on every update
+	harvestFraction = 0.1
+)
+
 type ResourceManager struct {
 	logic  *SimpleLogic
 	logger *log.Entry
@@ -29,8 +37,60 @@ var resourceIncrementValue = model.ChunkResources{
 	Plants:  6,
 }
 
+// harvest - moves part of the chunk resources to the characters which territories cover the chunk.
+// Every character gets resources only from the part of the chunk inside of its own territory
+func (r *ResourceManager) harvest(tx db.DatabaseTransaction, after *afterCommit) error {
+	towns, err := tx.GetAllTowns()
+	if err != nil {
+		return fmt.Errorf("failed to get towns: %w", err)
+	}
+
+	chunks, err := tx.GetMapChunksResources()
+	if err != nil {
+		return fmt.Errorf("failed to get chunks resources: %w", err)
+	}
+
+	grid := r.logic.grid()
+	harvested := make(map[string]model.Resources)
+
+	for _, chunk := range chunks {
+		chunkRect := grid.ChunkRect(geometry.Point{X: chunk.X, Y: chunk.Y})
+
+		territories := territoriesInRect(chunkRect, towns)
+		if len(territories) == 0 {
+			continue
+		}
+
+		var chunkHarvested model.ChunkResources
+		for owner, share := range territoryShares(chunkRect, territories) {
+			part := chunk.ChunkResources.Scale(share * harvestFraction)
+			chunkHarvested.Add(part)
+
+			resources := harvested[owner]
+			resources.Add(part.Harvested())
+			harvested[owner] = resources
+		}
+
+		if chunkHarvested.IsEmpty() {
+			continue
+		}
+
+		if err := tx.SubtractMapChunkResources(chunk.X, chunk.Y, chunkHarvested); err != nil {
+			return fmt.Errorf("failed to subtract chunk resources: %w", err)
+		}
+	}
+
+	for owner, resources := range harvested {
+		if err := r.logic.giveCharacterResources(owner, resources, tx, after); err != nil {
+			return fmt.Errorf("failed to give harvested resources to %s: %w", owner, err)
+		}
+	}
+
+	return nil
+}
+
 func (r *ResourceManager) Update() {
-	tx, err := r.logic.db.BeginTransaction(true, true)
+	tx, err := r.logic.db.BeginTransaction(false, true)
 	if err != nil {
 		r.logger.WithError(err).Error("Failed to begin transaction")
 		return
@@ -38,5 +98,21 @@ func (r *ResourceManager) Update() {
 
 	if err := tx.IncrementMapResources(resourceIncrementValue); err != nil {
 		r.logger.WithError(err).Error("Failed to increment map resources")
+		rollBack(tx, r.logger)
+		return
 	}
+
+	var after afterCommit
+	if err := r.harvest(tx, &after); err != nil {
+		r.logger.WithError(err).Error("Failed to harvest map resources")
+		rollBack(tx, r.logger)
+		return
+	}
+
+	if err := tx.EndTransaction(); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return
+	}
+
+	after.run()
 }
@@ -5,6 +5,7 @@ import (
 	"abbysoft/gardarike-online/model/consts"
 	rpc "abbysoft/gardarike-online/rpc/generated"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -32,16 +33,38 @@ func TestSimpleLogic_SelectCharacter(t *testing.T) {
 	db.On("GetCharacter", int64(2)).Return(character, nil)
 	db.On("GetTowns", character.Name).Return(towns, nil)
 
+	logic.config.ChunkSize = 10
+	logic.config.ChatRegionSize = 4
+	neighbours := []model.Town{towns[0], {ID: 7, X: 60, Y: 5, OwnerName: "neighbour"}, {ID: 8, X: 500, Y: 5}}
+	db.On("GetTownsForRect", mock.Anything).Return(neighbours, nil)
+	db.On("AddChatMessage", mock.MatchedBy(func(message model.ChatMessage) bool {
+		return message.IsSystem && message.Channel == consts.GlobalChatChannel
+	})).Return(int64(3), nil)
+
 	resp, err := logic.SelectCharacter(session, request)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
-	require.Equal(t, 1, len(logic.EventsChan))
+	require.Equal(t, 2, len(logic.EventsChan))
 
 	event := <-logic.EventsChan
-	require.Equal(t, model.NewSystemChatMessageEvent(consts.MessageCharacterAuthorized(character.Name)), event)
+	require.Equal(t, consts.GlobalTopic, event.Topic)
+	require.Equal(t, consts.MessageCharacterAuthorized(character.Name), event.Event.GetChatMessageEvent().Message.Text)
+
+	event = <-logic.EventsChan
+	require.Equal(t, consts.GlobalTopic, event.Topic)
+	require.Equal(t, character.Name, event.Event.GetCharacterStatusEvent().Name)
+	require.True(t, event.Event.GetCharacterStatusEvent().Online)
 
 	require.NotEmpty(t, resp.Towns)
 	require.Equal(t, model.ResourcesPlaceTown.ToRPC(), resp.Resources)
+	require.Equal(t, []string{
+		consts.GlobalTopic,
+		session.EventTopic,
+		logic.topicTokens.Private("CHARACTER/2/"),
+		logic.topicTokens.Private("CHAT/REGION/0/0/"),
+		logic.topicTokens.Private("CHAT/TOWN/1/"),
+		logic.topicTokens.Private("CHAT/TOWN/7/"),
+	}, resp.EventTopics)
 
 	db.AssertExpectations(t)
 }
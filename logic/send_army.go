@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// SendArmy - units leave the town garrison and march to the destination
+func (s *SimpleLogic) SendArmy(session *PlayerSession, request *rpc.SendArmyRequest) (*rpc.SendArmyResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":   request.SessionID,
+		"townID":      request.TownID,
+		"units":       request.Units,
+		"destination": request.Destination,
+		"capture":     request.Capture,
+	}).Info("SendArmy")
+
+	units := model.NewArmyFromRPC(request.Units)
+	if units.IsEmpty() || (request.Capture && request.Destination.GetTownID() == 0) {
+		return nil, model.ErrBadRequest
+	}
+
+	character := session.SelectedCharacter
+	town, found := character.Town(request.TownID)
+	if !found {
+		return nil, model.ErrTownNotFound
+	}
+
+	tx := session.Tx
+
+	garrisons, err := tx.GetCharacterArmy(character.Name)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get character army")
+		return nil, model.ErrInternalServerError
+	}
+
+	if !garrisons[town.ID].IsEnough(units) {
+		return nil, model.ErrNotEnoughUnits
+	}
+
+	march := model.March{
+		CharacterID:   character.ID,
+		CharacterName: character.Name,
+		HomeTownID:    town.ID,
+		Units:         units,
+		Capture:       request.Capture,
+	}
+
+	attack, modelErr := s.departMarch(&march, town.Location(), request.Destination, session)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	// Marching units stay in the character's army (and eat its food), only the garrison changes
+	if err := tx.RemoveTownUnits(town.ID, units); err != nil {
+		s.log.WithError(err).Error("Failed to remove town units")
+		return nil, model.ErrInternalServerError
+	}
+
+	if march.ID, err = tx.AddMarch(march); err != nil {
+		s.log.WithError(err).Error("Failed to add march")
+		return nil, model.ErrInternalServerError
+	}
+
+	if attack {
+		if err := s.dropAttackerProtection(session); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	s.publishMarchPosition(march, now)
+
+	return &rpc.SendArmyResponse{
+		March: march.ToRPC(now),
+	}, nil
+}
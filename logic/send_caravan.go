@@ -0,0 +1,114 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// caravanTravelTime - returns time needed to get from one town to another
+func (s *SimpleLogic) caravanTravelTime(from model.Town, to model.Town) time.Duration {
+	distance := geometry.Distance(from.Location(), to.Location())
+	return time.Duration(distance * float64(s.config.CaravanTileTravelTime))
+}
+
+func (s *SimpleLogic) SendCaravan(session *PlayerSession, request *rpc.SendCaravanRequest) (*rpc.SendCaravanResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID":  request.SessionID,
+		"fromTownID": request.FromTownID,
+		"toTownID":   request.ToTownID,
+		"resources":  request.Resources,
+	}).Info("SendCaravan")
+
+	character := session.SelectedCharacter
+	resources := model.NewResourcesFromRPC(request.Resources)
+
+	if resources.IsEmpty() || request.FromTownID == request.ToTownID {
+		return nil, model.ErrBadRequest
+	}
+
+	var from *model.Town
+	for i := range character.Towns {
+		if character.Towns[i].ID == request.FromTownID {
+			from = &character.Towns[i]
+			break
+		}
+	}
+
+	if from == nil {
+		return nil, model.ErrTownNotFound
+	}
+
+	tx := session.Tx
+
+	tx.SetAutoRollBack(false)
+	to, err := tx.GetTown(request.ToTownID)
+	tx.SetAutoRollBack(true)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrTownNotFound
+	} else if err != nil {
+		s.log.WithError(err).Error("Failed to get town")
+		return nil, model.ErrInternalServerError
+	}
+
+	receiver, err := s.findCharacter(to.OwnerName, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get character")
+		return nil, model.ErrInternalServerError
+	}
+
+	if receiver == nil {
+		return nil, model.ErrCharacterNotFound
+	}
+
+	state, err := s.characterRelation(character, receiver, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get diplomacy relation")
+		return nil, model.ErrInternalServerError
+	}
+
+	if state.IsHostile() {
+		return nil, model.ErrForbidden
+	}
+
+	before := character.Resources.Clone()
+	if !character.Resources.Subtract(resources) {
+		return nil, model.ErrNotEnoughResources
+	}
+
+	now := time.Now()
+	caravan := model.Caravan{
+		OwnerID:      character.ID,
+		OwnerName:    character.Name,
+		ReceiverID:   receiver.ID,
+		ReceiverName: receiver.Name,
+		FromTownID:   from.ID,
+		ToTownID:     to.ID,
+		Resources:    resources,
+		Status:       model.CaravanEnRoute,
+		DepartedAt:   now,
+		ArrivesAt:    now.Add(s.caravanTravelTime(*from, to)),
+	}
+
+	caravan.ID, err = tx.AddCaravan(caravan)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to add caravan")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := tx.AddOrUpdateResources(character.ID, character.Resources); err != nil {
+		s.log.WithError(err).Error("Failed to update character resources")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.publishEvent(model.NewResourcesChangedEvent(character.ID, before, character.Resources))
+
+	return &rpc.SendCaravanResponse{
+		Caravan: caravan.ToRPC(),
+	}, nil
+}
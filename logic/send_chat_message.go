@@ -1,26 +1,81 @@
 package logic
 
 import (
+	"abbysoft/gardarike-online/db"
 	"abbysoft/gardarike-online/model"
 	"abbysoft/gardarike-online/model/consts"
 	rpc "abbysoft/gardarike-online/rpc/generated"
 	log "github.com/sirupsen/logrus"
 )
 
+// sendSystemChatMessage - saves the system message to the global channel history and publishes it
+func (s *SimpleLogic) sendSystemChatMessage(text string, tx db.DatabaseTransaction) error {
+	return s.sendChannelSystemMessage(model.GlobalChatChannel(), text, tx)
+}
+
+// sendChannelSystemMessage - saves the system message to the channel history and publishes it
+func (s *SimpleLogic) sendChannelSystemMessage(channel model.ChatChannel, text string, tx db.DatabaseTransaction) error {
+	message := model.ChatMessage{
+		Sender:   consts.SystemUserName,
+		Text:     text,
+		Channel:  channel.Name(),
+		IsSystem: true,
+	}
+
+	id, err := tx.AddChatMessage(message)
+	if err != nil {
+		return err
+	}
+
+	message.ID = id
+	s.publishEvent(model.NewChatMessageEvent(message))
+
+	return nil
+}
+
 func (s *SimpleLogic) SendChatMessage(session *PlayerSession, request *rpc.SendChatMessageRequest) (*rpc.SendChatMessageResponse, model.Error) {
 	s.log.WithFields(log.Fields{
 		"sessionID": request.SessionID,
 		"text":      request.Text,
+		"channel":   request.Channel,
 	}).Info("SendChatMessage")
 
 	if len(request.Text) > s.config.ChatMessageMaxLength {
 		return nil, model.ErrMessageTooLong
 	}
 
+	// Muted and flooding characters can't run the commands either
+	if err := s.checkChatMute(session); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkChatRateLimit(session); err != nil {
+		return nil, err
+	}
+
+	if isChatCommand(request.Text) {
+		if err := s.executeChatCommand(session, request.Text); err != nil {
+			return nil, err
+		}
+
+		return &rpc.SendChatMessageResponse{}, nil
+	}
+
+	channel, err := s.getChatChannel(session, request.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := s.moderateChatMessage(session, request.Text)
+	if err != nil {
+		return nil, err
+	}
+
 	message := model.ChatMessage{
-		ID:     0,
-		Sender: session.SelectedCharacter.Name,
-		Text:   request.Text,
+		ID:      0,
+		Sender:  session.SelectedCharacter.Name,
+		Text:    text,
+		Channel: channel.Name(),
 	}
 
 	if insertedID, err := session.Tx.AddChatMessage(message); err != nil {
@@ -30,10 +85,7 @@ func (s *SimpleLogic) SendChatMessage(session *PlayerSession, request *rpc.SendC
 		message.ID = insertedID
 	}
 
-	s.EventsChan <- model.EventWrapper{
-		Topic: consts.GlobalTopic,
-		Event: model.NewChatMessageEvent(message).Event,
-	}
+	s.publishEvent(model.NewChatMessageEvent(message))
 
 	return &rpc.SendChatMessageResponse{
 		MessageID: message.ID,
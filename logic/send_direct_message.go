@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+func (s *SimpleLogic) SendDirectMessage(session *PlayerSession, request *rpc.SendDirectMessageRequest) (*rpc.SendDirectMessageResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"recipient": request.Recipient,
+		"text":      request.Text,
+	}).Info("SendDirectMessage")
+
+	if len(request.Text) > s.config.ChatMessageMaxLength {
+		return nil, model.ErrMessageTooLong
+	}
+
+	if err := s.checkChatMute(session); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkChatRateLimit(session); err != nil {
+		return nil, err
+	}
+
+	id, err := s.sendDirectMessage(session, request.Recipient, request.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpc.SendDirectMessageResponse{
+		MessageID: id,
+	}, nil
+}
+
+// sendDirectMessage - saves the message and delivers it to both characters. The caller has already checked
+// the mute and the flood limit of the session character
+func (s *SimpleLogic) sendDirectMessage(session *PlayerSession, recipientName string, text string) (int64, model.Error) {
+	sender := session.SelectedCharacter
+	if text == "" || recipientName == sender.Name {
+		return 0, model.ErrBadRequest
+	}
+
+	text, modErr := s.moderateChatMessage(session, text)
+	if modErr != nil {
+		return 0, modErr
+	}
+
+	recipient, err := s.findCharacter(recipientName, session.Tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get message recipient")
+		return 0, model.ErrInternalServerError
+	}
+
+	if recipient == nil {
+		return 0, model.ErrRecipientNotFound
+	}
+
+	message := model.DirectMessage{
+		Sender:    sender.Name,
+		Recipient: recipient.Name,
+		Text:      text,
+		CreatedAt: time.Now(),
+	}
+
+	if message.ID, err = session.Tx.AddDirectMessage(message); err != nil {
+		s.log.WithError(err).Error("Failed to add direct message")
+		return 0, model.ErrInternalServerError
+	}
+
+	// Sender receives the message too, so all of their sessions show the conversation
+	s.publishEvent(model.NewDirectMessageEvent(recipient.ID, message))
+	s.publishEvent(model.NewDirectMessageEvent(sender.ID, message))
+
+	return message.ID, nil
+}
@@ -0,0 +1,136 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSimpleLogic_SendDirectMessage(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "sender"}
+
+	db.On("GetChatMute", "sender").Return(model.ChatMute{}, sql.ErrNoRows)
+	db.On("GetCharacterByName", "recipient").Return(model.Character{ID: 2, Name: "recipient"}, nil)
+	db.On("AddDirectMessage", mock.MatchedBy(func(message model.DirectMessage) bool {
+		return message.Sender == "sender" && message.Recipient == "recipient" && message.Text == "hello"
+	})).Return(int64(10), nil)
+
+	resp, err := logic.SendDirectMessage(session, &rpc.SendDirectMessageRequest{
+		Recipient: "recipient",
+		Text:      "hello",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), resp.MessageID)
+
+	require.Equal(t, 2, len(logic.EventsChan))
+
+	// the message is delivered to the recipient's private topic, not to the topic built from its ID
+	event := <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(2)), event.Topic)
+	require.NotEqual(t, model.CharacterTopic(2), event.Topic)
+	require.Equal(t, int64(10), event.Event.GetDirectMessageEvent().Message.Id)
+
+	event = <-logic.EventsChan
+	require.Equal(t, logic.topicTokens.Private(model.CharacterTopic(1)), event.Topic)
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_SendDirectMessage_RecipientNotFound(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "sender"}
+
+	db.On("GetChatMute", "sender").Return(model.ChatMute{}, sql.ErrNoRows)
+	db.On("GetCharacterByName", "nobody").Return(model.Character{}, sql.ErrNoRows)
+
+	resp, err := logic.SendDirectMessage(session, &rpc.SendDirectMessageRequest{
+		Recipient: "nobody",
+		Text:      "hello",
+	})
+	require.EqualError(t, err, model.ErrRecipientNotFound.Error())
+	require.Nil(t, resp)
+	require.Equal(t, 0, len(logic.EventsChan))
+}
+
+func TestSimpleLogic_SendDirectMessage_Muted(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "sender"}
+
+	mute := model.ChatMute{CharacterName: "sender", ExpiresAt: time.Now().Add(time.Hour), Reason: "spam"}
+	db.On("GetChatMute", "sender").Return(mute, nil)
+
+	resp, err := logic.SendDirectMessage(session, &rpc.SendDirectMessageRequest{
+		Recipient: "recipient",
+		Text:      "hello",
+	})
+	require.EqualError(t, err, model.ErrCharacterMuted.Error())
+	require.Nil(t, resp)
+
+	// Only the notice to the muted sender is published
+	require.Equal(t, 1, len(logic.EventsChan))
+	notice := <-logic.EventsChan
+	require.Equal(t, session.EventTopic, notice.Topic)
+	db.AssertNotCalled(t, "AddDirectMessage", mock.Anything)
+}
+
+func TestSimpleLogic_SendDirectMessage_ToYourself(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	mockNotMuted(db)
+	logic.config.ChatMessageMaxLength = 100
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "sender"}
+
+	resp, err := logic.SendDirectMessage(session, &rpc.SendDirectMessageRequest{
+		Recipient: "sender",
+		Text:      "hello",
+	})
+	require.EqualError(t, err, model.ErrBadRequest.Error())
+	require.Nil(t, resp)
+}
+
+func TestSimpleLogic_GetDirectMessages_MarksRead(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "reader"}
+
+	messages := []model.DirectMessage{
+		{ID: 2, Sender: "friend", Recipient: "reader", Text: "how are you?"},
+		{ID: 1, Sender: "reader", Recipient: "friend", Text: "hi", IsRead: true},
+	}
+
+	db.On("GetDirectMessages", "reader", "friend", 0, 10).Return(messages, nil)
+	db.On("MarkDirectMessagesRead", "reader", "friend").Return(nil)
+
+	resp, err := logic.GetDirectMessages(session, &rpc.GetDirectMessagesRequest{Character: "friend"})
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 2)
+	require.False(t, resp.Messages[0].IsRead, "messages should be returned with the state before reading")
+
+	db.AssertExpectations(t)
+}
+
+func TestSimpleLogic_GetDirectMessages_Paging(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "reader"}
+
+	_, err := logic.GetDirectMessages(session, &rpc.GetDirectMessagesRequest{Character: "friend", Offset: -1})
+	require.EqualError(t, err, model.ErrBadRequest.Error())
+
+	_, err = logic.GetDirectMessages(session, &rpc.GetDirectMessagesRequest{Character: "friend", Count: -1})
+	require.EqualError(t, err, model.ErrBadRequest.Error())
+
+	db.On("GetDirectMessages", "reader", "friend", 20, consts.DefaultDirectMessagesMaxPageSize).
+		Return([]model.DirectMessage{}, nil)
+	db.On("MarkDirectMessagesRead", "reader", "friend").Return(nil)
+
+	_, err = logic.GetDirectMessages(session, &rpc.GetDirectMessagesRequest{Character: "friend", Offset: 20, Count: 1000})
+	require.NoError(t, err)
+	db.AssertExpectations(t)
+}
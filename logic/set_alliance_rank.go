@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) SetAllianceRank(session *PlayerSession, request *rpc.SetAllianceRankRequest) (*rpc.SetAllianceRankResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"character": request.Character,
+		"rank":      request.Rank,
+	}).Info("SetAllianceRank")
+
+	rank := model.AllianceRank(request.Rank)
+	if !rank.IsValid() || rank == model.AllianceRankLeader {
+		return nil, model.ErrBadRequest
+	}
+
+	leader, modelErr := s.checkAlliancePermission(session, model.AlliancePermissionSetRank)
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	if request.Character == session.SelectedCharacter.Name {
+		return nil, model.ErrForbidden
+	}
+
+	tx := session.Tx
+
+	member, err := s.getAllianceMember(request.Character, tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get alliance member")
+		return nil, model.ErrInternalServerError
+	}
+
+	if member == nil || member.AllianceID != leader.AllianceID {
+		return nil, model.ErrNotInAlliance
+	}
+
+	if err := tx.UpdateAllianceMemberRank(member.CharacterName, rank); err != nil {
+		s.log.WithError(err).Error("Failed to update alliance member rank")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := s.sendAllianceSystemMessage(member.AllianceID,
+		consts.MessageAllianceRankChanged(member.CharacterName, rank.String()), tx); err != nil {
+		return nil, err
+	}
+
+	return &rpc.SetAllianceRankResponse{}, nil
+}
@@ -0,0 +1,29 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) SetTaxRate(session *PlayerSession, request *rpc.SetTaxRateRequest) (*rpc.SetTaxRateResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"taxRate":   request.TaxRate,
+	}).Info("SetTaxRate")
+
+	if request.TaxRate > consts.MaxTaxRate {
+		return nil, model.ErrBadRequest
+	}
+
+	char := session.SelectedCharacter
+	char.TaxRate = request.TaxRate
+
+	if err := session.Tx.UpdateCharacter(*char); err != nil {
+		s.log.WithError(err).Error("Failed to update character")
+		return nil, model.ErrInternalServerError
+	}
+
+	return &rpc.SetTaxRateResponse{Happiness: char.Happiness()}, nil
+}
@@ -0,0 +1,29 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSimpleLogic_SetTaxRate(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 1, Name: "test", TaxRate: 10}
+
+	db.On("UpdateCharacter", mock.MatchedBy(func(character model.Character) bool {
+		return character.TaxRate == 40
+	})).Return(nil)
+
+	resp, err := logic.SetTaxRate(session, &rpc.SetTaxRateRequest{TaxRate: 40})
+	require.NoError(t, err)
+	require.Equal(t, uint32(60), resp.Happiness)
+	require.Equal(t, uint32(40), session.SelectedCharacter.TaxRate)
+
+	_, err = logic.SetTaxRate(session, &rpc.SetTaxRateRequest{TaxRate: 101})
+	require.Equal(t, model.ErrBadRequest, err)
+	require.Equal(t, uint32(40), session.SelectedCharacter.TaxRate)
+
+	db.AssertExpectations(t)
+}
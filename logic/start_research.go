@@ -0,0 +1,61 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+func (s *SimpleLogic) StartResearch(session *PlayerSession, request *rpc.StartResearchRequest) (*rpc.StartResearchResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"tech":      request.Tech,
+	}).Info("StartResearch")
+
+	character := session.SelectedCharacter
+
+	tech, found := s.techTree[request.Tech]
+	if !found || character.Techs[tech.ID] {
+		return nil, model.ErrBadRequest
+	}
+
+	if !tech.IsAvailable(character.Techs) {
+		return nil, model.ErrTechNotResearched
+	}
+
+	if _, err := s.getActiveResearch(session); err == nil {
+		return nil, model.ErrResearchInProgress
+	} else if err != model.ErrResearchNotFound {
+		return nil, err
+	}
+
+	before := character.Resources.Clone()
+	if !character.Resources.Subtract(tech.Cost) {
+		return nil, model.ErrNotEnoughResources
+	}
+
+	now := time.Now()
+	research := model.Research{
+		CharacterID: character.ID,
+		Tech:        tech.ID,
+		StartedAt:   now,
+		FinishesAt:  now.Add(tech.ResearchTime),
+	}
+
+	if err := session.Tx.AddResearch(research); err != nil {
+		s.log.WithError(err).Error("Failed to add research")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := session.Tx.AddOrUpdateResources(character.ID, character.Resources); err != nil {
+		s.log.WithError(err).Error("Failed to update character resources")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.publishEvent(model.NewResourcesChangedEvent(character.ID, before, character.Resources))
+
+	return &rpc.StartResearchResponse{
+		Research: research.ToRPC(),
+	}, nil
+}
@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"fmt"
+	"github.com/spf13/viper"
+	"time"
+)
+
+// techData - tech as it's described in the tech tree data file
+type techData struct {
+	ID              string
+	Name            string
+	Cost            map[string]uint64
+	ResearchTime    time.Duration
+	Requires        []string
+	Buildings       []string
+	ProductionBonus map[string]uint64
+}
+
+// LoadTechTree - reads the techs from the [[tech]] tables of the toml data file
+func LoadTechTree(path string) (model.TechTree, error) {
+	data := viper.New()
+	data.SetConfigFile(path)
+
+	if err := data.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var techs []techData
+	if err := data.UnmarshalKey("tech", &techs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	result := make([]model.Tech, 0, len(techs))
+	for _, tech := range techs {
+		converted, err := tech.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("invalid tech %s: %w", tech.ID, err)
+		}
+
+		result = append(result, converted)
+	}
+
+	return model.NewTechTree(result)
+}
+
+func (t techData) toModel() (result model.Tech, err error) {
+	result = model.Tech{
+		ID:           t.ID,
+		Name:         t.Name,
+		ResearchTime: t.ResearchTime,
+		Requires:     t.Requires,
+	}
+
+	if len(t.ID) == 0 {
+		return result, fmt.Errorf("tech id is empty")
+	}
+
+	if result.Cost, err = resourcesFromData(t.Cost); err != nil {
+		return result, fmt.Errorf("invalid cost: %w", err)
+	}
+
+	if result.ProductionBonus, err = resourcesFromData(t.ProductionBonus); err != nil {
+		return result, fmt.Errorf("invalid production bonus: %w", err)
+	}
+
+	for _, name := range t.Buildings {
+		building, found := rpc.BuildingType_value[name]
+		if !found {
+			return result, fmt.Errorf("unknown building %s", name)
+		}
+
+		result.Buildings = append(result.Buildings, rpc.BuildingType(building))
+	}
+
+	return result, nil
+}
+
+func resourcesFromData(data map[string]uint64) (model.Resources, error) {
+	result := model.Resources{}
+	for name, amount := range data {
+		resourceType := model.ResourceType(name)
+		if !resourceType.IsValid() {
+			return nil, fmt.Errorf("unknown resource %s", name)
+		}
+
+		result.Add(model.NewResources(resourceType, amount))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/db"
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"math"
+)
+
+const (
+	// Number of samples along every side of the chunk used to estimate area of the territories
+	territorySamplesPerSide = 8
+)
+
+// territoryOwner - returns the town which territory contains the tile or nil if the tile is free.
+// Tile covered by several territories belongs to the town which is relatively closer to it
+func territoryOwner(tile geometry.Point, towns []model.Town) *model.Town {
+	var owner *model.Town
+	bestRatio := math.MaxFloat64
+
+	for i := range towns {
+		town := &towns[i]
+		if !town.IsInTerritory(tile) {
+			continue
+		}
+
+		ratio := geometry.Distance(town.Location(), tile) / town.TerritoryRadius()
+		if ratio < bestRatio {
+			owner = town
+			bestRatio = ratio
+		}
+	}
+
+	return owner
+}
+
+// territoriesInRect - returns towns which territories cover at least one tile of the rectangle
+func territoriesInRect(rect geometry.Rect, towns []model.Town) (result []model.Town) {
+	for _, town := range towns {
+		if geometry.CircleIntersectsRect(town.Location(), town.TerritoryRadius(), rect) {
+			result = append(result, town)
+		}
+	}
+
+	return result
+}
+
+// territoryShares - returns part of the rectangle area (from 0 to 1) owned by every character
+func territoryShares(rect geometry.Rect, towns []model.Town) map[string]float64 {
+	shares := make(map[string]float64)
+
+	width := float64(rect.MaxX-rect.MinX+1) / territorySamplesPerSide
+	height := float64(rect.MaxY-rect.MinY+1) / territorySamplesPerSide
+	sampleShare := 1.0 / (territorySamplesPerSide * territorySamplesPerSide)
+
+	for i := 0; i < territorySamplesPerSide; i++ {
+		for j := 0; j < territorySamplesPerSide; j++ {
+			sample := geometry.Point{
+				X: rect.MinX + int64((float64(i)+0.5)*width),
+				Y: rect.MinY + int64((float64(j)+0.5)*height),
+			}
+
+			if owner := territoryOwner(sample, towns); owner != nil {
+				shares[owner.OwnerName] += sampleShare
+			}
+		}
+	}
+
+	return shares
+}
+
+// getTerritoriesForRect - returns towns which territories cover at least one tile of the rectangle
+func (s *SimpleLogic) getTerritoriesForRect(rect geometry.Rect, tx db.DatabaseTransaction) ([]model.Town, error) {
+	towns, err := tx.GetTownsForRect(rect.Expand(int64(math.Ceil(consts.TerritoryMaxRadius))))
+	if err != nil {
+		return nil, err
+	}
+
+	return territoriesInRect(rect, towns), nil
+}
+
+// getTileOwner - returns the town which controls the world tile or nil if the tile is free
+func (s *SimpleLogic) getTileOwner(tile geometry.Point, tx db.DatabaseTransaction) (*model.Town, error) {
+	towns, err := s.getTerritoriesForRect(geometry.RectAround(tile, 0), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return territoryOwner(tile, towns), nil
+}
+
+func (s *SimpleLogic) GetTileOwner(session *PlayerSession, request *rpc.GetTileOwnerRequest) (*rpc.GetTileOwnerResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"location":  request.Location,
+	}).Info("GetTileOwner")
+
+	if request.Location == nil {
+		return nil, model.ErrBadRequest
+	}
+
+	owner, err := s.getTileOwner(geometry.Point{X: int64(request.Location.X), Y: int64(request.Location.Y)}, session.Tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get tile owner")
+		return nil, model.ErrInternalServerError
+	}
+
+	response := &rpc.GetTileOwnerResponse{}
+	if owner != nil {
+		response.Town = owner.ToRPC()
+	}
+
+	return response, nil
+}
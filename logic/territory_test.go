@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTerritoryOwner(t *testing.T) {
+	towns := []model.Town{
+		{ID: 1, X: 0, Y: 0, OwnerName: "small"},
+		{ID: 2, X: 15, Y: 0, OwnerName: "big", Population: 200},
+	}
+
+	// Radius of the first town is 10 and the second is 20
+	assert.Equal(t, int64(1), territoryOwner(geometry.Point{X: -5, Y: 0}, towns).ID)
+	assert.Equal(t, int64(2), territoryOwner(geometry.Point{X: 30, Y: 0}, towns).ID)
+	// Covered by both, but relatively closer to the big town center (6/20 < 9/10)
+	assert.Equal(t, int64(2), territoryOwner(geometry.Point{X: 9, Y: 0}, towns).ID)
+	assert.Nil(t, territoryOwner(geometry.Point{X: 0, Y: 50}, towns))
+}
+
+func TestTerritoryShares(t *testing.T) {
+	towns := []model.Town{{X: 0, Y: 0, OwnerName: "owner", Population: 1000}}
+
+	shares := territoryShares(geometry.Rect{MinX: 0, MinY: 0, MaxX: 9, MaxY: 9}, towns)
+	assert.Equal(t, map[string]float64{"owner": 1}, shares)
+
+	shares = territoryShares(geometry.Rect{MinX: 100, MinY: 100, MaxX: 109, MaxY: 109}, towns)
+	assert.Empty(t, shares)
+}
+
+func TestResourceManager_Harvest(t *testing.T) {
+	logic, db, session := NewLogicMock()
+	logic.config.ChunkSize = 10
+	manager := NewResourceManager(logic)
+
+	session.SelectedCharacter = &model.Character{Name: "online", StorageCapacity: model.StorageCapacity(nil)}
+
+	db.On("GetAllTowns").Return([]model.Town{
+		{ID: 1, X: 5, Y: 5, OwnerName: "online", Population: 1000},
+		{ID: 2, X: 5005, Y: 5, OwnerName: "offline", Population: 1000},
+	}, nil)
+
+	chunkResources := model.ChunkResources{Trees: 100, Stones: 100, Animals: 100, Plants: 100}
+	db.On("GetMapChunksResources").Return([]model.WorldMapChunk{
+		{X: 0, Y: 0, ChunkResources: chunkResources},
+		{X: 500, Y: 0, ChunkResources: chunkResources},
+		{X: 1000, Y: 0, ChunkResources: chunkResources},
+	}, nil)
+
+	harvested := chunkResources.Scale(harvestFraction)
+	db.On("SubtractMapChunkResources", int64(0), int64(0), harvested).Return(nil)
+	db.On("SubtractMapChunkResources", int64(500), int64(0), harvested).Return(nil)
+	for _, owner := range []string{"online", "offline"} {
+		db.On("GetCharacterBuildings", owner).Return(model.CharacterBuildings{}, nil)
+		db.On("AddCharacterResources", owner, harvested.Harvested(), model.StorageCapacity(model.CharacterBuildings{})).Return(nil)
+	}
+
+	var after afterCommit
+	require.NoError(t, manager.harvest(db, &after))
+	after.run()
+
+	assert.Equal(t, harvested.Harvested(), session.SelectedCharacter.Resources)
+	db.AssertNotCalled(t, "SubtractMapChunkResources", int64(1000), int64(0), mock.Anything)
+	db.AssertExpectations(t)
+}
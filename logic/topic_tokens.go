@@ -0,0 +1,48 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"github.com/google/uuid"
+	"sync"
+)
+
+// TopicTokens - replaces the topics of the characters, alliances and chat channels with the unguessable ones.
+// Every such topic gets the random token on its first use, clients learn the tokens of their own
+// topics only from the character selection and alliance responses
+type TopicTokens struct {
+	mutex  sync.Mutex
+	tokens map[string]string // Published topic by the topic derived from the ID
+}
+
+func NewTopicTokens() *TopicTokens {
+	return &TopicTokens{
+		tokens: make(map[string]string),
+	}
+}
+
+// Private - returns the topic the events of the topic are published to, public topics are returned as is
+func (t *TopicTokens) Private(topic string) string {
+	prefix, private := model.PrivateTopicPrefix(topic)
+	if !private {
+		return topic
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	published, ok := t.tokens[topic]
+	if !ok {
+		published = prefix + uuid.New().String() + "/"
+		t.tokens[topic] = published
+	}
+
+	return published
+}
+
+// Rotate - replaces the token of the topic, subscribers of the old topic don't receive its events anymore
+func (t *TopicTokens) Rotate(topic string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.tokens, topic)
+}
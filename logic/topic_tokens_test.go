@@ -0,0 +1,56 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestTopicTokens_Private(t *testing.T) {
+	tokens := NewTopicTokens()
+
+	topic := tokens.Private(model.CharacterTopic(1))
+	require.NotEqual(t, model.CharacterTopic(1), topic)
+	require.True(t, strings.HasPrefix(topic, consts.CharacterTopicPrefix))
+	require.Equal(t, topic, tokens.Private(model.CharacterTopic(1)))
+	require.NotEqual(t, topic, tokens.Private(model.CharacterTopic(2)))
+
+	require.NotEqual(t, model.AllianceTopic(1), tokens.Private(model.AllianceTopic(1)))
+	require.NotEqual(t, model.AllianceChatChannel(1).Topic(), tokens.Private(model.AllianceChatChannel(1).Topic()))
+
+	require.Equal(t, consts.GlobalTopic, tokens.Private(consts.GlobalTopic))
+	require.NotEqual(t, model.TownChatChannel(1).Topic(), tokens.Private(model.TownChatChannel(1).Topic()))
+	require.NotEqual(t, model.RegionChatChannel(geometry.Point{}).Topic(),
+		tokens.Private(model.RegionChatChannel(geometry.Point{}).Topic()))
+	require.Equal(t, model.SessionTopic("token"), tokens.Private(model.SessionTopic("token")))
+}
+
+func TestTopicTokens_Rotate(t *testing.T) {
+	tokens := NewTopicTokens()
+
+	old := tokens.Private(model.AllianceTopic(1))
+	tokens.Rotate(model.AllianceTopic(1))
+
+	topic := tokens.Private(model.AllianceTopic(1))
+	require.NotEqual(t, old, topic)
+	require.Equal(t, topic, tokens.Private(model.AllianceTopic(1)))
+}
+
+func TestSimpleLogic_PublishEvent_PrivateTopic(t *testing.T) {
+	logic, _, session := NewLogicMock()
+	session.SelectedCharacter = &model.Character{ID: 5, Name: "test"}
+
+	logic.publishEvent(model.NewCharacterEvent(5, &rpc.Event{}))
+
+	event := <-logic.EventsChan
+	require.NotEqual(t, model.CharacterTopic(5), event.Topic)
+	require.Contains(t, logic.eventTopics(session), event.Topic)
+
+	// the topic derived from the ID is not available to anyone
+	_, err := logic.GetEventsSince(session, &rpc.GetEventsSinceRequest{Topic: model.CharacterTopic(5)})
+	require.Equal(t, model.ErrForbidden, err)
+}
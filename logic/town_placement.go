@@ -0,0 +1,155 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model"
+	"math"
+	"math/rand"
+)
+
+const (
+	// Number of the newest capitals the spawn search starts from
+	spawnAnchorCandidates = 5
+	// Number of locations checked on every ring of the spawn search
+	spawnRingLocations = 8
+)
+
+var neighbourOffsets = []geometry.Point{{X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: -1}, {X: 0, Y: 1}}
+
+// getSlopeAt - returns max height difference between the tile and its neighbours
+func (s *SimpleLogic) getSlopeAt(location geometry.Point, height float32, heights *heightMap) (float32, model.Error) {
+	slope := float32(0)
+
+	for _, offset := range neighbourOffsets {
+		neighbourHeight, err := heights.heightAt(geometry.Point{X: location.X + offset.X, Y: location.Y + offset.Y})
+		if err != nil {
+			return 0, err
+		}
+
+		slope = float32(math.Max(float64(slope), math.Abs(float64(neighbourHeight-height))))
+	}
+
+	return slope, nil
+}
+
+// isTooCloseToTown - checks if there are towns closer than config.MinTownDistance to the location
+func (s *SimpleLogic) isTooCloseToTown(location geometry.Point, session *PlayerSession) (bool, model.Error) {
+	distance := int64(s.config.MinTownDistance)
+	towns, err := session.Tx.GetTownsForRect(geometry.RectAround(location, distance))
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get towns around the location")
+		return false, model.ErrInternalServerError
+	}
+
+	for _, town := range towns {
+		if geometry.Distance(town.Location(), location) < float64(distance) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// checkTownLocation - checks that the town can be placed at the location.
+// Returns an error describing the first violated rule
+func (s *SimpleLogic) checkTownLocation(location geometry.Point, heights *heightMap, session *PlayerSession) model.Error {
+	height, err := heights.heightAt(location)
+	if err != nil {
+		return err
+	}
+
+	if height < s.config.WaterLevel {
+		return model.ErrLocationUnderWater
+	}
+
+	slope, err := s.getSlopeAt(location, height, heights)
+	if err != nil {
+		return err
+	}
+
+	if slope > s.config.MaxTownSlope {
+		return model.ErrTerrainTooSteep
+	}
+
+	tooClose, err := s.isTooCloseToTown(location, session)
+	if err != nil {
+		return err
+	}
+
+	if tooClose {
+		return model.ErrTownTooClose
+	}
+
+	return nil
+}
+
+// getSpawnAnchor - returns location around which the new town should be placed.
+// New players are placed near the other new players or at the spawn chunk center if there are no towns
+func (s *SimpleLogic) getSpawnAnchor(session *PlayerSession) (anchor geometry.Point, hasTowns bool, err model.Error) {
+	capitals, dbErr := session.Tx.GetNewestCapitals(spawnAnchorCandidates)
+	if dbErr != nil {
+		s.log.WithError(dbErr).Error("Failed to get newest capitals")
+		return anchor, false, model.ErrInternalServerError
+	}
+
+	if len(capitals) == 0 {
+		origin := s.grid().ChunkOrigin(geometry.Point{X: spawnChunkX, Y: spawnChunkY})
+		half := int64(s.config.ChunkSize / 2)
+
+		return geometry.Point{X: origin.X + half, Y: origin.Y + half}, false, nil
+	}
+
+	capital := capitals[rand.Intn(len(capitals))]
+	return capital.Location(), true, nil
+}
+
+// findSpawnLocation - searches a dry, flat and uncrowded location near the newest players.
+// Locations are checked on rings of growing radius around the anchor
+func (s *SimpleLogic) findSpawnLocation(session *PlayerSession) (geometry.Point, model.Error) {
+	anchor, hasTowns, err := s.getSpawnAnchor(session)
+	if err != nil {
+		return anchor, err
+	}
+
+	step := math.Max(float64(s.config.MinTownDistance), 1)
+	attempts := 0
+	heights := s.newHeightMap(session)
+
+	for ring := 0; attempts < s.config.SpawnSearchAttempts; ring++ {
+		// Anchor itself is occupied by the town
+		if ring == 0 && hasTowns {
+			continue
+		}
+
+		locations := spawnRingLocations
+		if ring == 0 {
+			locations = 1
+		}
+
+		angleOffset := rand.Float64() * 2 * math.Pi
+		for i := 0; i < locations && attempts < s.config.SpawnSearchAttempts; i++ {
+			attempts++
+
+			angle := angleOffset + 2*math.Pi*float64(i)/float64(locations)
+			location := geometry.Point{
+				X: anchor.X + int64(math.Round(math.Cos(angle)*step*float64(ring))),
+				Y: anchor.Y + int64(math.Round(math.Sin(angle)*step*float64(ring))),
+			}
+
+			err := s.checkTownLocation(location, heights, session)
+			if err == nil {
+				return location, nil
+			}
+
+			if err == model.ErrInternalServerError {
+				return location, err
+			}
+		}
+	}
+
+	s.log.WithField("anchor", anchor).
+		WithField("attempts", attempts).
+		Warn("Failed to find spawn location")
+
+	return anchor, model.ErrSpawnLocationNotFound
+}
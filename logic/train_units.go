@@ -0,0 +1,97 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// TrainUnits - adds a batch of units to the training queue of the town, the cost and the population
+// are taken at once, units are stationed in the town when the training is completed
+func (s *SimpleLogic) TrainUnits(session *PlayerSession, request *rpc.TrainUnitsRequest) (*rpc.TrainUnitsResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"townID":    request.TownID,
+		"unitType":  request.UnitType,
+		"count":     request.Count,
+	}).Info("TrainUnits")
+
+	unit, found := model.Units[request.UnitType]
+	if !found || request.Count == 0 {
+		return nil, model.ErrBadRequest
+	}
+
+	character := session.SelectedCharacter
+	if !character.HasTown(request.TownID) {
+		return nil, model.ErrTownNotFound
+	}
+
+	tx := session.Tx
+
+	buildings, err := tx.GetTownBuildings(request.TownID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get town buildings")
+		return nil, model.ErrInternalServerError
+	}
+
+	if buildings[rpc.BuildingType_BARRACKS] == 0 {
+		return nil, model.ErrBuildingRequired
+	}
+
+	population := unit.Population * request.Count
+	if character.CurrentPopulation < population {
+		return nil, model.ErrNotEnoughPopulation
+	}
+
+	cost := model.Resources{}
+	for resourceType, amount := range unit.Cost {
+		cost[resourceType] = amount * request.Count
+	}
+
+	before := character.Resources.Clone()
+	if !character.Resources.Subtract(cost) {
+		return nil, model.ErrNotEnoughResources
+	}
+
+	queue, err := tx.GetCharacterUnitTraining(character.ID)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get training queue")
+		return nil, model.ErrInternalServerError
+	}
+
+	// Batches of the town are trained one after another
+	startsAt := time.Now()
+	for _, queued := range queue {
+		if queued.TownID == request.TownID && queued.FinishesAt.After(startsAt) {
+			startsAt = queued.FinishesAt
+		}
+	}
+
+	training := model.UnitTraining{
+		CharacterID: character.ID,
+		TownID:      request.TownID,
+		UnitType:    unit.ID,
+		Count:       request.Count,
+		StartedAt:   startsAt,
+		FinishesAt:  startsAt.Add(unit.TrainingTime * time.Duration(request.Count)),
+	}
+
+	if training.ID, err = tx.AddUnitTraining(training); err != nil {
+		s.log.WithError(err).Error("Failed to add unit training")
+		return nil, model.ErrInternalServerError
+	}
+
+	character.CurrentPopulation -= population
+
+	if err := tx.UpdateCharacter(*character); err != nil {
+		s.log.WithError(err).Error("Failed to update character")
+		return nil, model.ErrInternalServerError
+	}
+
+	s.publishEvent(model.NewResourcesChangedEvent(character.ID, before, character.Resources))
+
+	return &rpc.TrainUnitsResponse{
+		Training: training.ToRPC(),
+	}, nil
+}
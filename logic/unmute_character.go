@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"abbysoft/gardarike-online/model"
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *SimpleLogic) UnmuteCharacter(session *PlayerSession, request *rpc.UnmuteCharacterRequest) (*rpc.UnmuteCharacterResponse, model.Error) {
+	s.log.WithFields(log.Fields{
+		"sessionID": request.SessionID,
+		"character": request.Character,
+	}).Info("UnmuteCharacter")
+
+	if !session.IsModerator {
+		return nil, model.ErrForbidden
+	}
+
+	target, err := s.findCharacter(request.Character, session.Tx)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get character")
+		return nil, model.ErrInternalServerError
+	}
+
+	if target == nil {
+		return nil, model.ErrCharacterNotFound
+	}
+
+	if err := session.Tx.RemoveChatMute(target.Name); err != nil {
+		s.log.WithError(err).Error("Failed to remove chat mute")
+		return nil, model.ErrInternalServerError
+	}
+
+	if err := s.logModeration(session, model.ModerationLogEntry{
+		ModeratorName: session.SelectedCharacter.Name,
+		Action:        model.ModerationActionUnmute,
+		TargetName:    target.Name,
+	}); err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(model.NewPrivateSystemChatMessageEvent(model.CharacterTopic(target.ID), consts.MessageUnmuted))
+
+	return &rpc.UnmuteCharacterResponse{}, nil
+}
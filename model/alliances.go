@@ -0,0 +1,98 @@
+package model
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"strings"
+	"time"
+)
+
+type AllianceRank int32
+
+const (
+	AllianceRankRecruit AllianceRank = iota
+	AllianceRankMember
+	AllianceRankOfficer
+	AllianceRankLeader
+)
+
+type AlliancePermission int
+
+const (
+	AlliancePermissionInvite AlliancePermission = iota
+	AlliancePermissionKick
+	AlliancePermissionSetRank
+	AlliancePermissionDisband
+	AlliancePermissionDiplomacy
+)
+
+var allianceRankPermissions = map[AllianceRank][]AlliancePermission{
+	AllianceRankOfficer: {AlliancePermissionInvite, AlliancePermissionKick},
+	AllianceRankLeader: {
+		AlliancePermissionInvite,
+		AlliancePermissionKick,
+		AlliancePermissionSetRank,
+		AlliancePermissionDisband,
+		AlliancePermissionDiplomacy,
+	},
+}
+
+func (r AllianceRank) IsValid() bool {
+	return r >= AllianceRankRecruit && r <= AllianceRankLeader
+}
+
+// Can - checks if the members of the rank have the permission
+func (r AllianceRank) Can(permission AlliancePermission) bool {
+	for _, granted := range allianceRankPermissions[r] {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r AllianceRank) String() string {
+	return strings.ToLower(rpc.AllianceRank(r).String())
+}
+
+func (r AllianceRank) ToRPC() rpc.AllianceRank {
+	return rpc.AllianceRank(r)
+}
+
+type Alliance struct {
+	ID        int64
+	Name      string
+	Tag       string
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (a Alliance) ToRPC() *rpc.Alliance {
+	return &rpc.Alliance{
+		Id:   a.ID,
+		Name: a.Name,
+		Tag:  a.Tag,
+	}
+}
+
+type AllianceMember struct {
+	AllianceID    int64  `db:"alliance_id"`
+	CharacterName string `db:"character_name"`
+	Rank          AllianceRank
+	JoinedAt      time.Time `db:"joined_at"`
+}
+
+func (m AllianceMember) ToRPC() *rpc.AllianceMember {
+	return &rpc.AllianceMember{
+		Character: m.CharacterName,
+		Rank:      m.Rank.ToRPC(),
+		JoinedAt:  m.JoinedAt.Unix(),
+	}
+}
+
+// AllianceInvite - the character can join the alliance until the invite is accepted or the alliance is disbanded
+type AllianceInvite struct {
+	AllianceID    int64     `db:"alliance_id"`
+	CharacterName string    `db:"character_name"`
+	InviterName   string    `db:"inviter_name"`
+	CreatedAt     time.Time `db:"created_at"`
+}
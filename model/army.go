@@ -0,0 +1,210 @@
+package model
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"sort"
+	"time"
+)
+
+type Unit struct {
+	ID             rpc.UnitType
+	Name           string
+	Cost           Resources
+	Population     uint64        // Citizens leaving the population to become one unit
+	TrainingTime   time.Duration // Time needed to train one unit
+	TileTravelTime time.Duration // Time needed to pass one flat dry tile
+	Upkeep         uint64        // Food eaten by one unit every tick
+	Attack         uint64
+	Defense        uint64
+	Counters       rpc.UnitType // Unit type this unit is strong against
+}
+
+var (
+	Units = map[rpc.UnitType]Unit{
+		rpc.UnitType_MILITIA: {
+			ID:             rpc.UnitType_MILITIA,
+			Name:           "militia",
+			Cost:           Resources{ResourceWood: 10, ResourceFood: 20},
+			Population:     1,
+			TrainingTime:   10 * time.Second,
+			TileTravelTime: 20 * time.Second,
+			Upkeep:         1,
+			Attack:         2,
+			Defense:        3,
+			Counters:       rpc.UnitType_CAVALRY,
+		},
+		rpc.UnitType_ARCHER: {
+			ID:             rpc.UnitType_ARCHER,
+			Name:           "archer",
+			Cost:           Resources{ResourceWood: 30, ResourceFood: 20, ResourceLeather: 10},
+			Population:     1,
+			TrainingTime:   20 * time.Second,
+			TileTravelTime: 20 * time.Second,
+			Upkeep:         1,
+			Attack:         5,
+			Defense:        2,
+			Counters:       rpc.UnitType_MILITIA,
+		},
+		rpc.UnitType_CAVALRY: {
+			ID:             rpc.UnitType_CAVALRY,
+			Name:           "cavalry",
+			Cost:           Resources{ResourceFood: 50, ResourceLeather: 30, ResourceIron: 20},
+			Population:     2,
+			TrainingTime:   45 * time.Second,
+			TileTravelTime: 8 * time.Second,
+			Upkeep:         3,
+			Attack:         8,
+			Defense:        6,
+			Counters:       rpc.UnitType_ARCHER,
+		},
+	}
+)
+
+// Army - number of units of each type
+type Army map[rpc.UnitType]uint64
+
+// Add - increments the number of units, initialises the army if it's nil
+func (a *Army) Add(units Army) {
+	for unitType, count := range units {
+		if count == 0 {
+			continue
+		}
+
+		if *a == nil {
+			*a = make(Army)
+		}
+
+		(*a)[unitType] += count
+	}
+}
+
+// NewArmyFromRPC - returns the units of the request, unknown unit types are skipped
+func NewArmyFromRPC(units []*rpc.UnitCount) Army {
+	result := Army{}
+	for _, unit := range units {
+		if IsValidUnitType(int32(unit.UnitType)) && unit.Count > 0 {
+			result[unit.UnitType] += unit.Count
+		}
+	}
+
+	return result
+}
+
+// Subtract - decrements the number of units, returns false and doesn't change the army if there are not enough units
+func (a Army) Subtract(units Army) bool {
+	if !a.IsEnough(units) {
+		return false
+	}
+
+	for unitType, count := range units {
+		a[unitType] -= count
+		if a[unitType] == 0 {
+			delete(a, unitType)
+		}
+	}
+
+	return true
+}
+
+func (a Army) IsEnough(units Army) bool {
+	for unitType, count := range units {
+		if a[unitType] < count {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a Army) IsEmpty() bool {
+	for _, count := range a {
+		if count > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TileTravelTime - the army moves with the speed of its slowest unit
+func (a Army) TileTravelTime() time.Duration {
+	var result time.Duration
+	for unitType, count := range a {
+		if travelTime := Units[unitType].TileTravelTime; count > 0 && travelTime > result {
+			result = travelTime
+		}
+	}
+
+	return result
+}
+
+// FoodUpkeep - food eaten by the army every tick
+func (a Army) FoodUpkeep() uint64 {
+	var result uint64
+	for unitType, count := range a {
+		result += Units[unitType].Upkeep * count
+	}
+
+	return result
+}
+
+// Count - total number of units
+func (a Army) Count() uint64 {
+	var result uint64
+	for _, count := range a {
+		result += count
+	}
+
+	return result
+}
+
+// unitTypes - types of the present units in the ascending order
+func (a Army) unitTypes() []rpc.UnitType {
+	types := make([]rpc.UnitType, 0, len(a))
+	for unitType, count := range a {
+		if count > 0 {
+			types = append(types, unitType)
+		}
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+func (a Army) ToRPC() []*rpc.UnitCount {
+	types := a.unitTypes()
+
+	result := make([]*rpc.UnitCount, 0, len(types))
+	for _, unitType := range types {
+		result = append(result, &rpc.UnitCount{UnitType: unitType, Count: a[unitType]})
+	}
+
+	return result
+}
+
+// UnitTraining - batch of units of one type trained in the town
+type UnitTraining struct {
+	ID          int64
+	CharacterID int64        `db:"character_id"`
+	TownID      int64        `db:"town_id"`
+	UnitType    rpc.UnitType `db:"unit_type"`
+	Count       uint64
+	StartedAt   time.Time `db:"started_at"`
+	FinishesAt  time.Time `db:"finishes_at"`
+}
+
+func (t UnitTraining) ToRPC() *rpc.UnitTraining {
+	return &rpc.UnitTraining{
+		Id:         t.ID,
+		TownID:     t.TownID,
+		UnitType:   t.UnitType,
+		Count:      t.Count,
+		StartedAt:  t.StartedAt.Unix(),
+		FinishesAt: t.FinishesAt.Unix(),
+	}
+}
+
+func IsValidUnitType(typeValue int32) bool {
+	_, found := rpc.UnitType_name[typeValue]
+	return found
+}
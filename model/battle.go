@@ -0,0 +1,171 @@
+package model
+
+import (
+	"abbysoft/gardarike-online/model/consts"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"math"
+	"time"
+)
+
+// Battle - the marching army attacks the garrison of the town
+type Battle struct {
+	Attacker Army
+	Defender Army
+	// Multipliers of the defender strength
+	TerrainBonus       float64
+	FortificationBonus float64
+}
+
+type BattleResult struct {
+	AttackerStrength float64
+	DefenderStrength float64
+	AttackerWon      bool
+	AttackerLosses   Army
+	DefenderLosses   Army
+}
+
+// TerrainDefenseBonus - defenders of the towns on the hills are stronger
+func TerrainDefenseBonus(height, waterLevel float32) float64 {
+	return 1 + math.Max(0, float64(height-waterLevel))*consts.BattleHillFactor
+}
+
+// DefenseBonus - multiplier of the garrison strength given by the fortifications of the town
+func (b CharacterBuildings) DefenseBonus() float64 {
+	var percents uint64
+	for buildingType, count := range b {
+		percents += Buildings[buildingType].DefenseBonus * count
+	}
+
+	return 1 + float64(percents)/100
+}
+
+// strength - total power of the army against the enemy. Every unit gets the counter bonus
+// proportional to the share of the enemy units it counters
+func (a Army) strength(enemy Army, power func(Unit) uint64) float64 {
+	enemyCount := enemy.Count()
+
+	result := 0.0
+	for _, unitType := range a.unitTypes() {
+		unit := Units[unitType]
+
+		bonus := 1.0
+		if enemyCount > 0 {
+			bonus += consts.BattleCounterBonus * float64(enemy[unit.Counters]) / float64(enemyCount)
+		}
+
+		result += float64(a[unitType]*power(unit)) * bonus
+	}
+
+	return result
+}
+
+// losses - units of every type lost by the army, the share is rounded to the nearest unit
+func (a Army) losses(share float64) Army {
+	result := Army{}
+	for unitType, count := range a {
+		if lost := uint64(math.Round(float64(count) * share)); lost > 0 {
+			result[unitType] = lost
+		}
+	}
+
+	return result
+}
+
+// Resolve - the stronger side wins, the defender wins the draw. The loser loses all units,
+// losses of the winner follow the square law: the closer the strengths, the more units are lost
+func (b Battle) Resolve() BattleResult {
+	result := BattleResult{
+		AttackerStrength: b.Attacker.strength(b.Defender, func(unit Unit) uint64 { return unit.Attack }),
+		DefenderStrength: b.Defender.strength(b.Attacker, func(unit Unit) uint64 { return unit.Defense }) *
+			b.TerrainBonus * b.FortificationBonus,
+	}
+
+	result.AttackerWon = result.AttackerStrength > result.DefenderStrength
+
+	winner, loser := result.AttackerStrength, result.DefenderStrength
+	if !result.AttackerWon {
+		winner, loser = loser, winner
+	}
+
+	winnerShare := 1.0
+	if winner > 0 {
+		ratio := loser / winner
+		winnerShare = 1 - math.Sqrt(1-ratio*ratio)
+	}
+
+	if result.AttackerWon {
+		result.AttackerLosses = b.Attacker.losses(winnerShare)
+		result.DefenderLosses = b.Defender.losses(1)
+	} else {
+		result.AttackerLosses = b.Attacker.losses(1)
+		result.DefenderLosses = b.Defender.losses(winnerShare)
+	}
+
+	return result
+}
+
+// Loot - share of the resources taken by the winning attacker
+func Loot(resources Resources) Resources {
+	result := Resources{}
+	for resourceType, amount := range resources {
+		if part := amount * consts.BattleLootPercent / 100; part > 0 {
+			result[resourceType] = part
+		}
+	}
+
+	return result
+}
+
+// BattleReport - outcome of the battle kept for both sides
+type BattleReport struct {
+	ID               int64
+	AttackerID       int64     `db:"attacker_id"`
+	AttackerName     string    `db:"attacker_name"`
+	DefenderID       int64     `db:"defender_id"`
+	DefenderName     string    `db:"defender_name"`
+	TownID           int64     `db:"town_id"`
+	TownName         string    `db:"town_name"`
+	AttackerUnits    Army      `db:"-"`
+	DefenderUnits    Army      `db:"-"`
+	AttackerLosses   Army      `db:"-"`
+	DefenderLosses   Army      `db:"-"`
+	AttackerStrength float64   `db:"attacker_strength"`
+	DefenderStrength float64   `db:"defender_strength"`
+	AttackerWon      bool      `db:"attacker_won"`
+	Loot             Resources `db:"-"`
+	Captured         bool
+	FoughtAt         time.Time `db:"fought_at"`
+}
+
+func NewBattleReport(battle Battle, result BattleResult) BattleReport {
+	return BattleReport{
+		AttackerUnits:    battle.Attacker,
+		DefenderUnits:    battle.Defender,
+		AttackerLosses:   result.AttackerLosses,
+		DefenderLosses:   result.DefenderLosses,
+		AttackerStrength: result.AttackerStrength,
+		DefenderStrength: result.DefenderStrength,
+		AttackerWon:      result.AttackerWon,
+		Loot:             Resources{},
+	}
+}
+
+func (r BattleReport) ToRPC() *rpc.BattleReport {
+	return &rpc.BattleReport{
+		Id:               r.ID,
+		Attacker:         r.AttackerName,
+		Defender:         r.DefenderName,
+		TownID:           r.TownID,
+		TownName:         r.TownName,
+		AttackerUnits:    r.AttackerUnits.ToRPC(),
+		DefenderUnits:    r.DefenderUnits.ToRPC(),
+		AttackerLosses:   r.AttackerLosses.ToRPC(),
+		DefenderLosses:   r.DefenderLosses.ToRPC(),
+		AttackerStrength: r.AttackerStrength,
+		DefenderStrength: r.DefenderStrength,
+		AttackerWon:      r.AttackerWon,
+		Loot:             r.Loot.ToRPC(),
+		Captured:         r.Captured,
+		FoughtAt:         r.FoughtAt.Unix(),
+	}
+}
@@ -1,6 +1,9 @@
 package model
 
-import rpc "abbysoft/gardarike-online/rpc/generated"
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"sort"
+)
 
 type Location2D struct {
 	X float32
@@ -14,13 +17,38 @@ func LocationFromRPC(d *rpc.Vector2D) Location2D {
 	}
 }
 
+func (l Location2D) ToRPC() *rpc.Vector2D {
+	return &rpc.Vector2D{
+		X: l.X,
+		Y: l.Y,
+	}
+}
+
 type Building struct {
 	ID              rpc.BuildingType
 	Name            string
 	Cost            Resources
+	GoldCost        uint64 // Alternative price of the building in gold
 	Production      Resources
+	Consumption     Resources // Inputs consumed every tick to get the production, empty for the raw production
 	Location        Location2D
 	PopulationBonus uint64
+	Storage         Resources // Increase of the owner's storage capacity
+	DefenseBonus    uint64    // Percent added to the strength of the town garrison
+}
+
+// IsProductionChain - checks if the building processes other resources
+func (b Building) IsProductionChain() bool {
+	return !b.Consumption.IsEmpty()
+}
+
+// Price - resources spent to construct the building, either the regular cost or its price in gold
+func (b Building) Price(payWithGold bool) Resources {
+	if payWithGold {
+		return Resources{ResourceGold: b.GoldCost}
+	}
+
+	return b.Cost
 }
 
 // CharacterBuildings - number of buildings of each type
@@ -31,20 +59,131 @@ var (
 		rpc.BuildingType_HOUSE: {
 			ID:              rpc.BuildingType_HOUSE,
 			Name:            "house",
-			Cost:            Resources{Wood: 30, Food: 10, Stone: 15, Leather: 20},
-			Production:      Resources{Food: 1},
+			Cost:            Resources{ResourceWood: 30, ResourceFood: 10, ResourceStone: 15, ResourceLeather: 20},
+			GoldCost:        40,
+			Production:      Resources{ResourceFood: 1},
 			PopulationBonus: 5,
 		},
 		rpc.BuildingType_QUARRY: {
 			ID:              rpc.BuildingType_QUARRY,
 			Name:            "quarry",
-			Cost:            Resources{Wood: 100, Food: 50, Stone: 0, Leather: 80},
-			Production:      Resources{Stone: 1},
+			Cost:            Resources{ResourceWood: 100, ResourceFood: 50, ResourceLeather: 80},
+			GoldCost:        120,
+			Production:      Resources{ResourceStone: 1},
 			PopulationBonus: 0,
 		},
+		rpc.BuildingType_WAREHOUSE: {
+			ID:       rpc.BuildingType_WAREHOUSE,
+			Name:     "warehouse",
+			Cost:     Resources{ResourceWood: 150, ResourceStone: 100, ResourceLeather: 20},
+			GoldCost: 140,
+			Storage: Resources{ResourceWood: 1000, ResourceStone: 1000, ResourceLeather: 1000,
+				ResourceIronOre: 1000, ResourceIron: 1000, ResourceTools: 1000, ResourceFurs: 1000},
+		},
+		rpc.BuildingType_GRANARY: {
+			ID:       rpc.BuildingType_GRANARY,
+			Name:     "granary",
+			Cost:     Resources{ResourceWood: 120, ResourceStone: 60, ResourceLeather: 10},
+			GoldCost: 110,
+			Storage:  Resources{ResourceFood: 1000, ResourceGrain: 1000, ResourceBread: 1000, ResourceHoney: 1000},
+		},
+		rpc.BuildingType_MINE: {
+			ID:         rpc.BuildingType_MINE,
+			Name:       "mine",
+			Cost:       Resources{ResourceWood: 120, ResourceFood: 40, ResourceStone: 40},
+			GoldCost:   100,
+			Production: Resources{ResourceIronOre: 1},
+		},
+		rpc.BuildingType_SMELTER: {
+			ID:          rpc.BuildingType_SMELTER,
+			Name:        "smelter",
+			Cost:        Resources{ResourceWood: 80, ResourceStone: 150},
+			GoldCost:    120,
+			Consumption: Resources{ResourceIronOre: 2, ResourceWood: 1},
+			Production:  Resources{ResourceIron: 1},
+		},
+		rpc.BuildingType_SMITHY: {
+			ID:          rpc.BuildingType_SMITHY,
+			Name:        "smithy",
+			Cost:        Resources{ResourceWood: 100, ResourceStone: 100, ResourceLeather: 20},
+			GoldCost:    110,
+			Consumption: Resources{ResourceIron: 1, ResourceWood: 1},
+			Production:  Resources{ResourceTools: 1},
+		},
+		rpc.BuildingType_FARM: {
+			ID:         rpc.BuildingType_FARM,
+			Name:       "farm",
+			Cost:       Resources{ResourceWood: 60, ResourceFood: 20},
+			GoldCost:   40,
+			Production: Resources{ResourceGrain: 2},
+		},
+		rpc.BuildingType_BAKERY: {
+			ID:          rpc.BuildingType_BAKERY,
+			Name:        "bakery",
+			Cost:        Resources{ResourceWood: 60, ResourceStone: 60},
+			GoldCost:    60,
+			Consumption: Resources{ResourceGrain: 2},
+			Production:  Resources{ResourceBread: 1},
+		},
+		rpc.BuildingType_HUNTING_LODGE: {
+			ID:         rpc.BuildingType_HUNTING_LODGE,
+			Name:       "hunting lodge",
+			Cost:       Resources{ResourceWood: 80, ResourceFood: 20},
+			GoldCost:   50,
+			Production: Resources{ResourceFurs: 1, ResourceLeather: 1},
+		},
+		rpc.BuildingType_APIARY: {
+			ID:         rpc.BuildingType_APIARY,
+			Name:       "apiary",
+			Cost:       Resources{ResourceWood: 40, ResourceFood: 10},
+			GoldCost:   25,
+			Production: Resources{ResourceHoney: 1},
+		},
+		rpc.BuildingType_BARRACKS: {
+			ID:       rpc.BuildingType_BARRACKS,
+			Name:     "barracks",
+			Cost:     Resources{ResourceWood: 200, ResourceStone: 150, ResourceLeather: 50},
+			GoldCost: 180,
+		},
+		rpc.BuildingType_WALLS: {
+			ID:           rpc.BuildingType_WALLS,
+			Name:         "walls",
+			Cost:         Resources{ResourceWood: 100, ResourceStone: 300},
+			GoldCost:     200,
+			DefenseBonus: 20,
+		},
 	}
 )
 
+// RunProductionChains - every production chain building of the character consumes its inputs
+// and produces the outputs. Buildings are idle when there is not enough inputs or there is no room
+// for the outputs. Buildings are processed in the order of their types, so outputs of the earlier
+// chains can be used by the later ones in the same tick
+func (c *Character) RunProductionChains() {
+	types := make([]rpc.BuildingType, 0, len(c.Buildings))
+	for buildingType := range c.Buildings {
+		types = append(types, buildingType)
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	for _, buildingType := range types {
+		building := Buildings[buildingType]
+		if !building.IsProductionChain() {
+			continue
+		}
+
+		for i := uint64(0); i < c.Buildings[buildingType]; i++ {
+			if !c.Resources.HasRoom(building.Production, c.StorageCapacity) ||
+				!c.Resources.Subtract(building.Consumption) {
+				break
+			}
+
+			c.AddResources(building.Production)
+		}
+	}
+}
+
 func IsValidBuildingType(typeValue int32) bool {
 	_, found := rpc.BuildingType_name[typeValue]
 	return found
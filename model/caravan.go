@@ -0,0 +1,56 @@
+package model
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"time"
+)
+
+type CaravanStatus int32
+
+const (
+	CaravanEnRoute CaravanStatus = iota
+	CaravanArrived
+	CaravanLost
+)
+
+// Caravan - resources on the way from the town of the owner to the town of the receiver
+type Caravan struct {
+	ID           int64
+	OwnerID      int64     `db:"owner_id"`
+	OwnerName    string    `db:"owner_name"`
+	ReceiverID   int64     `db:"receiver_id"`
+	ReceiverName string    `db:"receiver_name"`
+	FromTownID   int64     `db:"from_town_id"`
+	ToTownID     int64     `db:"to_town_id"`
+	Resources    Resources `db:"-"`
+	Status       CaravanStatus
+	DepartedAt   time.Time `db:"departed_at"`
+	ArrivesAt    time.Time `db:"arrives_at"`
+}
+
+// Lose - removes the resources from the cargo, the caravan is lost when nothing is left.
+// Returns the resources which were actually lost
+func (c *Caravan) Lose(resources Resources) Resources {
+	lost := resources.Min(c.Resources)
+	c.Resources.Subtract(lost)
+
+	if c.Resources.IsEmpty() {
+		c.Status = CaravanLost
+	}
+
+	return lost
+}
+
+func (c Caravan) ToRPC() *rpc.Caravan {
+	return &rpc.Caravan{
+		Id:         c.ID,
+		Owner:      c.OwnerName,
+		Receiver:   c.ReceiverName,
+		FromTownID: c.FromTownID,
+		ToTownID:   c.ToTownID,
+		Resources:  c.Resources.ToRPC(),
+		Status:     rpc.CaravanStatus(c.Status),
+		DepartedAt: c.DepartedAt.Unix(),
+		ArrivesAt:  c.ArrivesAt.Unix(),
+	}
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model/consts"
+	"fmt"
+)
+
+type ChatChannelType int
+
+const (
+	ChatChannelGlobal ChatChannelType = iota
+	ChatChannelRegion
+	ChatChannelTown
+	ChatChannelAlliance
+)
+
+// ChatChannel - chat channel identified by its name, e.g. GLOBAL, REGION/1/-2, TOWN/15 or ALLIANCE/3.
+// Region is a square area of the world map consisting of several chunks
+type ChatChannel struct {
+	Type   ChatChannelType
+	Region geometry.Point // Region coordinates of the region channel
+	ID     int64          // Town or alliance ID
+}
+
+func GlobalChatChannel() ChatChannel {
+	return ChatChannel{Type: ChatChannelGlobal}
+}
+
+func RegionChatChannel(region geometry.Point) ChatChannel {
+	return ChatChannel{Type: ChatChannelRegion, Region: region}
+}
+
+func TownChatChannel(townID int64) ChatChannel {
+	return ChatChannel{Type: ChatChannelTown, ID: townID}
+}
+
+func AllianceChatChannel(allianceID int64) ChatChannel {
+	return ChatChannel{Type: ChatChannelAlliance, ID: allianceID}
+}
+
+// ParseChatChannel - parses the channel name, empty name means the global channel
+func ParseChatChannel(name string) (channel ChatChannel, err error) {
+	if name == "" || name == consts.GlobalChatChannel {
+		return GlobalChatChannel(), nil
+	}
+
+	var x, y, id int64
+	if _, err := fmt.Sscanf(name, consts.RegionChatChannel, &x, &y); err == nil {
+		channel = RegionChatChannel(geometry.Point{X: x, Y: y})
+	} else if _, err := fmt.Sscanf(name, consts.TownChatChannel, &id); err == nil {
+		channel = TownChatChannel(id)
+	} else if _, err := fmt.Sscanf(name, consts.AllianceChatChannel, &id); err == nil {
+		channel = AllianceChatChannel(id)
+	} else {
+		return channel, fmt.Errorf("unknown chat channel %s", name)
+	}
+
+	// Names with trailing garbage (e.g. TOWN/1abc) are rejected
+	if channel.Name() != name {
+		return channel, fmt.Errorf("malformed chat channel name %s", name)
+	}
+
+	return channel, nil
+}
+
+func (c ChatChannel) Name() string {
+	switch c.Type {
+	case ChatChannelRegion:
+		return fmt.Sprintf(consts.RegionChatChannel, c.Region.X, c.Region.Y)
+	case ChatChannelTown:
+		return fmt.Sprintf(consts.TownChatChannel, c.ID)
+	case ChatChannelAlliance:
+		return fmt.Sprintf(consts.AllianceChatChannel, c.ID)
+	default:
+		return consts.GlobalChatChannel
+	}
+}
+
+// Topic - event topic of the channel messages, messages of the global channel go to the global topic.
+// Messages of other channels are published to the private topics known only to the channel members
+func (c ChatChannel) Topic() string {
+	if c.Type == ChatChannelGlobal {
+		return consts.GlobalTopic
+	}
+
+	return fmt.Sprintf(consts.ChatTopicFormat, c.Name())
+}
+
+// ChatChannelTopic - returns event topic of the channel with the name
+func ChatChannelTopic(name string) string {
+	channel, err := ParseChatChannel(name)
+	if err != nil {
+		return consts.GlobalTopic
+	}
+
+	return channel.Topic()
+}
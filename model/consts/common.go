@@ -1,7 +1,56 @@
 package consts
 
 const (
-	SystemUserName      = "Server"
-	GlobalTopic         = "GLOBAL"
+	SystemUserName = "Server"
+	GlobalTopic    = "GLOBAL"
+	// Private topics end with the delimiter, so ZMQ prefix matching can't mix up CHARACTER/1 and CHARACTER/12
+	CharacterTopicFormat = CharacterTopicPrefix + "%d/"
+	SessionTopicFormat   = "SESSION/%s/"
+	AllianceTopicFormat  = AllianceTopicPrefix + "%d/"
+	ChatTopicFormat      = ChatTopicPrefix + "%s/"
+	// Topics with these prefixes are derived from the IDs or the coordinates, their events are published
+	// to the topics with the random token in place of the ID
+	CharacterTopicPrefix = "CHARACTER/"
+	AllianceTopicPrefix  = "ALLIANCE/"
+	ChatTopicPrefix      = "CHAT/"
+
 	TownPopulationBonus = 100
+
+	// Town territory radius (in tiles) is growing with the town population and buildings
+	TerritoryBaseRadius        = 10.0
+	TerritoryPopulationPerTile = 20.0
+	TerritoryRadiusPerBuilding = 1.0
+	TerritoryMaxRadius         = 60.0
+
+	// Chat channel names, empty channel name means the global channel
+	GlobalChatChannel   = "GLOBAL"
+	RegionChatChannel   = "REGION/%d/%d"
+	TownChatChannel     = "TOWN/%d"
+	AllianceChatChannel = "ALLIANCE/%d"
+	// Characters which towns are closer (in tiles) to the town are members of the town chat channel
+	TownChatChannelRadius = 100
+
+	// Max duration (in seconds) of the chat mute, longer mutes overflow the expiration time
+	ChatMuteMaxDuration = 365 * 24 * 60 * 60
+
+	AllianceNameMaxLength = 40
+
+	// Tax rate is a percent of the population paying one gold every tick
+	MaxTaxRate   = 100
+	MaxHappiness = 100
+
+	// March of the army is slower on the slopes (per unit of the height difference between the tiles)
+	// and when crossing the water
+	MarchSlopeFactor = 20.0
+	MarchWaterFactor = 3.0
+
+	// Strength of the unit is increased by the bonus multiplied by the share of the enemy units it counters
+	BattleCounterBonus = 0.5
+	// Defenders are stronger on the hills (per unit of the town height above the water level)
+	BattleHillFactor = 0.5
+	// Percent of the defender's resources taken by the winning attacker
+	BattleLootPercent = 25
+
+	// Max number of orders of every side returned by GetOrderBook
+	MarketOrderBookMaxDepth = 50
 )
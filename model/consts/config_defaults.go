@@ -1,7 +1,29 @@
 package consts
 
+import "time"
+
 const (
-	DefaultMapChunkSize        = 500
-	DefaultWaterLevel          = 0.1
-	DefaultAlwaysRegenerateMap = false
+	DefaultMapChunkSize              = 500
+	DefaultWaterLevel                = 0.1
+	DefaultAlwaysRegenerateMap       = false
+	DefaultMaxViewportChunks         = 25
+	DefaultChunkCacheSize            = 256
+	DefaultPregenRadius              = 0
+	DefaultPregenWorkers             = 4
+	DefaultMinTownDistance           = 20
+	DefaultMaxTownSlope              = 0.5
+	DefaultSpawnSearchAttempts       = 100
+	DefaultEventReplayBufferSize     = 1000
+	DefaultEventsQueueSize           = 1000
+	DefaultChatRegionSize            = 4
+	DefaultChatRateLimit             = 5
+	DefaultChatRateWindow            = 10 * time.Second
+	DefaultChatHistoryMaxPageSize    = 50
+	DefaultDirectMessagesMaxPageSize = 50
+	DefaultCaravanTileTravelTime     = 10 * time.Second
+	DefaultTechTreeFile              = "configs/techs.toml"
+	DefaultMarchMaxDistance          = 500
+	DefaultNewbieProtection          = 72 * time.Hour
+	DefaultPeaceShieldDuration       = 24 * time.Hour
+	DefaultPeaceShieldGoldCost       = 500
 )
@@ -1,6 +1,10 @@
 package consts
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
 	// Messages
@@ -8,4 +12,57 @@ var (
 	MessageCharacterAuthorized = func(name string) string {
 		return fmt.Sprintf("\"%s\" enters the world!", name)
 	}
+
+	MessageChatRateLimited = "You are sending messages too fast, please wait a bit"
+	MessageWordsMasked     = "Your message contains forbidden words, they were masked"
+	MessageUnmuted         = "You can write to the chat again"
+
+	MessageUnknownChatCommand = func(name string) string {
+		return fmt.Sprintf("Unknown command /%s, type /help to see the list of commands", name)
+	}
+
+	MessageChatCommandUsage = func(usage string) string {
+		return fmt.Sprintf("Usage: %s", usage)
+	}
+
+	MessageKicked = "You were kicked from the server by the moderator"
+
+	MessageAllianceJoined = func(name string) string {
+		return fmt.Sprintf("\"%s\" joined the alliance", name)
+	}
+
+	MessageAllianceLeft = func(name string) string {
+		return fmt.Sprintf("\"%s\" left the alliance", name)
+	}
+
+	MessageAllianceMemberKicked = func(name string, officer string) string {
+		return fmt.Sprintf("\"%s\" was kicked from the alliance by \"%s\"", name, officer)
+	}
+
+	MessageKickedFromAlliance = func(alliance string) string {
+		return fmt.Sprintf("You were kicked from the alliance \"%s\"", alliance)
+	}
+
+	MessageAllianceRankChanged = func(name string, rank string) string {
+		return fmt.Sprintf("\"%s\" is now %s of the alliance", name, rank)
+	}
+
+	MessageAllianceDisbanded = func(alliance string) string {
+		return fmt.Sprintf("Alliance \"%s\" was disbanded", alliance)
+	}
+
+	MessageMuted = func(until time.Time, reason string) string {
+		message := fmt.Sprintf("You can't write to the chat until %s", until.UTC().Format(time.RFC822))
+		if reason != "" {
+			message += fmt.Sprintf(", reason: %s", reason)
+		}
+
+		return message
+	}
+
+	// Moderation log reasons
+
+	ModerationReasonBannedWords = func(words []string) string {
+		return fmt.Sprintf("banned words: %s", strings.Join(words, ", "))
+	}
 )
@@ -0,0 +1,184 @@
+package model
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"strings"
+	"time"
+)
+
+type DiplomacyState int32
+
+const (
+	DiplomacyNeutral DiplomacyState = iota
+	DiplomacyPeace
+	DiplomacyWar
+	DiplomacyNonAggression
+	DiplomacyAllied
+)
+
+func (s DiplomacyState) IsValid() bool {
+	return s >= DiplomacyNeutral && s <= DiplomacyAllied
+}
+
+// RequiresConsent - the state can be set only if both parties agree,
+// war and breaking of the treaty don't need the consent
+func (s DiplomacyState) RequiresConsent() bool {
+	return s == DiplomacyPeace || s == DiplomacyNonAggression || s == DiplomacyAllied
+}
+
+// IsHostile - the parties can attack each other
+func (s DiplomacyState) IsHostile() bool {
+	return s == DiplomacyWar
+}
+
+// CanAttack - armies of the parties fight only at war or without any treaty
+func (s DiplomacyState) CanAttack() bool {
+	return s == DiplomacyWar || s == DiplomacyNeutral
+}
+
+// IsFriendly - the parties can use each other's territory and can't attack each other
+func (s DiplomacyState) IsFriendly() bool {
+	return s == DiplomacyAllied
+}
+
+func (s DiplomacyState) String() string {
+	return strings.ToLower(strings.ReplaceAll(rpc.DiplomacyState(s).String(), "_", " "))
+}
+
+func (s DiplomacyState) ToRPC() rpc.DiplomacyState {
+	return rpc.DiplomacyState(s)
+}
+
+type DiplomacyPartyType int32
+
+const (
+	DiplomacyPartyCharacter DiplomacyPartyType = iota
+	DiplomacyPartyAlliance
+)
+
+// DiplomacyParty - the character (identified by name) or the alliance (identified by tag)
+type DiplomacyParty struct {
+	Type DiplomacyPartyType
+	Name string
+}
+
+func CharacterParty(name string) DiplomacyParty {
+	return DiplomacyParty{Type: DiplomacyPartyCharacter, Name: name}
+}
+
+func AllianceParty(tag string) DiplomacyParty {
+	return DiplomacyParty{Type: DiplomacyPartyAlliance, Name: tag}
+}
+
+func NewDiplomacyPartyFromRPC(party *rpc.DiplomacyParty) DiplomacyParty {
+	return DiplomacyParty{Type: DiplomacyPartyType(party.Type), Name: party.Name}
+}
+
+func (p DiplomacyParty) less(other DiplomacyParty) bool {
+	if p.Type != other.Type {
+		return p.Type < other.Type
+	}
+
+	return p.Name < other.Name
+}
+
+func (p DiplomacyParty) ToRPC() *rpc.DiplomacyParty {
+	return &rpc.DiplomacyParty{
+		Type: rpc.DiplomacyParty_Type(p.Type),
+		Name: p.Name,
+	}
+}
+
+// DiplomacyRelation - state between two parties, the parties are ordered so every pair is stored once
+type DiplomacyRelation struct {
+	FirstType  DiplomacyPartyType `db:"first_type"`
+	FirstName  string             `db:"first_name"`
+	SecondType DiplomacyPartyType `db:"second_type"`
+	SecondName string             `db:"second_name"`
+	State      DiplomacyState
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+func NewDiplomacyRelation(first, second DiplomacyParty, state DiplomacyState) DiplomacyRelation {
+	if second.less(first) {
+		first, second = second, first
+	}
+
+	return DiplomacyRelation{
+		FirstType:  first.Type,
+		FirstName:  first.Name,
+		SecondType: second.Type,
+		SecondName: second.Name,
+		State:      state,
+		UpdatedAt:  time.Now(),
+	}
+}
+
+func (r DiplomacyRelation) First() DiplomacyParty {
+	return DiplomacyParty{Type: r.FirstType, Name: r.FirstName}
+}
+
+func (r DiplomacyRelation) Second() DiplomacyParty {
+	return DiplomacyParty{Type: r.SecondType, Name: r.SecondName}
+}
+
+// Other - returns the party of the relation which differs from the provided one
+func (r DiplomacyRelation) Other(party DiplomacyParty) DiplomacyParty {
+	if r.First() == party {
+		return r.Second()
+	}
+
+	return r.First()
+}
+
+func (r DiplomacyRelation) ToRPC() *rpc.DiplomacyRelation {
+	return &rpc.DiplomacyRelation{
+		First:     r.First().ToRPC(),
+		Second:    r.Second().ToRPC(),
+		State:     r.State.ToRPC(),
+		UpdatedAt: r.UpdatedAt.Unix(),
+	}
+}
+
+// DiplomacyProposal - the state proposed by one party to another, the state is set when the target accepts it
+type DiplomacyProposal struct {
+	ID           int64
+	FromType     DiplomacyPartyType `db:"from_type"`
+	FromName     string             `db:"from_name"`
+	ToType       DiplomacyPartyType `db:"to_type"`
+	ToName       string             `db:"to_name"`
+	State        DiplomacyState
+	ProposerName string    `db:"proposer_name"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+func NewDiplomacyProposal(from, to DiplomacyParty, state DiplomacyState, proposer string) DiplomacyProposal {
+	return DiplomacyProposal{
+		FromType:     from.Type,
+		FromName:     from.Name,
+		ToType:       to.Type,
+		ToName:       to.Name,
+		State:        state,
+		ProposerName: proposer,
+		CreatedAt:    time.Now(),
+	}
+}
+
+func (p DiplomacyProposal) From() DiplomacyParty {
+	return DiplomacyParty{Type: p.FromType, Name: p.FromName}
+}
+
+func (p DiplomacyProposal) To() DiplomacyParty {
+	return DiplomacyParty{Type: p.ToType, Name: p.ToName}
+}
+
+func (p DiplomacyProposal) ToRPC() *rpc.DiplomacyProposal {
+	return &rpc.DiplomacyProposal{
+		Id:        p.ID,
+		From:      p.From().ToRPC(),
+		To:        p.To().ToRPC(),
+		State:     p.State.ToRPC(),
+		Proposer:  p.ProposerName,
+		CreatedAt: p.CreatedAt.Unix(),
+	}
+}
@@ -46,3 +46,32 @@ var ErrUsernameIsTaken = NewError("username is already registered", rpc.Error_US
 var ErrForbidden = NewError("action is forbidden", rpc.Error_FORBIDDEN)
 var ErrNotEnoughResources = NewError("not enough resources", rpc.Error_NOT_ENOUGH_RESOURCES)
 var ErrTownNotFound = NewError("town not found", rpc.Error_TOWN_NOT_FOUND)
+var ErrViewportTooLarge = NewError("viewport contains too many chunks", rpc.Error_BAD_REQUEST)
+var ErrTownTooClose = NewError("town is too close to another town", rpc.Error_TOWN_TOO_CLOSE)
+var ErrLocationUnderWater = NewError("location is bellow the water level", rpc.Error_LOCATION_UNDER_WATER)
+var ErrTerrainTooSteep = NewError("terrain is too steep to place a town", rpc.Error_TERRAIN_TOO_STEEP)
+var ErrSpawnLocationNotFound = NewError("failed to find free location for the town", rpc.Error_SPAWN_LOCATION_NOT_FOUND)
+var ErrUnknownChatChannel = NewError("unknown chat channel", rpc.Error_BAD_REQUEST)
+var ErrNotChatChannelMember = NewError("character isn't a member of the chat channel", rpc.Error_FORBIDDEN)
+var ErrRecipientNotFound = NewError("message recipient not found", rpc.Error_RECIPIENT_NOT_FOUND)
+var ErrChatRateLimited = NewError("too many chat messages", rpc.Error_CHAT_RATE_LIMITED)
+var ErrCharacterMuted = NewError("character is muted", rpc.Error_CHARACTER_MUTED)
+var ErrAlreadyInAlliance = NewError("character is already in alliance", rpc.Error_ALREADY_IN_ALLIANCE)
+var ErrNotInAlliance = NewError("character isn't in alliance", rpc.Error_NOT_IN_ALLIANCE)
+var ErrAllianceNameTaken = NewError("alliance name or tag is already taken", rpc.Error_ALLIANCE_NAME_TAKEN)
+var ErrAllianceNotFound = NewError("alliance not found", rpc.Error_ALLIANCE_NOT_FOUND)
+var ErrAllianceInviteNotFound = NewError("alliance invite not found", rpc.Error_ALLIANCE_INVITE_NOT_FOUND)
+var ErrDiplomacyProposalNotFound = NewError("diplomacy proposal not found", rpc.Error_DIPLOMACY_PROPOSAL_NOT_FOUND)
+var ErrMarketOrderNotFound = NewError("market order not found", rpc.Error_MARKET_ORDER_NOT_FOUND)
+var ErrTechNotResearched = NewError("required tech isn't researched", rpc.Error_TECH_NOT_RESEARCHED)
+var ErrResearchInProgress = NewError("another research is in progress", rpc.Error_RESEARCH_IN_PROGRESS)
+var ErrResearchNotFound = NewError("research not found", rpc.Error_RESEARCH_NOT_FOUND)
+var ErrNotEnoughPopulation = NewError("not enough population", rpc.Error_NOT_ENOUGH_POPULATION)
+var ErrBuildingRequired = NewError("required building isn't placed in the town", rpc.Error_BUILDING_REQUIRED)
+var ErrNotEnoughUnits = NewError("not enough units", rpc.Error_NOT_ENOUGH_UNITS)
+var ErrMarchNotFound = NewError("march not found", rpc.Error_MARCH_NOT_FOUND)
+var ErrDestinationTooFar = NewError("destination is too far", rpc.Error_DESTINATION_TOO_FAR)
+var ErrTargetProtected = NewError("town owner is under the newbie protection", rpc.Error_FORBIDDEN)
+var ErrTargetShielded = NewError("town owner is under the peace shield", rpc.Error_FORBIDDEN)
+var ErrTreatyViolation = NewError("attack would break the treaty with the town owner", rpc.Error_FORBIDDEN)
+var ErrAlreadyProtected = NewError("character is already protected", rpc.Error_FORBIDDEN)
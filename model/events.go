@@ -3,8 +3,52 @@ package model
 import (
 	"abbysoft/gardarike-online/model/consts"
 	rpc "abbysoft/gardarike-online/rpc/generated"
+	"fmt"
+	"strings"
+	"time"
 )
 
+// CharacterTopic - topic of the events addressed to all sessions of the character
+func CharacterTopic(characterID int64) string {
+	return fmt.Sprintf(consts.CharacterTopicFormat, characterID)
+}
+
+// AllianceTopic - topic of the events addressed to all members of the alliance
+func AllianceTopic(allianceID int64) string {
+	return fmt.Sprintf(consts.AllianceTopicFormat, allianceID)
+}
+
+// SessionTopic - topic of the events addressed to the single session.
+// Session topic ID differs from the session ID because topics are visible to all subscribers
+func SessionTopic(topicID string) string {
+	return fmt.Sprintf(consts.SessionTopicFormat, topicID)
+}
+
+// PrivateTopicPrefix - returns the prefix of the topic derived from the ID or the coordinates, such topics
+// are never published as is because any subscriber could guess them
+func PrivateTopicPrefix(topic string) (string, bool) {
+	for _, prefix := range []string{consts.CharacterTopicPrefix, consts.AllianceTopicPrefix, consts.ChatTopicPrefix} {
+		if strings.HasPrefix(topic, prefix) {
+			return prefix, true
+		}
+	}
+
+	return "", false
+}
+
+// NewEvent - wraps the event published to the topic
+func NewEvent(topic string, event *rpc.Event) EventWrapper {
+	return EventWrapper{
+		Topic: topic,
+		Event: event,
+	}
+}
+
+// NewCharacterEvent - wraps the event delivered only to the character
+func NewCharacterEvent(characterID int64, event *rpc.Event) EventWrapper {
+	return NewEvent(CharacterTopic(characterID), event)
+}
+
 func NewChatMessageEvent(message ChatMessage) EventWrapper {
 	return EventWrapper{
 		Event: &rpc.Event{
@@ -14,7 +58,7 @@ func NewChatMessageEvent(message ChatMessage) EventWrapper {
 				},
 			},
 		},
-		Topic: consts.GlobalTopic,
+		Topic: ChatChannelTopic(message.Channel),
 	}
 }
 
@@ -23,6 +67,238 @@ func NewSystemChatMessageEvent(text string) EventWrapper {
 		ID:       0,
 		Sender:   consts.SystemUserName,
 		Text:     text,
+		Channel:  consts.GlobalChatChannel,
 		IsSystem: true,
 	})
 }
+
+// NewPrivateSystemChatMessageEvent - system message visible only to the topic subscribers
+func NewPrivateSystemChatMessageEvent(topic string, text string) EventWrapper {
+	event := NewSystemChatMessageEvent(text)
+	event.Topic = topic
+
+	return event
+}
+
+// resourcesDelta - signed difference between the new and the old resources values
+func resourcesDelta(before, after Resources) *rpc.ResourcesDelta {
+	amounts := make(map[string]int64)
+	for resourceType, amount := range after {
+		if delta := int64(amount) - int64(before[resourceType]); delta != 0 {
+			amounts[resourceType.ToRPC()] = delta
+		}
+	}
+
+	for resourceType, amount := range before {
+		if _, found := after[resourceType]; !found && amount != 0 {
+			amounts[resourceType.ToRPC()] = -int64(amount)
+		}
+	}
+
+	return &rpc.ResourcesDelta{
+		Amounts: amounts,
+	}
+}
+
+func NewResourcesChangedEvent(characterID int64, before, after Resources) EventWrapper {
+	return NewCharacterEvent(characterID, &rpc.Event{
+		Payload: &rpc.Event_ResourcesChangedEvent{
+			ResourcesChangedEvent: &rpc.ResourcesChangedEvent{
+				Resources: after.ToRPC(),
+				Delta:     resourcesDelta(before, after),
+			},
+		},
+	})
+}
+
+func NewPopulationGrownEvent(character Character) EventWrapper {
+	return NewCharacterEvent(character.ID, &rpc.Event{
+		Payload: &rpc.Event_PopulationGrownEvent{
+			PopulationGrownEvent: &rpc.PopulationGrownEvent{
+				CurrentPopulation: character.CurrentPopulation,
+				MaxPopulation:     character.MaxPopulation,
+			},
+		},
+	})
+}
+
+// NewTownFoundedEvent - new town is visible to all players on the map
+func NewTownFoundedEvent(town Town) EventWrapper {
+	return NewEvent(consts.GlobalTopic, &rpc.Event{
+		Payload: &rpc.Event_TownFoundedEvent{
+			TownFoundedEvent: &rpc.TownFoundedEvent{
+				Town: town.ToRPC(),
+			},
+		},
+	})
+}
+
+func NewBuildingPlacedEvent(characterID int64, townID int64, building Building) EventWrapper {
+	return NewCharacterEvent(characterID, &rpc.Event{
+		Payload: &rpc.Event_BuildingPlacedEvent{
+			BuildingPlacedEvent: &rpc.BuildingPlacedEvent{
+				TownID:     townID,
+				BuildingID: building.ID,
+				Location:   building.Location.ToRPC(),
+			},
+		},
+	})
+}
+
+func NewBuildingCompletedEvent(characterID int64, townID int64, building Building) EventWrapper {
+	return NewCharacterEvent(characterID, &rpc.Event{
+		Payload: &rpc.Event_BuildingCompletedEvent{
+			BuildingCompletedEvent: &rpc.BuildingCompletedEvent{
+				TownID:     townID,
+				BuildingID: building.ID,
+				Location:   building.Location.ToRPC(),
+			},
+		},
+	})
+}
+
+// NewCharacterStatusEvent - character entered or left the world
+func NewCharacterStatusEvent(name string, online bool) EventWrapper {
+	return NewEvent(consts.GlobalTopic, &rpc.Event{
+		Payload: &rpc.Event_CharacterStatusEvent{
+			CharacterStatusEvent: &rpc.CharacterStatusEvent{
+				Name:   name,
+				Online: online,
+			},
+		},
+	})
+}
+
+// NewAllianceInviteEvent - the character was invited to the alliance
+func NewAllianceInviteEvent(characterID int64, alliance Alliance, inviter string) EventWrapper {
+	return NewCharacterEvent(characterID, &rpc.Event{
+		Payload: &rpc.Event_AllianceInviteEvent{
+			AllianceInviteEvent: &rpc.AllianceInviteEvent{
+				Alliance: alliance.ToRPC(),
+				Inviter:  inviter,
+			},
+		},
+	})
+}
+
+// NewDiplomacyProposalEvent - the party received the diplomacy proposal
+func NewDiplomacyProposalEvent(topic string, proposal DiplomacyProposal) EventWrapper {
+	return NewEvent(topic, &rpc.Event{
+		Payload: &rpc.Event_DiplomacyProposalEvent{
+			DiplomacyProposalEvent: &rpc.DiplomacyProposalEvent{
+				Proposal: proposal.ToRPC(),
+			},
+		},
+	})
+}
+
+// NewDiplomacyChangedEvent - diplomacy state of the party was changed
+func NewDiplomacyChangedEvent(topic string, relation DiplomacyRelation) EventWrapper {
+	return NewEvent(topic, &rpc.Event{
+		Payload: &rpc.Event_DiplomacyChangedEvent{
+			DiplomacyChangedEvent: &rpc.DiplomacyChangedEvent{
+				Relation: relation.ToRPC(),
+			},
+		},
+	})
+}
+
+// NewMarketOrderFilledEvent - 'amount' units of the character's order were traded at the price
+func NewMarketOrderFilledEvent(order MarketOrder, amount uint64, price uint64) EventWrapper {
+	return NewCharacterEvent(order.CharacterID, &rpc.Event{
+		Payload: &rpc.Event_MarketOrderFilledEvent{
+			MarketOrderFilledEvent: &rpc.MarketOrderFilledEvent{
+				Order:  order.ToRPC(),
+				Amount: amount,
+				Price:  price,
+			},
+		},
+	})
+}
+
+// NewCaravanArrivedEvent - the caravan sent by or to the character reached its destination
+func NewCaravanArrivedEvent(characterID int64, caravan Caravan, lost Resources, returned Resources) EventWrapper {
+	return NewCharacterEvent(characterID, &rpc.Event{
+		Payload: &rpc.Event_CaravanArrivedEvent{
+			CaravanArrivedEvent: &rpc.CaravanArrivedEvent{
+				Caravan:  caravan.ToRPC(),
+				Lost:     lost.ToRPC(),
+				Returned: returned.ToRPC(),
+			},
+		},
+	})
+}
+
+func NewResearchCompletedEvent(research Research) EventWrapper {
+	return NewCharacterEvent(research.CharacterID, &rpc.Event{
+		Payload: &rpc.Event_ResearchCompletedEvent{
+			ResearchCompletedEvent: &rpc.ResearchCompletedEvent{
+				Tech: research.Tech,
+			},
+		},
+	})
+}
+
+func NewUnitsTrainedEvent(training UnitTraining) EventWrapper {
+	return NewCharacterEvent(training.CharacterID, &rpc.Event{
+		Payload: &rpc.Event_UnitsTrainedEvent{
+			UnitsTrainedEvent: &rpc.UnitsTrainedEvent{
+				Training: training.ToRPC(),
+			},
+		},
+	})
+}
+
+func NewArmyPositionEvent(topic string, march March, now time.Time) EventWrapper {
+	return NewEvent(topic, &rpc.Event{
+		Payload: &rpc.Event_ArmyPositionEvent{
+			ArmyPositionEvent: &rpc.ArmyPositionEvent{
+				March: march.ToRPC(now),
+			},
+		},
+	})
+}
+
+func NewArmyArrivedEvent(topic string, march March, stationed bool, now time.Time) EventWrapper {
+	return NewEvent(topic, &rpc.Event{
+		Payload: &rpc.Event_ArmyArrivedEvent{
+			ArmyArrivedEvent: &rpc.ArmyArrivedEvent{
+				March:     march.ToRPC(now),
+				Stationed: stationed,
+			},
+		},
+	})
+}
+
+// NewBattleEvent - report of the battle delivered to the attacker or to the defender
+func NewBattleEvent(characterID int64, report BattleReport) EventWrapper {
+	return NewCharacterEvent(characterID, &rpc.Event{
+		Payload: &rpc.Event_BattleEvent{
+			BattleEvent: &rpc.BattleEvent{
+				Report: report.ToRPC(),
+			},
+		},
+	})
+}
+
+// NewDirectMessageEvent - direct message delivered to the character
+func NewDirectMessageEvent(characterID int64, message DirectMessage) EventWrapper {
+	return NewCharacterEvent(characterID, &rpc.Event{
+		Payload: &rpc.Event_DirectMessageEvent{
+			DirectMessageEvent: &rpc.DirectMessageEvent{
+				Message: message.ToRPC(),
+			},
+		},
+	})
+}
+
+// NewEventTopicsChangedEvent - new topics of the session, sent when the tokens of its private topics are replaced
+func NewEventTopicsChangedEvent(sessionTopic string, topics []string) EventWrapper {
+	return NewEvent(sessionTopic, &rpc.Event{
+		Payload: &rpc.Event_EventTopicsChangedEvent{
+			EventTopicsChangedEvent: &rpc.EventTopicsChangedEvent{
+				EventTopics: topics,
+			},
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"abbysoft/gardarike-online/geometry"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"math"
+	"time"
+)
+
+type MarchStatus int
+
+const (
+	MarchMoving MarchStatus = iota
+	MarchCamped
+)
+
+// March - army which left its town, the army either moves to the destination or camps there
+type March struct {
+	ID            int64
+	CharacterID   int64  `db:"character_id"`
+	CharacterName string `db:"character_name"`
+	HomeTownID    int64  `db:"home_town_id"`
+	TargetTownID  int64  `db:"target_town_id"` // Zero if the army marches to the world tile
+	Units         Army   `db:"-"`
+	FromX         int64  `db:"from_x"`
+	FromY         int64  `db:"from_y"`
+	ToX           int64  `db:"to_x"`
+	ToY           int64  `db:"to_y"`
+	Status        MarchStatus
+	Capture       bool      // Take the target town if the army wins the battle
+	DepartedAt    time.Time `db:"departed_at"`
+	ArrivesAt     time.Time `db:"arrives_at"`
+}
+
+func (m March) From() geometry.Point {
+	return geometry.Point{X: m.FromX, Y: m.FromY}
+}
+
+func (m March) To() geometry.Point {
+	return geometry.Point{X: m.ToX, Y: m.ToY}
+}
+
+// Position - tile the army passes at the moment. Travel time already accounts the terrain,
+// so the position is interpolated along the straight line between the departure and the destination
+func (m March) Position(now time.Time) geometry.Point {
+	total := m.ArrivesAt.Sub(m.DepartedAt)
+	if m.Status != MarchMoving || !now.Before(m.ArrivesAt) || total <= 0 {
+		return m.To()
+	}
+
+	if !now.After(m.DepartedAt) {
+		return m.From()
+	}
+
+	progress := float64(now.Sub(m.DepartedAt)) / float64(total)
+	return geometry.Point{
+		X: m.FromX + int64(math.Round(float64(m.ToX-m.FromX)*progress)),
+		Y: m.FromY + int64(math.Round(float64(m.ToY-m.FromY)*progress)),
+	}
+}
+
+// Depart - starts the new leg of the march from the location to the destination
+func (m *March) Depart(from, to geometry.Point, targetTownID int64, now time.Time, travelTime time.Duration) {
+	m.FromX, m.FromY = from.X, from.Y
+	m.ToX, m.ToY = to.X, to.Y
+	m.TargetTownID = targetTownID
+	m.Status = MarchMoving
+	m.DepartedAt = now
+	m.ArrivesAt = now.Add(travelTime)
+}
+
+func (m March) ToRPC(now time.Time) *rpc.March {
+	position := m.Position(now)
+
+	return &rpc.March{
+		Id:           m.ID,
+		Owner:        m.CharacterName,
+		HomeTownID:   m.HomeTownID,
+		TargetTownID: m.TargetTownID,
+		Units:        m.Units.ToRPC(),
+		Status:       rpc.MarchStatus(m.Status),
+		FromX:        m.FromX,
+		FromY:        m.FromY,
+		ToX:          m.ToX,
+		ToY:          m.ToY,
+		X:            position.X,
+		Y:            position.Y,
+		DepartedAt:   m.DepartedAt.Unix(),
+		ArrivesAt:    m.ArrivesAt.Unix(),
+		Capture:      m.Capture,
+	}
+}
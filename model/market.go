@@ -0,0 +1,72 @@
+package model
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"time"
+)
+
+type MarketOrderSide int32
+
+const (
+	MarketOrderBuy MarketOrderSide = iota
+	MarketOrderSell
+)
+
+type MarketOrderStatus int32
+
+const (
+	MarketOrderOpen MarketOrderStatus = iota
+	MarketOrderFilled
+	MarketOrderCancelled
+)
+
+// MarketOrder - order to buy or sell the resource, price is the number of the price resource units
+// paid for one unit of the resource
+type MarketOrder struct {
+	ID            int64
+	CharacterID   int64  `db:"character_id"`
+	CharacterName string `db:"character_name"`
+	Side          MarketOrderSide
+	Resource      ResourceType
+	PriceResource ResourceType `db:"price_resource"`
+	Price         uint64
+	Amount        uint64
+	Remaining     uint64
+	Status        MarketOrderStatus
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// Escrow - resources held by the order to trade 'amount' units of the resource
+func (o MarketOrder) Escrow(amount uint64) Resources {
+	if o.Side == MarketOrderSell {
+		return NewResources(o.Resource, amount)
+	}
+
+	return NewResources(o.PriceResource, amount*o.Price)
+}
+
+// Fill - marks 'amount' units of the order as traded
+func (o *MarketOrder) Fill(amount uint64) {
+	o.Remaining -= amount
+	if o.Remaining == 0 {
+		o.Status = MarketOrderFilled
+	}
+
+	o.UpdatedAt = time.Now()
+}
+
+func (o MarketOrder) ToRPC() *rpc.MarketOrder {
+	return &rpc.MarketOrder{
+		Id:            o.ID,
+		Owner:         o.CharacterName,
+		Side:          rpc.MarketOrderSide(o.Side),
+		Resource:      o.Resource.ToRPC(),
+		PriceResource: o.PriceResource.ToRPC(),
+		Price:         o.Price,
+		Amount:        o.Amount,
+		Remaining:     o.Remaining,
+		Status:        rpc.MarketOrderStatus(o.Status),
+		CreatedAt:     o.CreatedAt.Unix(),
+	}
+}
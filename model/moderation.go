@@ -0,0 +1,41 @@
+package model
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"time"
+)
+
+const (
+	ModerationActionMute   = "mute"
+	ModerationActionUnmute = "unmute"
+	ModerationActionFilter = "filter"
+	ModerationActionKick   = "kick"
+)
+
+// ChatMute - character can't write to the chat channels until the mute expires
+type ChatMute struct {
+	CharacterName string `db:"character_name"`
+	ModeratorName string `db:"moderator_name"`
+	Reason        string
+	ExpiresAt     time.Time `db:"expires_at"`
+}
+
+type ModerationLogEntry struct {
+	ID            int64
+	ModeratorName string `db:"moderator_name"`
+	Action        string
+	TargetName    string `db:"target_name"`
+	Reason        string
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+func (e ModerationLogEntry) ToRPC() *rpc.ModerationLogEntry {
+	return &rpc.ModerationLogEntry{
+		Id:        e.ID,
+		Moderator: e.ModeratorName,
+		Action:    e.Action,
+		Target:    e.TargetName,
+		Reason:    e.Reason,
+		Timestamp: e.CreatedAt.Unix(),
+	}
+}
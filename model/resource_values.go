@@ -1,17 +1,30 @@
 package model
 
+const (
+	// Storage capacity of every resource for the character without storage buildings
+	BaseStorageCapacity = 2000
+)
+
 var (
 	ResourcesPlaceTown = Resources{
-		Wood:    1000,
-		Food:    1000,
-		Stone:   1000,
-		Leather: 0,
+		ResourceWood:  1000,
+		ResourceFood:  1000,
+		ResourceStone: 1000,
 	}
+)
 
-	ResourcesLimit = Resources{
-		Wood:    2000,
-		Food:    2000,
-		Stone:   2000,
-		Leather: 2000,
+// StorageCapacity - returns the max amount of every resource the owner of the buildings can store
+func StorageCapacity(buildings CharacterBuildings) Resources {
+	capacity := Resources{}
+	for resourceType := range ResourceRegistry {
+		capacity[resourceType] = BaseStorageCapacity
 	}
-)
+
+	for buildingType, count := range buildings {
+		for resourceType, amount := range Buildings[buildingType].Storage {
+			capacity[resourceType] += amount * count
+		}
+	}
+
+	return capacity
+}
@@ -0,0 +1,286 @@
+package model
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"sort"
+)
+
+// ResourceType - identifier of the resource, used as the key in the database and the protocol
+type ResourceType string
+
+const (
+	ResourceWood    ResourceType = "wood"
+	ResourceStone   ResourceType = "stone"
+	ResourceFood    ResourceType = "food"
+	ResourceLeather ResourceType = "leather"
+	ResourceIronOre ResourceType = "iron_ore"
+	ResourceIron    ResourceType = "iron"
+	ResourceTools   ResourceType = "tools"
+	ResourceGrain   ResourceType = "grain"
+	ResourceBread   ResourceType = "bread"
+	ResourceFurs    ResourceType = "furs"
+	ResourceHoney   ResourceType = "honey"
+	ResourceGold    ResourceType = "gold"
+)
+
+// ResourceInfo - description of the resource in the registry
+type ResourceInfo struct {
+	Type      ResourceType
+	Name      string
+	Processed bool // Produced from other resources by the production chains
+}
+
+var (
+	ResourceRegistry = map[ResourceType]ResourceInfo{
+		ResourceWood:    {Type: ResourceWood, Name: "Wood"},
+		ResourceStone:   {Type: ResourceStone, Name: "Stone"},
+		ResourceFood:    {Type: ResourceFood, Name: "Food"},
+		ResourceLeather: {Type: ResourceLeather, Name: "Leather"},
+		ResourceIronOre: {Type: ResourceIronOre, Name: "Iron ore"},
+		ResourceIron:    {Type: ResourceIron, Name: "Iron", Processed: true},
+		ResourceTools:   {Type: ResourceTools, Name: "Tools", Processed: true},
+		ResourceGrain:   {Type: ResourceGrain, Name: "Grain"},
+		ResourceBread:   {Type: ResourceBread, Name: "Bread", Processed: true},
+		ResourceFurs:    {Type: ResourceFurs, Name: "Furs"},
+		ResourceHoney:   {Type: ResourceHoney, Name: "Honey"},
+		ResourceGold:    {Type: ResourceGold, Name: "Gold"},
+	}
+)
+
+func (t ResourceType) IsValid() bool {
+	_, found := ResourceRegistry[t]
+	return found
+}
+
+func (t ResourceType) ToRPC() string {
+	return string(t)
+}
+
+func (i ResourceInfo) ToRPC() *rpc.ResourceInfo {
+	return &rpc.ResourceInfo{
+		Type:      i.Type.ToRPC(),
+		Name:      i.Name,
+		Processed: i.Processed,
+	}
+}
+
+// ResourceTypes - returns all registered resource types in a stable order
+func ResourceTypes() []ResourceType {
+	types := make([]ResourceType, 0, len(ResourceRegistry))
+	for resourceType := range ResourceRegistry {
+		types = append(types, resourceType)
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Resources - amounts of the resources by type, zero amounts aren't stored
+type Resources map[ResourceType]uint64
+
+// NewResources - returns resources containing only 'amount' units of the resource
+func NewResources(resourceType ResourceType, amount uint64) Resources {
+	return Resources{resourceType: amount}.withoutZeros()
+}
+
+// NewResourcesFromRPC - returns the resources of the request, unknown resource types are skipped
+func NewResourcesFromRPC(resources *rpc.Resources) Resources {
+	result := Resources{}
+	if resources == nil {
+		return result
+	}
+
+	for name, amount := range resources.Amounts {
+		if resourceType := ResourceType(name); resourceType.IsValid() && amount > 0 {
+			result[resourceType] = amount
+		}
+	}
+
+	return result
+}
+
+func (r Resources) withoutZeros() Resources {
+	result := Resources{}
+	for resourceType, amount := range r {
+		if amount > 0 {
+			result[resourceType] = amount
+		}
+	}
+
+	return result
+}
+
+func (r Resources) ToRPC() *rpc.Resources {
+	amounts := make(map[string]uint64, len(r))
+	for resourceType, amount := range r {
+		amounts[resourceType.ToRPC()] = amount
+	}
+
+	return &rpc.Resources{
+		Amounts: amounts,
+	}
+}
+
+// Clone - returns the copy which can be changed independently
+func (r Resources) Clone() Resources {
+	return r.withoutZeros()
+}
+
+// Equal - checks if both have the same amounts of every resource
+func (r Resources) Equal(resources Resources) bool {
+	if len(r.withoutZeros()) != len(resources.withoutZeros()) {
+		return false
+	}
+
+	for resourceType, amount := range r {
+		if resources[resourceType] != amount {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Subtract - decrement resources by the provided values if there is enough
+// resources or do nothing
+// return true if the resources were subtracted
+func (r *Resources) Subtract(resources Resources) bool {
+	if !r.IsEnough(resources) {
+		return false
+	}
+
+	for resourceType, amount := range resources {
+		if amount == 0 {
+			continue
+		}
+
+		if (*r)[resourceType] == amount {
+			delete(*r, resourceType)
+		} else {
+			(*r)[resourceType] -= amount
+		}
+	}
+
+	return true
+}
+
+func (r *Resources) Add(resources Resources) {
+	for resourceType, amount := range resources {
+		if amount == 0 {
+			continue
+		}
+
+		if *r == nil {
+			*r = Resources{}
+		}
+
+		(*r)[resourceType] += amount
+	}
+}
+
+// AddUpTo - increments every resource until it reaches the capacity,
+// resources which are already above the capacity are kept as is
+func (r *Resources) AddUpTo(resources Resources, capacity Resources) {
+	for resourceType, amount := range resources {
+		value := (*r)[resourceType]
+		result := addUpTo(value, amount, capacity[resourceType])
+		if result == value {
+			continue
+		}
+
+		if *r == nil {
+			*r = Resources{}
+		}
+
+		(*r)[resourceType] = result
+	}
+}
+
+func addUpTo(value, increment, capacity uint64) uint64 {
+	if value >= capacity {
+		return value
+	}
+
+	if increment > capacity-value {
+		return capacity
+	}
+
+	return value + increment
+}
+
+// Overflow - returns the part of the resources which doesn't fit into the storage with the capacity
+func (r Resources) Overflow(resources Resources, capacity Resources) Resources {
+	result := Resources{}
+	for resourceType, amount := range resources {
+		added := addUpTo(r[resourceType], amount, capacity[resourceType]) - r[resourceType]
+		if added < amount {
+			result[resourceType] = amount - added
+		}
+	}
+
+	return result
+}
+
+// Min - returns the amount of every resource limited by the other resources
+func (r Resources) Min(resources Resources) Resources {
+	result := Resources{}
+	for resourceType, amount := range r {
+		if limit := resources[resourceType]; limit < amount {
+			amount = limit
+		}
+
+		if amount > 0 {
+			result[resourceType] = amount
+		}
+	}
+
+	return result
+}
+
+// Percent - returns the given percent of every resource, resources without the percent are omitted
+func (r Resources) Percent(percents Resources) Resources {
+	result := Resources{}
+	for resourceType, amount := range r {
+		if part := amount * percents[resourceType] / 100; part > 0 {
+			result[resourceType] = part
+		}
+	}
+
+	return result
+}
+
+func (r Resources) IsEnough(requested Resources) bool {
+	for resourceType, amount := range requested {
+		if r[resourceType] < amount {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r Resources) IsEmpty() bool {
+	for _, amount := range r {
+		if amount > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsFull - checks if every resource reached the capacity
+func (r Resources) IsFull(capacity Resources) bool {
+	return r.IsEnough(capacity)
+}
+
+// HasRoom - checks if at least one of the resources is below the capacity
+func (r Resources) HasRoom(resources Resources, capacity Resources) bool {
+	for resourceType := range resources {
+		if r[resourceType] < capacity[resourceType] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Tech - technology researched by the characters, techs are loaded from the tech tree data file
+type Tech struct {
+	ID              string
+	Name            string
+	Cost            Resources
+	ResearchTime    time.Duration
+	Requires        []string           // Techs which should be researched before this one
+	Buildings       []rpc.BuildingType // Buildings which can't be placed until the tech is researched
+	ProductionBonus Resources          // Increase (in percents) of the production of every resource
+}
+
+// IsAvailable - checks if all prerequisites of the tech are researched
+func (t Tech) IsAvailable(researched CharacterTechs) bool {
+	for _, id := range t.Requires {
+		if !researched[id] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (t Tech) ToRPC() *rpc.Tech {
+	return &rpc.Tech{
+		Id:              t.ID,
+		Name:            t.Name,
+		Cost:            t.Cost.ToRPC(),
+		ResearchTime:    int64(t.ResearchTime.Seconds()),
+		Requires:        t.Requires,
+		Buildings:       t.Buildings,
+		ProductionBonus: t.ProductionBonus.ToRPC(),
+	}
+}
+
+// CharacterTechs - set of the techs researched by the character
+type CharacterTechs map[string]bool
+
+func (c CharacterTechs) IDs() []string {
+	result := make([]string, 0, len(c))
+	for id := range c {
+		result = append(result, id)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+type TechTree map[string]Tech
+
+func NewTechTree(techs []Tech) (TechTree, error) {
+	tree := make(TechTree, len(techs))
+	for _, tech := range techs {
+		if _, found := tree[tech.ID]; found {
+			return nil, fmt.Errorf("duplicate tech %s", tech.ID)
+		}
+
+		tree[tech.ID] = tech
+	}
+
+	if err := tree.validate(); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// validate - checks that all prerequisites exist and there are no prerequisite cycles
+func (t TechTree) validate() error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[string]int, len(t))
+
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("tech %s requires itself", id)
+		case visited:
+			return nil
+		}
+
+		state[id] = visiting
+		for _, required := range t[id].Requires {
+			if _, found := t[required]; !found {
+				return fmt.Errorf("tech %s requires unknown tech %s", id, required)
+			}
+
+			if err := visit(required); err != nil {
+				return err
+			}
+		}
+
+		state[id] = visited
+		return nil
+	}
+
+	for _, id := range t.IDs() {
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IDs - returns ids of all techs in the alphabetical order
+func (t TechTree) IDs() []string {
+	result := make([]string, 0, len(t))
+	for id := range t {
+		result = append(result, id)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// IsBuildingAvailable - checks if the building can be placed by the character. Buildings which aren't unlocked
+// by any tech are available from the start, other buildings require at least one of the unlocking techs
+func (t TechTree) IsBuildingAvailable(building rpc.BuildingType, researched CharacterTechs) bool {
+	locked := false
+	for id, tech := range t {
+		for _, unlocked := range tech.Buildings {
+			if unlocked != building {
+				continue
+			}
+
+			if researched[id] {
+				return true
+			}
+
+			locked = true
+		}
+	}
+
+	return !locked
+}
+
+// ProductionBonus - total production bonus (in percents) of the researched techs
+func (t TechTree) ProductionBonus(researched CharacterTechs) Resources {
+	result := Resources{}
+	for id := range researched {
+		result.Add(t[id].ProductionBonus)
+	}
+
+	return result
+}
+
+// Research - research of the tech by the character, the tech is researched when the research is completed
+type Research struct {
+	CharacterID int64 `db:"character_id"`
+	Tech        string
+	StartedAt   time.Time `db:"started_at"`
+	FinishesAt  time.Time `db:"finishes_at"`
+	Completed   bool
+}
+
+func (r Research) ToRPC() *rpc.Research {
+	return &rpc.Research{
+		Tech:       r.Tech,
+		StartedAt:  r.StartedAt.Unix(),
+		FinishesAt: r.FinishesAt.Unix(),
+	}
+}
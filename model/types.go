@@ -1,10 +1,14 @@
 package model
 
 import (
+	"abbysoft/gardarike-online/geometry"
+	"abbysoft/gardarike-online/model/consts"
 	rpc "abbysoft/gardarike-online/rpc/generated"
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"math"
+	"time"
 )
 
 type EventWrapper struct {
@@ -19,12 +23,14 @@ type Account struct {
 	Salt          string `db:"salt"`
 	IsOnline      bool   `db:"is_online"`
 	LastSessionID string `db:"last_session_id"`
+	IsModerator   bool   `db:"is_moderator"`
 }
 
 type ChatMessage struct {
-	ID       int64
-	Sender   string
+	ID       int64  `db:"message_id"`
+	Sender   string `db:"sender_name"`
 	Text     string
+	Channel  string
 	IsSystem bool `db:"is_system"`
 }
 
@@ -37,31 +43,104 @@ func (c ChatMessage) ToRPC() *rpc.ChatMessage {
 	}
 
 	return &rpc.ChatMessage{
-		Id:     c.ID,
-		Sender: c.Sender,
-		Text:   c.Text,
-		Type:   messageType,
+		Id:      c.ID,
+		Sender:  c.Sender,
+		Text:    c.Text,
+		Type:    messageType,
+		Channel: c.Channel,
+	}
+}
+
+type DirectMessage struct {
+	ID        int64
+	Sender    string `db:"sender_name"`
+	Recipient string `db:"recipient_name"`
+	Text      string
+	IsRead    bool      `db:"is_read"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (m DirectMessage) ToRPC() *rpc.DirectMessage {
+	return &rpc.DirectMessage{
+		Id:        m.ID,
+		Sender:    m.Sender,
+		Recipient: m.Recipient,
+		Text:      m.Text,
+		IsRead:    m.IsRead,
+		Timestamp: m.CreatedAt.Unix(),
+	}
+}
+
+// Conversation - the latest message exchanged with the interlocutor
+// and number of the interlocutor's messages which weren't read yet
+type Conversation struct {
+	DirectMessage
+	Interlocutor string `db:"interlocutor"`
+	UnreadCount  uint64 `db:"unread_count"`
+}
+
+func (c Conversation) ToRPC() *rpc.Conversation {
+	return &rpc.Conversation{
+		Character:   c.Interlocutor,
+		LastMessage: c.DirectMessage.ToRPC(),
+		UnreadCount: c.UnreadCount,
 	}
 }
 
 type Town struct {
-	ID         int64
-	X          int64
-	Y          int64
-	OwnerName  string `db:"owner_name"`
-	Population uint64
-	Name       string
-	Buildings  []Building
+	ID             int64
+	X              int64
+	Y              int64
+	OwnerName      string `db:"owner_name"`
+	Population     uint64
+	Name           string
+	Buildings      []Building
+	BuildingsCount uint64 `db:"buildings_count"`
+	AllianceTag    string `db:"alliance_tag"`
+}
+
+// IsHostileTo - the town belongs to someone outside of the character's alliance
+func (t Town) IsHostileTo(characterName string, allianceTag string) bool {
+	return t.OwnerName != characterName && (t.AllianceTag == "" || t.AllianceTag != allianceTag)
+}
+
+func (t Town) Location() geometry.Point {
+	return geometry.Point{X: t.X, Y: t.Y}
+}
+
+// TerritoryRadius - radius (in tiles) of the territory controlled by the town
+func (t Town) TerritoryRadius() float64 {
+	radius := consts.TerritoryBaseRadius +
+		float64(t.Population)/consts.TerritoryPopulationPerTile +
+		float64(t.BuildingsCount)*consts.TerritoryRadiusPerBuilding
+
+	return math.Min(radius, consts.TerritoryMaxRadius)
+}
+
+// IsInTerritory - checks if the tile is located inside of the town territory
+func (t Town) IsInTerritory(tile geometry.Point) bool {
+	return geometry.Distance(t.Location(), tile) <= t.TerritoryRadius()
+}
+
+func (t Town) TerritoryToRPC() *rpc.TownTerritory {
+	return &rpc.TownTerritory{
+		TownID:    t.ID,
+		OwnerName: t.OwnerName,
+		X:         t.X,
+		Y:         t.Y,
+		Radius:    float32(t.TerritoryRadius()),
+	}
 }
 
 func (t Town) ToRPC() *rpc.Town {
 	return &rpc.Town{
-		Id:         t.ID,
-		X:          t.X,
-		Y:          t.Y,
-		Name:       t.Name,
-		OwnerName:  t.OwnerName,
-		Population: t.Population,
+		Id:          t.ID,
+		X:           t.X,
+		Y:           t.Y,
+		Name:        t.Name,
+		OwnerName:   t.OwnerName,
+		Population:  t.Population,
+		AllianceTag: t.AllianceTag,
 	}
 }
 
@@ -79,6 +158,37 @@ type ChunkResources struct {
 	Plants  uint64
 }
 
+// Harvested - returns character resources produced from the chunk resources
+func (c ChunkResources) Harvested() Resources {
+	return Resources{
+		ResourceWood:    c.Trees,
+		ResourceStone:   c.Stones,
+		ResourceFood:    c.Plants + c.Animals/2,
+		ResourceLeather: c.Animals,
+	}.withoutZeros()
+}
+
+// Scale - returns the part of the resources, fraction should be in [0; 1]
+func (c ChunkResources) Scale(fraction float64) ChunkResources {
+	return ChunkResources{
+		Trees:   uint64(float64(c.Trees) * fraction),
+		Stones:  uint64(float64(c.Stones) * fraction),
+		Animals: uint64(float64(c.Animals) * fraction),
+		Plants:  uint64(float64(c.Plants) * fraction),
+	}
+}
+
+func (c *ChunkResources) Add(resources ChunkResources) {
+	c.Trees += resources.Trees
+	c.Stones += resources.Stones
+	c.Animals += resources.Animals
+	c.Plants += resources.Plants
+}
+
+func (c ChunkResources) IsEmpty() bool {
+	return c.Trees == 0 && c.Stones == 0 && c.Animals == 0 && c.Plants == 0
+}
+
 type WorldMapChunk struct {
 	X      int64
 	Y      int64
@@ -151,97 +261,162 @@ type Character struct {
 	Name              string
 	MaxPopulation     uint64 `db:"max_population"`
 	CurrentPopulation uint64 `db:"current_population"`
+	TaxRate           uint32 `db:"tax_rate"` // Percent of the population taxed every tick
 	Towns             []Town
 	Resources         Resources
-	ProductionRate    Resources
+	ProductionRate    Resources // Resources produced every tick by the buildings without inputs
+	StorageCapacity   Resources
+	Buildings         CharacterBuildings
+	Techs             CharacterTechs
+	Army              Army      // Units of the character, both stationed in the towns and on the march
+	ProtectedUntil    time.Time `db:"protected_until"` // Newbie protection of the new character
+	ShieldUntil       time.Time `db:"shield_until"`    // Peace shield bought by the character
+	AllianceID        int64     `db:"alliance_id"`
+	AllianceTag       string    `db:"alliance_tag"`
+}
+
+// AddResources - increments resources of the character, every resource is limited by the storage capacity
+func (c *Character) AddResources(resources Resources) {
+	c.Resources.AddUpTo(resources, c.StorageCapacity)
+}
+
+// TaxIncome - gold collected from the population every tick
+func (c Character) TaxIncome() uint64 {
+	return c.CurrentPopulation * uint64(c.TaxRate) / consts.MaxTaxRate
+}
+
+// Happiness - the population is less happy with the higher taxes, unhappy population grows slower
+func (c Character) Happiness() uint32 {
+	if c.TaxRate >= consts.MaxTaxRate {
+		return 0
+	}
+
+	return consts.MaxHappiness - c.TaxRate*consts.MaxHappiness/consts.MaxTaxRate
 }
 
 func (c Character) HasTown(townID int64) bool {
+	_, found := c.Town(townID)
+	return found
+}
+
+func (c Character) Town(townID int64) (Town, bool) {
 	for _, town := range c.Towns {
 		if town.ID == townID {
-			return true
+			return town, true
 		}
 	}
 
-	return false
+	return Town{}, false
 }
 
-func (c Character) ToRPC() *rpc.Character {
-	return &rpc.Character{
-		Id:                c.ID,
-		Name:              c.Name,
-		MaxPopulation:     c.MaxPopulation,
-		CurrentPopulation: c.CurrentPopulation,
+// GainTown - the captured town joins the character with all its buildings
+func (c *Character) GainTown(town Town, buildings CharacterBuildings) {
+	town.OwnerName = c.Name
+	c.Towns = append(c.Towns, town)
+	c.MaxPopulation += consts.TownPopulationBonus
+
+	if c.Buildings == nil {
+		c.Buildings = make(CharacterBuildings)
 	}
-}
 
-type Resources struct {
-	CharacterID int64 `db:"character_id"`
-	Wood        uint64
-	Food        uint64
-	Stone       uint64
-	Leather     uint64
-}
+	for buildingType, count := range buildings {
+		building := Buildings[buildingType]
+		c.Buildings[buildingType] += count
+		c.MaxPopulation += building.PopulationBonus * count
 
-func (r Resources) ToRPC() *rpc.Resources {
-	return &rpc.Resources{
-		Wood:    r.Wood,
-		Stone:   r.Stone,
-		Food:    r.Food,
-		Leather: r.Leather,
+		// Production of the chains depends on the inputs, so it's calculated every tick
+		if !building.IsProductionChain() {
+			for i := uint64(0); i < count; i++ {
+				c.ProductionRate.Add(building.Production)
+			}
+		}
 	}
+
+	c.StorageCapacity = StorageCapacity(c.Buildings)
 }
 
-// Subtract - decrement resources by the provided values if there is enough
-// resources or do nothing
-// return true if the resources were subtracted
-func (r *Resources) Subtract(resources Resources) bool {
-	if !r.IsEnough(resources) {
-		return false
+// LoseTown - the captured town leaves the character with all its buildings,
+// population above the new limit leaves too
+func (c *Character) LoseTown(townID int64, buildings CharacterBuildings) {
+	for i, town := range c.Towns {
+		if town.ID == townID {
+			c.Towns = append(c.Towns[:i:i], c.Towns[i+1:]...)
+			break
+		}
 	}
 
-	r.Food -= resources.Food
-	r.Wood -= resources.Wood
-	r.Stone -= resources.Stone
-	r.Leather -= resources.Leather
+	bonus := uint64(consts.TownPopulationBonus)
+	for buildingType, count := range buildings {
+		building := Buildings[buildingType]
+		bonus += building.PopulationBonus * count
 
-	return true
-}
+		if c.Buildings[buildingType] <= count {
+			delete(c.Buildings, buildingType)
+		} else {
+			c.Buildings[buildingType] -= count
+		}
 
-func (r *Resources) Add(resources Resources) {
-	r.Food += resources.Food
-	r.Wood += resources.Wood
-	r.Stone += resources.Stone
-	r.Leather += resources.Leather
+		if !building.IsProductionChain() {
+			for i := uint64(0); i < count; i++ {
+				c.ProductionRate.Subtract(c.ProductionRate.Min(building.Production))
+			}
+		}
+	}
 
-	*r = minResources(*r, ResourcesLimit)
+	if c.MaxPopulation > bonus {
+		c.MaxPopulation -= bonus
+	} else {
+		c.MaxPopulation = 0
+	}
+
+	if c.CurrentPopulation > c.MaxPopulation {
+		c.CurrentPopulation = c.MaxPopulation
+	}
+
+	c.StorageCapacity = StorageCapacity(c.Buildings)
 }
 
-func minResources(a, b Resources) (r Resources) {
-	r = a
-	if a.Food > b.Food {
-		r.Food = b.Food
+func (c Character) ToRPC() *rpc.Character {
+	return &rpc.Character{
+		Id:                c.ID,
+		Name:              c.Name,
+		MaxPopulation:     c.MaxPopulation,
+		CurrentPopulation: c.CurrentPopulation,
+		AllianceTag:       c.AllianceTag,
+		TaxRate:           c.TaxRate,
+		Happiness:         c.Happiness(),
+		ProtectedUntil:    unixOrZero(c.ProtectedUntil),
+		ShieldUntil:       unixOrZero(c.ShieldUntil),
 	}
-	if a.Wood > b.Wood {
-		r.Wood = b.Wood
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
 	}
-	if a.Stone > b.Stone {
-		r.Stone = b.Stone
+
+	return t.Unix()
+}
+
+// ProtectionError - reason the towns of the character can't be attacked at the moment, nil if they can
+func (c Character) ProtectionError(now time.Time) Error {
+	if now.Before(c.ProtectedUntil) {
+		return ErrTargetProtected
 	}
-	if a.Leather > b.Leather {
-		r.Leather = b.Leather
+
+	if now.Before(c.ShieldUntil) {
+		return ErrTargetShielded
 	}
 
-	return
+	return nil
 }
 
-func (r Resources) IsEnough(requested Resources) bool {
-	return r.Food >= requested.Food &&
-		r.Stone >= requested.Stone &&
-		r.Wood >= requested.Wood &&
-		r.Leather >= requested.Leather
+func (c Character) IsProtected(now time.Time) bool {
+	return c.ProtectionError(now) != nil
 }
 
-func (r Resources) IsLimitReached() bool {
-	return r.IsEnough(ResourcesLimit)
+// DropProtection - the character attacking other players loses both the newbie protection and the peace shield
+func (c *Character) DropProtection() {
+	c.ProtectedUntil = time.Time{}
+	c.ShieldUntil = time.Time{}
 }
@@ -26,6 +26,7 @@ type Server struct {
 type Config struct {
 	RequestEndpoint string // Listens for requests on this endpoint (e.g. tcp://*:555)
 	EventEndpoint   string // Publish events on this endpoint
+	EventsQueueSize int    // Max number of events waiting for publishing
 }
 
 func NewServer(
@@ -50,7 +51,7 @@ func NewServer(
 
 	logger := log.WithField("module", "server")
 
-	eventsChan := make(chan model.EventWrapper, 10)
+	eventsChan := make(chan model.EventWrapper, config.EventsQueueSize)
 	gameLogic, err := logic.NewLogic(
 		generation.NewSimplexTerrainGenerator(generatorConfig, time.Now().UnixNano()),
 		eventsChan,
@@ -78,7 +79,8 @@ func NewServer(
 func (s *Server) publishEvent(event model.EventWrapper) {
 	logger := s.log.
 		WithField("event", fmt.Sprintf("%T", event.Event.Payload)).
-		WithField("topic", event.Topic)
+		WithField("topic", event.Topic).
+		WithField("sequence", event.Event.Sequence)
 
 	bytes, err := proto.Marshal(event.Event)
 	if err != nil {
@@ -86,7 +88,9 @@ func (s *Server) publishEvent(event model.EventWrapper) {
 		return
 	}
 
-	if _, err := s.eventSock.SendMessageDontwait(event.Topic, string(bytes)); err != nil {
+	// PUB socket never blocks, messages for slow subscribers are dropped on the high water mark.
+	// Clients detect such gaps by the event sequence numbers and request the replay
+	if _, err := s.eventSock.SendMessage(event.Topic, string(bytes)); err != nil {
 		logger.WithError(err).Error("Failed to push server event")
 	} else {
 		logger.WithField("payload", event.Event).Info("EventWrapper published to the clients")
@@ -124,17 +128,27 @@ func (s *Server) Serve() error {
 
 		s.log.Debugf("Read %d bytes from client", len(packet))
 
-		resp := s.handler.HandleClientPacket([]byte(packet))
+		responses := s.handler.HandleClientPacket([]byte(packet))
 
-		respBytes, err := proto.Marshal(resp)
-		if err != nil {
-			s.log.Errorf("Failed to marshal server response: %v", err)
-			continue
+		// Multipart response is sent as a single multi-frame message
+		var frames []interface{}
+		for _, resp := range responses {
+			respBytes, err := proto.Marshal(resp)
+			if err != nil {
+				s.log.Errorf("Failed to marshal server response: %v", err)
+				frames = nil
+				break
+			}
+
+			s.log.Infof("Sending %T response to the client (%d bytes)", resp.Data, len(respBytes))
+			frames = append(frames, string(respBytes))
 		}
 
-		s.log.Infof("Sending %T response to the client (%d bytes)", resp.Data, len(respBytes))
+		if len(frames) == 0 {
+			continue
+		}
 
-		if _, err := s.requestSock.Send(string(respBytes), 0); err != nil {
+		if _, err := s.requestSock.SendMessage(frames...); err != nil {
 			s.log.Errorf("Failed to send answer to the client: %v", err)
 		}
 	}
@@ -0,0 +1,41 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetAllianceMembers_NotInAlliance(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetAllianceMembersRequest{
+		GetAllianceMembersRequest: &rpc.GetAllianceMembersRequest{
+			SessionID: sessionID,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+	require.Equal(t, rpc.Error_NOT_IN_ALLIANCE, resp.GetErrorResponse().Code)
+}
+
+func TestCreateAlliance_InvalidTag(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_CreateAllianceRequest{
+		CreateAllianceRequest: &rpc.CreateAllianceRequest{
+			SessionID: sessionID,
+			Name:      "Druzhina",
+			Tag:       "D",
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+	require.Equal(t, rpc.Error_BAD_REQUEST, resp.GetErrorResponse().Code)
+}
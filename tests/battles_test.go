@@ -0,0 +1,26 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetBattleReports(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetBattleReportsRequest{
+		GetBattleReportsRequest: &rpc.GetBattleReportsRequest{
+			SessionID: sessionID,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+
+	if !assert.NoError(t, err, "request error is not nil") {
+		return
+	}
+
+	assert.NotNil(t, resp.GetGetBattleReportsResponse(), "response isn't a get battle reports response")
+}
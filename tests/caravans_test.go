@@ -0,0 +1,41 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetCaravans(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetCaravansRequest{
+		GetCaravansRequest: &rpc.GetCaravansRequest{
+			SessionID: sessionID,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetGetCaravansResponse())
+}
+
+func TestSendCaravan_TownNotFound(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_SendCaravanRequest{
+		SendCaravanRequest: &rpc.SendCaravanRequest{
+			SessionID:  sessionID,
+			FromTownID: -1,
+			ToTownID:   -2,
+			Resources:  &rpc.Resources{Amounts: map[string]uint64{"wood": 1}},
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+	require.Equal(t, rpc.Error_TOWN_NOT_FOUND, resp.GetErrorResponse().Code)
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSendChatMessage_NotChannelMember(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_SendChatMessageRequest{
+		SendChatMessageRequest: &rpc.SendChatMessageRequest{
+			SessionID: sessionID,
+			Text:      "hello",
+			Channel:   "ALLIANCE/1000000",
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+	require.Equal(t, rpc.Error_FORBIDDEN, resp.GetErrorResponse().Code)
+}
+
+func TestGetChatHistory_GlobalChannel(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetChatHistoryRequest{
+		GetChatHistoryRequest: &rpc.GetChatHistoryRequest{
+			SessionID: sessionID,
+			Count:     5,
+			Channel:   "GLOBAL",
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetGetChatHistoryResponse())
+
+	for _, message := range resp.GetGetChatHistoryResponse().Messages {
+		require.Equal(t, "GLOBAL", message.Channel)
+	}
+}
+
+func TestGetChatHistory_BeforeID(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetChatHistoryRequest{
+		GetChatHistoryRequest: &rpc.GetChatHistoryRequest{
+			SessionID: sessionID,
+			Count:     2,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetGetChatHistoryResponse())
+
+	latest := resp.GetGetChatHistoryResponse().Messages
+	require.NotEmpty(t, latest)
+	require.Equal(t, rpc.ChatMessage_SYSTEM, latest[0].Type)
+
+	request.Data = &rpc.Request_GetChatHistoryRequest{
+		GetChatHistoryRequest: &rpc.GetChatHistoryRequest{
+			SessionID: sessionID,
+			Count:     2,
+			BeforeID:  latest[0].Id,
+		},
+	}
+
+	resp, err = client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetGetChatHistoryResponse())
+
+	for _, message := range resp.GetGetChatHistoryResponse().Messages {
+		require.True(t, message.Id < latest[0].Id)
+	}
+}
@@ -0,0 +1,36 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatCommand_Help(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_SendChatMessageRequest{
+		SendChatMessageRequest: &rpc.SendChatMessageRequest{
+			SessionID: sessionID,
+			Text:      "/help",
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetSendChatMessageResponse())
+
+	// Skip events left from the character selection
+	for {
+		event := requireEvent(t, time.Second)
+		message := event.GetChatMessageEvent().GetMessage()
+
+		if message != nil && strings.HasPrefix(message.Text, "Available commands") {
+			require.Equal(t, rpc.ChatMessage_SYSTEM, message.Type)
+			return
+		}
+	}
+}
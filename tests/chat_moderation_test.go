@@ -0,0 +1,25 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMuteCharacter_NotModerator(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_MuteCharacterRequest{
+		MuteCharacterRequest: &rpc.MuteCharacterRequest{
+			SessionID: sessionID,
+			Character: "test",
+			Duration:  60,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+	require.Equal(t, rpc.Error_FORBIDDEN, resp.GetErrorResponse().Code)
+}
@@ -77,6 +77,15 @@ func NewClient(config ClientConfig) (*Client, error) {
 	return client, nil
 }
 
+// Subscribe - subscribes to the server events topic
+func (c *Client) Subscribe(topic string) error {
+	if err := c.eventSocket.SetSubscribe(topic); err != nil {
+		return fmt.Errorf("failed to subscribe to %s topic: %w", topic, err)
+	}
+
+	return nil
+}
+
 func (c *Client) pollEvents() {
 	for {
 		event, err := c.pollEvent()
@@ -116,10 +125,10 @@ func (c *Client) SendMessage(message proto.Message) {
 	}
 }
 
-func (c *Client) SendRequest(request rpc.Request) (*rpc.Response, error) {
+func (c *Client) sendRequest(request rpc.Request) error {
 	requestBytes, err := proto.Marshal(&request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	c.logger.WithFields(log.Fields{
@@ -129,10 +138,17 @@ func (c *Client) SendRequest(request rpc.Request) (*rpc.Response, error) {
 	}).Info("Send request to the server")
 
 	if _, err := c.socket.Send(string(requestBytes), zmq.DONTWAIT); err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return fmt.Errorf("failed to send request: %w", err)
 	}
 
 	c.logger.Printf("%T sent to the server", request.Data)
+	return nil
+}
+
+func (c *Client) SendRequest(request rpc.Request) (*rpc.Response, error) {
+	if err := c.sendRequest(request); err != nil {
+		return nil, err
+	}
 
 	if response, err := c.readResponse(); err != nil {
 		return nil, fmt.Errorf("failed to read response to the server: %w", err)
@@ -144,23 +160,68 @@ func (c *Client) SendRequest(request rpc.Request) (*rpc.Response, error) {
 	}
 }
 
-func (c *Client) readResponse() (*rpc.Response, error) {
-	bytesRead, err := c.socket.Recv(0)
+// SendMultipartRequest - sends request and returns all parts of the multipart response
+func (c *Client) SendMultipartRequest(request rpc.Request) ([]*rpc.Response, error) {
+	if err := c.sendRequest(request); err != nil {
+		return nil, err
+	}
+
+	responses, err := c.readResponses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response to the server: %w", err)
+	}
+
+	if errorResp := responses[0].GetErrorResponse(); errorResp != nil {
+		return nil, model.NewError(errorResp.Message, errorResp.Code)
+	}
+
+	header := responses[0].GetMultipartResponse()
+	if header == nil {
+		return nil, fmt.Errorf("multipart response expected")
+	}
+
+	if int(header.Parts) != len(responses)-1 {
+		return nil, fmt.Errorf("expected %d parts but %d received", header.Parts, len(responses)-1)
+	}
+
+	return responses[1:], nil
+}
+
+func (c *Client) readResponses() ([]*rpc.Response, error) {
+	frames, err := c.socket.RecvMessage(0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response from the server: %w", err)
 	}
 
-	var response rpc.Response
-	if err := proto.Unmarshal([]byte(bytesRead), &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal server response: %w", err)
+	var responses []*rpc.Response
+	for _, frame := range frames {
+		var response rpc.Response
+		if err := proto.Unmarshal([]byte(frame), &response); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal server response: %w", err)
+		}
+
+		c.logger.
+			WithField("response", response.Data).
+			Infof("Server respond with %d bytes", len(frame))
+		responses = append(responses, &response)
+	}
+
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("empty response received")
+	}
+
+	return responses, nil
+}
+
+func (c *Client) readResponse() (*rpc.Response, error) {
+	responses, err := c.readResponses()
+	if err != nil {
+		return nil, err
 	}
 
-	if response.GetMultipartResponse() != nil {
+	if responses[0].GetMultipartResponse() != nil {
 		return nil, fmt.Errorf("multipart response received")
 	}
 
-	c.logger.
-		WithField("response", response.Data).
-		Infof("Server respond with %d bytes", len(bytesRead))
-	return &response, nil
+	return responses[0], nil
 }
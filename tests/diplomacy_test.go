@@ -0,0 +1,39 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetDiplomacy(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetDiplomacyRequest{
+		GetDiplomacyRequest: &rpc.GetDiplomacyRequest{
+			SessionID: sessionID,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetGetDiplomacyResponse())
+}
+
+func TestAcceptDiplomacyProposal_NotFound(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_AcceptDiplomacyProposalRequest{
+		AcceptDiplomacyProposalRequest: &rpc.AcceptDiplomacyProposalRequest{
+			SessionID:  sessionID,
+			ProposalID: -1,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+	require.Equal(t, rpc.Error_DIPLOMACY_PROPOSAL_NOT_FOUND, resp.GetErrorResponse().Code)
+}
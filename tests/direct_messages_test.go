@@ -0,0 +1,40 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSendDirectMessage_RecipientNotFound(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_SendDirectMessageRequest{
+		SendDirectMessageRequest: &rpc.SendDirectMessageRequest{
+			SessionID: sessionID,
+			Recipient: "character which doesn't exist",
+			Text:      "hello",
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+	require.Equal(t, rpc.Error_RECIPIENT_NOT_FOUND, resp.GetErrorResponse().Code)
+}
+
+func TestGetConversations(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetConversationsRequest{
+		GetConversationsRequest: &rpc.GetConversationsRequest{
+			SessionID: sessionID,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetGetConversationsResponse())
+}
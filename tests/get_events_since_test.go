@@ -0,0 +1,53 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetEventsSince(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetEventsSinceRequest{
+		GetEventsSinceRequest: &rpc.GetEventsSinceRequest{
+			SessionID: sessionID,
+			Topic:     "GLOBAL",
+			Sequence:  0,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetGetEventsSinceResponse())
+
+	response := resp.GetGetEventsSinceResponse()
+	require.NotEmpty(t, response.Events, "character selection events should be available for replay")
+
+	previous := uint64(0)
+	for _, event := range response.Events {
+		require.Equal(t, "GLOBAL", event.Topic)
+		require.True(t, event.Sequence > previous, "sequence numbers should increase")
+		previous = event.Sequence
+	}
+
+	require.Equal(t, previous, response.LastSequence)
+}
+
+func TestGetEventsSince_ForeignTopic(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetEventsSinceRequest{
+		GetEventsSinceRequest: &rpc.GetEventsSinceRequest{
+			SessionID: sessionID,
+			Topic:     "CHARACTER/1000000/",
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+	require.Equal(t, rpc.Error_FORBIDDEN, resp.GetErrorResponse().Code)
+}
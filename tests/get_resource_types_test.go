@@ -0,0 +1,29 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetResourceTypes(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetResourceTypesRequest{
+		GetResourceTypesRequest: &rpc.GetResourceTypesRequest{
+			SessionID: sessionID,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+
+	if !assert.NoError(t, err, "request error is not nil") {
+		return
+	}
+	if !assert.NotNil(t, resp.GetGetResourceTypesResponse(), "response isn't a get resource types response") {
+		return
+	}
+
+	assert.NotEmpty(t, resp.GetGetResourceTypesResponse().Resources, "resource registry is empty")
+}
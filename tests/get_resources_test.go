@@ -27,4 +27,6 @@ func TestGetResources(t *testing.T) {
 	if !assert.NotNil(t, resp.GetGetResourcesResponse(), "response isn't a get resources response") {
 		return
 	}
+
+	assert.NotNil(t, resp.GetGetResourcesResponse().Capacity, "storage capacity is nil")
 }
@@ -0,0 +1,39 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetWorldViewport(t *testing.T) {
+	TestLoginSuccessful(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetWorldViewportRequest{
+		GetWorldViewportRequest: &rpc.GetWorldViewportRequest{
+			SessionID:     sessionID,
+			From:          &rpc.IntVector2D{X: 0, Y: 0},
+			To:            &rpc.IntVector2D{X: 1, Y: 1},
+			LevelOfDetail: 4,
+		},
+	}
+
+	parts, err := client.SendMultipartRequest(request)
+
+	if !assert.NoError(t, err, "request error is not nil") {
+		return
+	}
+	if !assert.Len(t, parts, 4, "wrong number of parts") {
+		return
+	}
+
+	for _, part := range parts {
+		if !assert.NotNil(t, part.GetGetWorldViewportResponse(), "part isn't a get world viewport response") {
+			return
+		}
+
+		assert.NotEmpty(t, part.GetGetWorldViewportResponse().Map.Data)
+		assert.Equal(t, int32(4), part.GetGetWorldViewportResponse().Map.LevelOfDetail)
+	}
+}
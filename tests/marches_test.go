@@ -0,0 +1,26 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetMarches(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetMarchesRequest{
+		GetMarchesRequest: &rpc.GetMarchesRequest{
+			SessionID: sessionID,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+
+	if !assert.NoError(t, err, "request error is not nil") {
+		return
+	}
+
+	assert.NotNil(t, resp.GetGetMarchesResponse(), "response isn't a get marches response")
+}
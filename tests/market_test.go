@@ -0,0 +1,41 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetOrderBook(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetOrderBookRequest{
+		GetOrderBookRequest: &rpc.GetOrderBookRequest{
+			SessionID:     sessionID,
+			Resource:      "wood",
+			PriceResource: "stone",
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetGetOrderBookResponse())
+}
+
+func TestCancelMarketOrder_NotFound(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_CancelMarketOrderRequest{
+		CancelMarketOrderRequest: &rpc.CancelMarketOrderRequest{
+			SessionID: sessionID,
+			OrderID:   -1,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetErrorResponse())
+	require.Equal(t, rpc.Error_MARKET_ORDER_NOT_FOUND, resp.GetErrorResponse().Code)
+}
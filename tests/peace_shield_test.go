@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"abbysoft/gardarike-online/model"
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBuyPeaceShield(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_BuyPeaceShieldRequest{
+		BuyPeaceShieldRequest: &rpc.BuyPeaceShieldRequest{
+			SessionID: sessionID,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+
+	// the test character can be protected already or have not enough gold
+	if err != nil {
+		assert.Contains(t, []string{model.ErrAlreadyProtected.Error(), model.ErrNotEnoughResources.Error()}, err.Error(),
+			"unexpected error")
+		return
+	}
+
+	if !assert.NotNil(t, resp.GetBuyPeaceShieldResponse(), "response isn't a buy peace shield response") {
+		return
+	}
+
+	assert.NotZero(t, resp.GetBuyPeaceShieldResponse().ShieldUntil, "shield expiration isn't set")
+}
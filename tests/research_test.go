@@ -0,0 +1,29 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetTechs(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_GetTechsRequest{
+		GetTechsRequest: &rpc.GetTechsRequest{
+			SessionID: sessionID,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+
+	if !assert.NoError(t, err, "request error is not nil") {
+		return
+	}
+	if !assert.NotNil(t, resp.GetGetTechsResponse(), "response isn't a get techs response") {
+		return
+	}
+
+	assert.NotEmpty(t, resp.GetGetTechsResponse().Techs, "tech tree is empty")
+}
@@ -46,6 +46,11 @@ func TestSelectCharacter(t *testing.T) {
 	}
 
 	require.NoError(t, err)
+	require.Contains(t, resp.GetSelectCharacterResponse().EventTopics, "GLOBAL")
+
+	for _, topic := range resp.GetSelectCharacterResponse().EventTopics {
+		require.NoError(t, client.Subscribe(topic))
+	}
 
 	event := requireEvent(t, time.Second)
 
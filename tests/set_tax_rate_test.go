@@ -0,0 +1,30 @@
+package tests
+
+import (
+	rpc "abbysoft/gardarike-online/rpc/generated"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSetTaxRate(t *testing.T) {
+	TestSelectCharacter(t)
+
+	var request rpc.Request
+	request.Data = &rpc.Request_SetTaxRateRequest{
+		SetTaxRateRequest: &rpc.SetTaxRateRequest{
+			SessionID: sessionID,
+			TaxRate:   30,
+		},
+	}
+
+	resp, err := client.SendRequest(request)
+
+	if !assert.NoError(t, err, "request error is not nil") {
+		return
+	}
+	if !assert.NotNil(t, resp.GetSetTaxRateResponse(), "response isn't a set tax rate response") {
+		return
+	}
+
+	assert.Equal(t, uint32(70), resp.GetSetTaxRateResponse().Happiness, "wrong happiness")
+}